@@ -45,6 +45,7 @@ const (
 	contentTypeField            = "content_type"
 	contentEncodingField        = "content_encoding"
 	metadataFilterField         = "metadata"
+	batchingField               = "batching"
 	priorityField               = "priority"
 	persistentField             = "persistent"
 	mandatoryField              = "mandatory"
@@ -41,7 +41,13 @@ It's possible for this output type to create the target exchange by setting `+"`
 
 TLS is automatic when connecting to an `+"`amqps`"+` URL, but custom settings can be enabled in the `+"`tls`"+` section.
 
-The fields 'key', 'exchange' and 'type' can be dynamically set using xref:configuration:interpolation.adoc#bloblang-queries[function interpolations].`).
+The fields 'key', 'exchange' and 'type' can be dynamically set using xref:configuration:interpolation.adoc#bloblang-queries[function interpolations].
+
+== Publisher confirms
+
+Messages are published with publisher confirms enabled, and a batch of messages is only acknowledged once the server has confirmed every message within it. Messages of a batch are published without waiting for the confirms of those before them, and any that are rejected by the server are reported as individual errors, which allows them to be handled with xref:configuration:error_handling.adoc[error handling patterns].
+
+When `+"`"+mandatoryField+"`"+` is set messages that cannot be routed to any queue are returned by the server, and a returned message is also considered failed rather than delivered.`).
 		Fields(
 			service.NewURLListField(urlsField).
 				Description("A list of URLs to connect to. The first URL to successfully establish a connection will be used until the connection is closed. If an item of the list contains commas it will be expanded into multiple URLs.").
@@ -118,7 +124,7 @@ The fields 'key', 'exchange' and 'type' can be dynamically set using xref:config
 				Advanced().
 				Default(false),
 			service.NewBoolField(mandatoryField).
-				Description("Whether to set the mandatory flag on published messages. When set if a published message is routed to zero queues it is returned.").
+				Description("Whether to set the mandatory flag on published messages. When set if a published message is routed to zero queues it is returned, and the message is considered failed.").
 				Advanced().
 				Default(false),
 			service.NewBoolField(immediateField).
@@ -130,17 +136,20 @@ The fields 'key', 'exchange' and 'type' can be dynamically set using xref:config
 				Advanced().
 				Default(""),
 			service.NewTLSToggledField(tlsField),
+			service.NewBatchPolicyField(batchingField),
 		)
 }
 
 func init() {
-	err := service.RegisterOutput("amqp_0_9", amqp09OutputSpec(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
-		maxInFlight, err := conf.FieldMaxInFlight()
-		if err != nil {
-			return nil, 0, err
+	err := service.RegisterBatchOutput("amqp_0_9", amqp09OutputSpec(), func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPol service.BatchPolicy, mif int, err error) {
+		if batchPol, err = conf.FieldBatchPolicy(batchingField); err != nil {
+			return
 		}
-		w, err := amqp09WriterFromParsed(conf, mgr)
-		return w, maxInFlight, err
+		if mif, err = conf.FieldMaxInFlight(); err != nil {
+			return
+		}
+		out, err = amqp09WriterFromParsed(conf, mgr)
+		return
 	})
 	if err != nil {
 		panic(err)
@@ -179,9 +188,9 @@ type amqp09Writer struct {
 
 	log *service.Logger
 
-	conn       *amqp.Connection
-	amqpChan   *amqp.Channel
-	returnChan <-chan amqp.Return
+	conn     *amqp.Connection
+	amqpChan *amqp.Channel
+	confirms *confirmTracker
 
 	connLock sync.RWMutex
 }
@@ -304,9 +313,7 @@ func (a *amqp09Writer) Connect(ctx context.Context) error {
 
 	a.conn = conn
 	a.amqpChan = amqpChan
-	if a.mandatory || a.immediate {
-		a.returnChan = amqpChan.NotifyReturn(make(chan amqp.Return, 1))
-	}
+	a.confirms = newConfirmTracker(amqpChan, a.mandatory || a.immediate)
 
 	if sExchange, isStatic := a.exchange.Static(); isStatic {
 		if err := a.declareExchange(sExchange); err != nil {
@@ -323,6 +330,7 @@ func (a *amqp09Writer) disconnect() error {
 
 	if a.amqpChan != nil {
 		a.amqpChan = nil
+		a.confirms = nil
 	}
 	if a.conn != nil {
 		if err := a.conn.Close(); err != nil {
@@ -370,94 +378,85 @@ func (a *amqp09Writer) declareExchange(exchange string) error {
 
 var errNoAck = errors.New("failed to receive acknowledgement")
 
-func (a *amqp09Writer) Write(ctx context.Context, msg *service.Message) error {
-	a.connLock.RLock()
-	conn := a.conn
-	amqpChan := a.amqpChan
-	returnChan := a.returnChan
-	a.connLock.RUnlock()
-
-	if conn == nil {
-		return service.ErrNotConnected
-	}
-
-	if a.timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, a.timeout)
-		defer cancel()
-	}
+// amqpPublishing is a message to be published along with its destination.
+type amqpPublishing struct {
+	exchange   string
+	bindingKey string
+	msg        amqp.Publishing
+}
 
+func (a *amqp09Writer) publishingFromMessage(msg *service.Message) (*amqpPublishing, error) {
 	msgBytes, err := msg.AsBytes()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	bindingKey, err := a.key.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("binding key interpolation error: %w", err)
+		return nil, fmt.Errorf("binding key interpolation error: %w", err)
 	}
 	bindingKey = strings.ReplaceAll(bindingKey, "/", ".")
 
 	msgType, err := a.msgType.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("msg type interpolation error: %w", err)
+		return nil, fmt.Errorf("msg type interpolation error: %w", err)
 	}
 	msgType = strings.ReplaceAll(msgType, "/", ".")
 
 	contentType, err := a.contentType.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("content type interpolation error: %w", err)
+		return nil, fmt.Errorf("content type interpolation error: %w", err)
 	}
 	contentEncoding, err := a.contentEncoding.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("content encoding interpolation error: %w", err)
+		return nil, fmt.Errorf("content encoding interpolation error: %w", err)
 	}
 
 	priorityString, err := a.priority.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("priority interpolation error: %w", err)
+		return nil, fmt.Errorf("priority interpolation error: %w", err)
 	}
 
 	var priority uint8
 	if priorityString != "" {
 		priorityInt, err := strconv.Atoi(priorityString)
 		if err != nil {
-			return fmt.Errorf("failed to parse valid integer from priority expression: %w", err)
+			return nil, fmt.Errorf("failed to parse valid integer from priority expression: %w", err)
 		}
 		if priorityInt > 9 || priorityInt < 0 {
-			return fmt.Errorf("invalid priority parsed from expression, must be <= 9 and >= 0, got %d", priorityInt)
+			return nil, fmt.Errorf("invalid priority parsed from expression, must be <= 9 and >= 0, got %d", priorityInt)
 		}
 		priority = uint8(priorityInt)
 	}
 
 	correlationID, err := a.correlationID.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("correlation ID interpolation error: %w", err)
+		return nil, fmt.Errorf("correlation ID interpolation error: %w", err)
 	}
 
 	replyTo, err := a.replyTo.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("reply to interpolation error: %w", err)
+		return nil, fmt.Errorf("reply to interpolation error: %w", err)
 	}
 
 	expiration, err := a.expiration.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("expiration interpolation error: %w", err)
+		return nil, fmt.Errorf("expiration interpolation error: %w", err)
 	}
 
 	messageID, err := a.messageID.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("message ID interpolation error: %w", err)
+		return nil, fmt.Errorf("message ID interpolation error: %w", err)
 	}
 
 	userID, err := a.userID.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("user ID interpolation error: %w", err)
+		return nil, fmt.Errorf("user ID interpolation error: %w", err)
 	}
 
 	appID, err := a.appID.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("app ID interpolation error: %w", err)
+		return nil, fmt.Errorf("app ID interpolation error: %w", err)
 	}
 	headers := amqp.Table{}
 	_ = a.metaFilter.WalkMut(msg, func(k string, v any) error {
@@ -467,19 +466,13 @@ func (a *amqp09Writer) Write(ctx context.Context, msg *service.Message) error {
 
 	exchange, err := a.exchange.TryString(msg)
 	if err != nil {
-		return fmt.Errorf("exchange name interpolation error: %w", err)
-	}
-	if err := a.declareExchange(exchange); err != nil {
-		return fmt.Errorf("amqp failed to declare exchange: %w", err)
+		return nil, fmt.Errorf("exchange name interpolation error: %w", err)
 	}
 
-	conf, err := amqpChan.PublishWithDeferredConfirmWithContext(
-		ctx,
-		exchange,    // publish to an exchange
-		bindingKey,  // routing to 0 or more queues
-		a.mandatory, // mandatory
-		a.immediate, // immediate
-		amqp.Publishing{
+	return &amqpPublishing{
+		exchange:   exchange,
+		bindingKey: bindingKey,
+		msg: amqp.Publishing{
 			Headers:         headers,
 			ContentType:     contentType,
 			ContentEncoding: contentEncoding,
@@ -495,26 +488,83 @@ func (a *amqp09Writer) Write(ctx context.Context, msg *service.Message) error {
 			UserId:          userID,
 			// a bunch of application/implementation-specific fields
 		},
-	)
-	if err != nil {
-		_ = a.disconnect()
-		a.log.Errorf("Failed to send message: %w", err)
+	}, nil
+}
+
+func (a *amqp09Writer) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	a.connLock.RLock()
+	conn := a.conn
+	amqpChan := a.amqpChan
+	confirms := a.confirms
+	a.connLock.RUnlock()
+
+	if conn == nil {
 		return service.ErrNotConnected
 	}
-	if !conf.Wait() {
-		a.log.Error("Failed to acknowledge message.")
-		return errNoAck
+
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+
+	var batchErr *service.BatchError
+	batchErrFailed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = service.NewBatchError(batch, err)
+		}
+		batchErr.Failed(i, err)
+	}
+
+	type publishResult struct {
+		index int
+		err   error
+	}
+	results := make(chan publishResult, len(batch))
+
+	var pending int
+	for i, msg := range batch {
+		pub, err := a.publishingFromMessage(msg)
+		if err != nil {
+			batchErrFailed(i, err)
+			continue
+		}
+		if err := a.declareExchange(pub.exchange); err != nil {
+			batchErrFailed(i, fmt.Errorf("amqp failed to declare exchange: %w", err))
+			continue
+		}
+
+		index := i
+		if err := confirms.publish(ctx, amqpChan, pub.exchange, pub.bindingKey, a.mandatory, a.immediate, pub.msg, func(err error) {
+			results <- publishResult{index: index, err: err}
+		}); err != nil {
+			_ = a.disconnect()
+			a.log.Errorf("Failed to send message: %v", err)
+			return service.ErrNotConnected
+		}
+		pending++
 	}
-	if returnChan != nil {
+
+	for ; pending > 0; pending-- {
 		select {
-		case _, open := <-returnChan:
-			if !open {
-				return errors.New("acknowledgement not supported, ensure server supports immediate and mandatory flags")
+		case res := <-results:
+			if res.err == nil {
+				continue
 			}
-			return errNoAck
-		default:
+			if errors.Is(res.err, errConfirmsClosed) {
+				_ = a.disconnect()
+				return service.ErrNotConnected
+			}
+			a.log.Debugf("Failed to deliver message: %v", res.err)
+			batchErrFailed(res.index, res.err)
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
+
+	if batchErr != nil {
+		return batchErr
+	}
 	return nil
 }
 
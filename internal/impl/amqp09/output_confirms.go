@@ -0,0 +1,206 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqp09
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var errConfirmsClosed = errors.New("channel closed before the publishing was confirmed")
+
+// returnedError describes a publishing that was returned by the server as it
+// could not be routed.
+type returnedError struct {
+	code uint16
+	text string
+}
+
+func (e *returnedError) Error() string {
+	return fmt.Sprintf("message was returned by the server with code %v: %v", e.code, e.text)
+}
+
+// pendingPublish is a publishing awaiting confirmation from the server.
+type pendingPublish struct {
+	tag      uint64
+	exchange string
+	key      string
+	returned *amqp.Return
+	done     func(err error)
+}
+
+// confirmTracker correlates the confirms and returns of a channel in confirm
+// mode with the publishings they refer to.
+//
+// The server sends a basic.return for an unroutable publishing before the
+// basic.ack that confirms it, but returns do not carry a delivery tag. They are
+// therefore matched to the earliest pending publishing with the same exchange
+// and routing key, which is sound as routing is otherwise deterministic.
+type confirmTracker struct {
+	publishMut sync.Mutex
+
+	mut     sync.Mutex
+	pending []*pendingPublish
+	closed  bool
+}
+
+// newConfirmTracker creates a tracker for a channel that is already in confirm
+// mode, returned publishings are only tracked when withReturns is true.
+func newConfirmTracker(amqpChan *amqp.Channel, withReturns bool) *confirmTracker {
+	t := &confirmTracker{}
+
+	confirms := amqpChan.NotifyPublish(make(chan amqp.Confirmation, 64))
+	var returns chan amqp.Return
+	if withReturns {
+		returns = amqpChan.NotifyReturn(make(chan amqp.Return, 64))
+	}
+	go t.loop(confirms, returns)
+	return t
+}
+
+func (t *confirmTracker) loop(confirms <-chan amqp.Confirmation, returns <-chan amqp.Return) {
+	// Returns must be processed before the confirms that follow them, and so
+	// any that are buffered are drained before handling each confirm.
+	drainReturns := func() {
+		for {
+			select {
+			case ret, open := <-returns:
+				if !open {
+					returns = nil
+					return
+				}
+				t.handleReturn(ret)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case ret, open := <-returns:
+			if !open {
+				returns = nil
+				continue
+			}
+			t.handleReturn(ret)
+		case conf, open := <-confirms:
+			if !open {
+				t.close()
+				return
+			}
+			drainReturns()
+			t.handleConfirm(conf)
+		}
+	}
+}
+
+func (t *confirmTracker) handleReturn(ret amqp.Return) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	for _, p := range t.pending {
+		if p.returned == nil && p.exchange == ret.Exchange && p.key == ret.RoutingKey {
+			p.returned = &ret
+			return
+		}
+	}
+}
+
+func (t *confirmTracker) handleConfirm(conf amqp.Confirmation) {
+	p := t.remove(conf.DeliveryTag)
+	if p == nil {
+		return
+	}
+	switch {
+	case !conf.Ack:
+		p.done(errNoAck)
+	case p.returned != nil:
+		p.done(&returnedError{code: p.returned.ReplyCode, text: p.returned.ReplyText})
+	default:
+		p.done(nil)
+	}
+}
+
+func (t *confirmTracker) remove(tag uint64) *pendingPublish {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	for i, p := range t.pending {
+		if p.tag == tag {
+			t.pending = append(t.pending[:i], t.pending[i+1:]...)
+			return p
+		}
+	}
+	return nil
+}
+
+func (t *confirmTracker) close() {
+	t.mut.Lock()
+	pending := t.pending
+	t.pending = nil
+	t.closed = true
+	t.mut.Unlock()
+
+	for _, p := range pending {
+		p.done(errConfirmsClosed)
+	}
+}
+
+// publish sends a publishing to the channel, the provided done func is called
+// once the server has confirmed or rejected it. An error is returned if the
+// publishing could not be sent, in which case done is never called.
+func (t *confirmTracker) publish(
+	ctx context.Context,
+	amqpChan *amqp.Channel,
+	exchange, key string,
+	mandatory, immediate bool,
+	msg amqp.Publishing,
+	done func(err error),
+) error {
+	// Publishing is serialised so that the delivery tag of each publishing can
+	// be registered before it is sent, otherwise its confirm could arrive
+	// before we know about it.
+	t.publishMut.Lock()
+	defer t.publishMut.Unlock()
+
+	p := &pendingPublish{
+		tag:      amqpChan.GetNextPublishSeqNo(),
+		exchange: exchange,
+		key:      key,
+		done:     done,
+	}
+
+	t.mut.Lock()
+	if t.closed {
+		t.mut.Unlock()
+		return errConfirmsClosed
+	}
+	t.pending = append(t.pending, p)
+	t.mut.Unlock()
+
+	if err := amqpChan.PublishWithContext(ctx, exchange, key, mandatory, immediate, msg); err != nil {
+		if t.remove(p.tag) == nil {
+			// The channel has closed and done has already been called.
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqp09
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmTrackerResults(t *testing.T) {
+	tracker := &confirmTracker{}
+
+	results := map[uint64]chan error{}
+	for i, route := range [][2]string{
+		{"foo", "a"},
+		{"foo", "b"},
+		{"foo", "b"},
+		{"bar", "a"},
+	} {
+		tag := uint64(i + 1)
+		resChan := make(chan error, 1)
+		results[tag] = resChan
+		tracker.pending = append(tracker.pending, &pendingPublish{
+			tag:      tag,
+			exchange: route[0],
+			key:      route[1],
+			done: func(err error) {
+				resChan <- err
+			},
+		})
+	}
+
+	confirms := make(chan amqp.Confirmation, 10)
+	returns := make(chan amqp.Return, 10)
+	loopDone := make(chan struct{})
+	go func() {
+		tracker.loop(confirms, returns)
+		close(loopDone)
+	}()
+
+	// Returns are buffered ahead of the confirms they precede.
+	returns <- amqp.Return{Exchange: "foo", RoutingKey: "b", ReplyCode: 312, ReplyText: "NO_ROUTE"}
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+	confirms <- amqp.Confirmation{DeliveryTag: 3, Ack: true}
+	confirms <- amqp.Confirmation{DeliveryTag: 4, Ack: false}
+
+	waitResult := func(tag uint64) error {
+		t.Helper()
+		select {
+		case err := <-results[tag]:
+			return err
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for result of tag %v", tag)
+		}
+		return nil
+	}
+
+	assert.NoError(t, waitResult(1))
+	assert.EqualError(t, waitResult(2), "message was returned by the server with code 312: NO_ROUTE")
+	assert.NoError(t, waitResult(3))
+	assert.ErrorIs(t, waitResult(4), errNoAck)
+
+	close(confirms)
+	select {
+	case <-loopDone:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for loop to exit")
+	}
+}
+
+func TestConfirmTrackerClose(t *testing.T) {
+	tracker := &confirmTracker{}
+
+	resChan := make(chan error, 1)
+	tracker.pending = append(tracker.pending, &pendingPublish{
+		tag: 1,
+		done: func(err error) {
+			resChan <- err
+		},
+	})
+
+	confirms := make(chan amqp.Confirmation)
+	close(confirms)
+	tracker.loop(confirms, nil)
+
+	select {
+	case err := <-resChan:
+		assert.ErrorIs(t, err, errConfirmsClosed)
+	default:
+		t.Fatal("expected pending publishing to be resolved")
+	}
+	require.True(t, tracker.closed)
+	assert.Empty(t, tracker.pending)
+}
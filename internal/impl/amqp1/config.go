@@ -37,6 +37,13 @@ const (
 	azureRenewLockField   = "azure_renew_lock"
 	getMessageHeaderField = "read_header"
 	creditField           = "credit"
+	nackModeField         = "nack_mode"
+	rejectConditionField  = "reject_condition"
+	maxDeliveryCountField = "max_delivery_count"
+	sessionField          = "session"
+	sessionEnabledField   = "enabled"
+	sessionIDField        = "session_id"
+	sessionIdleField      = "idle_timeout"
 
 	// Output
 	targetAddrField  = "target_address"
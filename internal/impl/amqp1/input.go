@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/go-amqp"
@@ -74,6 +75,41 @@ func amqp1InputSpec() *service.ConfigSpec {
 				Version("4.26.0").
 				Default(64).
 				Advanced(),
+			service.NewStringAnnotatedEnumField(nackModeField, map[string]string{
+				nackModeRelease:                   "Release the message back to the broker unmodified, this does not count as a failed delivery attempt.",
+				nackModeReject:                    "Reject the message with an error condition, brokers that support it will move the message to a dead letter queue.",
+				nackModeModifyFailed:              "Modify the message with `delivery-failed` set, which increments its delivery count before it is redelivered.",
+				nackModeModifyFailedUndeliverable: "Modify the message with both `delivery-failed` and `undeliverable-here` set, which prevents it from being redelivered to this consumer.",
+			}).
+				Description("How messages are settled when they are rejected downstream (nacked).").
+				Version("4.33.0").
+				Default(nackModeModifyFailed).
+				Advanced(),
+			service.NewStringField(rejectConditionField).
+				Description("The error condition set when rejecting a message, the description of the error is the reason the message was nacked.").
+				Version("4.33.0").
+				Default(string(amqp.ErrCondInternalError)).
+				Advanced(),
+			service.NewIntField(maxDeliveryCountField).
+				Description("When set to a value greater than zero a nacked message that has reached this number of delivery attempts is rejected rather than settled according to `"+nackModeField+"`, allowing the broker to dead-letter it. The delivery attempts of a message are derived from the delivery count of its header, which is only incremented by brokers when a message is modified with `delivery-failed` or when its lock expires.").
+				LintRule(`root = if this < 0 { [ "`+maxDeliveryCountField+` must not be negative" ] }`).
+				Version("4.33.0").
+				Default(0).
+				Advanced(),
+			service.NewObjectField(sessionField,
+				service.NewBoolField(sessionEnabledField).
+					Description("Whether to consume from a session-enabled queue.").
+					Default(false),
+				service.NewStringField(sessionIDField).
+					Description("A specific session to lock. When empty the broker assigns the next available session.").
+					Default(""),
+				service.NewDurationField(sessionIdleField).
+					Description("When consuming the next available session, the period of time after which an idle session is released in favour of the next one. Set to `0s` in order to keep the session locked until the connection is closed.").
+					Default("1m"),
+			).
+				Description("Consume from a session-enabled queue, such as an Azure Service Bus queue or subscription with sessions enabled. A single session is locked per connection, and its ID is added to each message as the metadata field `amqp_session_id`.").
+				Version("4.33.0").
+				Advanced(),
 			service.NewTLSToggledField(tlsField),
 			saslFieldSpec(),
 		).LintRule(`
@@ -95,14 +131,32 @@ func init() {
 
 //------------------------------------------------------------------------------
 
+const (
+	nackModeRelease                   = "release"
+	nackModeReject                    = "reject"
+	nackModeModifyFailed              = "modify_failed"
+	nackModeModifyFailedUndeliverable = "modify_failed_undeliverable"
+)
+
+const (
+	sessionFilterName = "com.microsoft:session-filter"
+	sessionFilterCode = uint64(0x00000137000000C)
+)
+
 type amqp1Reader struct {
-	urls       []string
-	sourceAddr string
-	renewLock  bool
-	getHeader  bool
-	credit     int // max_in_flight
-	connOpts   *amqp.ConnOptions
-	log        *service.Logger
+	urls             []string
+	sourceAddr       string
+	renewLock        bool
+	getHeader        bool
+	credit           int // max_in_flight
+	nackMode         string
+	rejectCondition  amqp.ErrCond
+	maxDeliveryCount int
+	sessionEnabled   bool
+	sessionID        string
+	sessionIdle      time.Duration
+	connOpts         *amqp.ConnOptions
+	log              *service.Logger
 
 	m    sync.RWMutex
 	conn *amqp1Conn
@@ -154,6 +208,33 @@ func amqp1ReaderFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (
 		return nil, err
 	}
 
+	if a.nackMode, err = conf.FieldString(nackModeField); err != nil {
+		return nil, err
+	}
+
+	var rejectCondition string
+	if rejectCondition, err = conf.FieldString(rejectConditionField); err != nil {
+		return nil, err
+	}
+	a.rejectCondition = amqp.ErrCond(rejectCondition)
+
+	if a.maxDeliveryCount, err = conf.FieldInt(maxDeliveryCountField); err != nil {
+		return nil, err
+	}
+
+	sConf := conf.Namespace(sessionField)
+	if a.sessionEnabled, err = sConf.FieldBool(sessionEnabledField); err != nil {
+		return nil, err
+	}
+	if a.sessionEnabled {
+		if a.sessionID, err = sConf.FieldString(sessionIDField); err != nil {
+			return nil, err
+		}
+		if a.sessionIdle, err = sConf.FieldDuration(sessionIdleField); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := saslOptFnsFromParsed(conf, a.connOpts); err != nil {
 		return nil, err
 	}
@@ -180,6 +261,7 @@ func (a *amqp1Reader) Connect(ctx context.Context) (err error) {
 	conn := &amqp1Conn{
 		log:                    a.log,
 		lockRenewAddressPrefix: randomString(15),
+		closed:                 make(chan struct{}),
 	}
 
 	// Create client
@@ -194,13 +276,33 @@ func (a *amqp1Reader) Connect(ctx context.Context) (err error) {
 	}
 
 	// Create a receiver
-	if conn.receiver, err = conn.session.NewReceiver(ctx, a.sourceAddr, &amqp.ReceiverOptions{
+	recvOpts := &amqp.ReceiverOptions{
 		Credit: int32(a.credit),
-	}); err != nil {
+	}
+	if a.sessionEnabled {
+		// A nil filter value asks the broker to lock the next available session.
+		var sessionFilterValue any
+		if a.sessionID != "" {
+			sessionFilterValue = a.sessionID
+		}
+		recvOpts.Filters = []amqp.LinkFilter{
+			amqp.NewLinkFilter(sessionFilterName, sessionFilterCode, sessionFilterValue),
+		}
+	}
+	if conn.receiver, err = conn.session.NewReceiver(ctx, a.sourceAddr, recvOpts); err != nil {
 		_ = conn.Close(ctx)
 		return
 	}
 
+	if a.sessionEnabled {
+		// The broker responds with the session that was locked.
+		if conn.sessionID, _ = conn.receiver.LinkSourceFilterValue(sessionFilterName).(string); conn.sessionID == "" {
+			_ = conn.Close(ctx)
+			return errors.New("broker did not lock a session")
+		}
+		a.log.Debugf("Locked session %v", conn.sessionID)
+	}
+
 	if a.renewLock {
 		managementAddress := a.sourceAddr + "/$management"
 
@@ -218,6 +320,10 @@ func (a *amqp1Reader) Connect(ctx context.Context) (err error) {
 		}
 	}
 
+	if a.renewLock && a.sessionEnabled {
+		a.startSessionRenewJob(conn)
+	}
+
 	a.conn = conn
 	return nil
 }
@@ -243,15 +349,11 @@ func (a *amqp1Reader) ReadBatch(ctx context.Context) (service.MessageBatch, serv
 	}
 
 	// Receive next message
-	amqpMsg, err := conn.receiver.Receive(ctx, nil)
+	amqpMsg, err := a.receive(ctx, conn)
 	if err != nil {
-		if ctx.Err() == nil {
-			a.log.Errorf("Lost connection due to: %v", err)
-			_ = a.disconnect(ctx)
-			err = service.ErrNotConnected
-		}
 		return nil, nil, err
 	}
+	conn.pending.Add(1)
 
 	var part *service.Message
 
@@ -286,8 +388,14 @@ func (a *amqp1Reader) ReadBatch(ctx context.Context) (service.MessageBatch, serv
 		}
 	}
 
+	if conn.sessionID != "" {
+		amqpSetMetadata(part, "amqp_session_id", conn.sessionID)
+	}
+
+	// Messages of a locked session are covered by the session lock, which is
+	// renewed for the lifetime of the connection instead.
 	var done chan struct{}
-	if a.renewLock {
+	if a.renewLock && !a.sessionEnabled {
 		done = a.startRenewJob(amqpMsg)
 	}
 
@@ -296,20 +404,91 @@ func (a *amqp1Reader) ReadBatch(ctx context.Context) (service.MessageBatch, serv
 			close(done)
 			done = nil
 		}
+		defer conn.pending.Add(-1)
 
-		// TODO: These methods were moved in v0.16.0, but nacking seems broken
-		// (integration tests fail)
 		if res != nil {
-			return conn.receiver.ModifyMessage(ctx, amqpMsg, &amqp.ModifyMessageOptions{
-				DeliveryFailed:    true,
-				UndeliverableHere: false,
-				Annotations:       amqpMsg.Annotations,
-			})
+			return a.nack(ctx, conn.receiver, amqpMsg, res)
 		}
 		return conn.receiver.AcceptMessage(ctx, amqpMsg)
 	}, nil
 }
 
+// receive blocks until the next message arrives. When consuming the next
+// available session the connection is closed once the session has been idle
+// for the configured period and has no pending messages, so that the next
+// connection locks another session.
+func (a *amqp1Reader) receive(ctx context.Context, conn *amqp1Conn) (*amqp.Message, error) {
+	for {
+		rctx, done := ctx, func() {}
+		rotate := a.sessionEnabled && a.sessionID == "" && a.sessionIdle > 0
+		if rotate {
+			rctx, done = context.WithTimeout(ctx, a.sessionIdle)
+		}
+
+		amqpMsg, err := conn.receiver.Receive(rctx, nil)
+		done()
+		if err == nil {
+			return amqpMsg, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if rotate && errors.Is(err, context.DeadlineExceeded) {
+			if conn.pending.Load() > 0 {
+				continue
+			}
+			a.log.Debugf("Releasing idle session %v", conn.sessionID)
+			_ = a.disconnect(ctx)
+			return nil, service.ErrNotConnected
+		}
+
+		a.log.Errorf("Lost connection due to: %v", err)
+		_ = a.disconnect(ctx)
+		return nil, service.ErrNotConnected
+	}
+}
+
+// nackDisposition returns how a message that was rejected downstream should be
+// settled.
+func (a *amqp1Reader) nackDisposition(msg *amqp.Message) string {
+	if a.maxDeliveryCount > 0 {
+		// The delivery count of a message is the number of prior unsuccessful
+		// delivery attempts.
+		attempts := 1
+		if msg.Header != nil {
+			attempts += int(msg.Header.DeliveryCount)
+		}
+		if attempts >= a.maxDeliveryCount {
+			return nackModeReject
+		}
+	}
+	return a.nackMode
+}
+
+func (a *amqp1Reader) nack(ctx context.Context, receiver *amqp.Receiver, msg *amqp.Message, res error) error {
+	switch a.nackDisposition(msg) {
+	case nackModeRelease:
+		return receiver.ReleaseMessage(ctx, msg)
+	case nackModeReject:
+		return receiver.RejectMessage(ctx, msg, &amqp.Error{
+			Condition:   a.rejectCondition,
+			Description: res.Error(),
+		})
+	case nackModeModifyFailedUndeliverable:
+		return receiver.ModifyMessage(ctx, msg, &amqp.ModifyMessageOptions{
+			DeliveryFailed:    true,
+			UndeliverableHere: true,
+			Annotations:       msg.Annotations,
+		})
+	default:
+		return receiver.ModifyMessage(ctx, msg, &amqp.ModifyMessageOptions{
+			DeliveryFailed:    true,
+			UndeliverableHere: false,
+			Annotations:       msg.Annotations,
+		})
+	}
+}
+
 func (a *amqp1Reader) Close(ctx context.Context) error {
 	return a.disconnect(ctx)
 }
@@ -345,9 +524,17 @@ type amqp1Conn struct {
 
 	log                    *service.Logger
 	lockRenewAddressPrefix string
+
+	sessionID string
+	pending   atomic.Int64
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 func (c *amqp1Conn) Close(ctx context.Context) error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
 	if c.renewLockSender != nil {
 		if err := c.renewLockSender.Close(ctx); err != nil {
 			c.log.Errorf("Failed to cleanly close renew lock sender: %v\n", err)
@@ -545,3 +732,74 @@ func amqpSetMetadata(p *service.Message, k string, v any) {
 		p.MetaSetMut(metaKey, metaValue)
 	}
 }
+
+// startSessionRenewJob renews the lock of the session held by a connection
+// until it is closed.
+func (a *amqp1Reader) startSessionRenewJob(conn *amqp1Conn) {
+	go func() {
+		ctx, done := context.WithCancel(context.Background())
+		defer done()
+		go func() {
+			<-conn.closed
+			done()
+		}()
+
+		// The initial expiry of the lock is not known and so it is renewed
+		// immediately.
+		var wait time.Duration
+		for {
+			select {
+			case <-conn.closed:
+				return
+			case <-time.After(wait):
+				lockedUntil, err := a.renewSessionWithContext(ctx, conn)
+				if err != nil {
+					if ctx.Err() == nil {
+						a.log.Errorf("Unable to renew session lock err: %v", err)
+					}
+					return
+				}
+				a.log.Tracef("Renewed session lock until %v", lockedUntil)
+				wait = time.Until(lockedUntil) / 10 * 9
+			}
+		}
+	}()
+}
+
+func (a *amqp1Reader) renewSessionWithContext(ctx context.Context, conn *amqp1Conn) (time.Time, error) {
+	replyTo := conn.lockRenewAddressPrefix + lockRenewResponseSuffix
+	renewMsg := &amqp.Message{
+		Properties: &amqp.MessageProperties{
+			ReplyTo: &replyTo,
+		},
+		ApplicationProperties: map[string]any{
+			"operation": "com.microsoft:renew-session-lock",
+		},
+		Value: map[string]any{
+			"session-id": conn.sessionID,
+		},
+	}
+
+	if err := conn.renewLockSender.Send(ctx, renewMsg, nil); err != nil {
+		return time.Time{}, err
+	}
+
+	result, err := conn.renewLockReceiver.Receive(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if statusCode, ok := result.ApplicationProperties["statusCode"].(int32); !ok || statusCode != 200 {
+		return time.Time{}, fmt.Errorf("unsuccessful status code %d, message %s", statusCode, result.ApplicationProperties["statusDescription"])
+	}
+
+	values, ok := result.Value.(map[string]any)
+	if !ok {
+		return time.Time{}, errors.New("missing value in response message")
+	}
+
+	expiration, ok := values["expiration"].(time.Time)
+	if !ok {
+		return time.Time{}, errors.New("missing expiration field in response message values")
+	}
+	return expiration, nil
+}
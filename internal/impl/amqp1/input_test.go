@@ -0,0 +1,83 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqp1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestAMQP1InputSettlementConfig(t *testing.T) {
+	conf, err := amqp1InputSpec().ParseYAML(`
+urls: [ amqp://localhost:5672/ ]
+source_address: queue:/foo
+`, nil)
+	require.NoError(t, err)
+
+	r, err := amqp1ReaderFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	assert.Equal(t, nackModeModifyFailed, r.nackMode)
+	assert.Equal(t, amqp.ErrCondInternalError, r.rejectCondition)
+	assert.Equal(t, 0, r.maxDeliveryCount)
+	assert.False(t, r.sessionEnabled)
+
+	conf, err = amqp1InputSpec().ParseYAML(`
+urls: [ amqp://localhost:5672/ ]
+source_address: queue:/foo
+nack_mode: release
+reject_condition: amqp:precondition-failed
+max_delivery_count: 5
+session:
+  enabled: true
+  idle_timeout: 10s
+`, nil)
+	require.NoError(t, err)
+
+	r, err = amqp1ReaderFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	assert.Equal(t, nackModeRelease, r.nackMode)
+	assert.Equal(t, amqp.ErrCondPreconditionFailed, r.rejectCondition)
+	assert.Equal(t, 5, r.maxDeliveryCount)
+	assert.True(t, r.sessionEnabled)
+	assert.Equal(t, "", r.sessionID)
+	assert.Equal(t, 10*time.Second, r.sessionIdle)
+}
+
+func TestAMQP1InputNackDisposition(t *testing.T) {
+	withCount := func(n uint32) *amqp.Message {
+		return &amqp.Message{Header: &amqp.MessageHeader{DeliveryCount: n}}
+	}
+
+	r := &amqp1Reader{nackMode: nackModeModifyFailedUndeliverable}
+	assert.Equal(t, nackModeModifyFailedUndeliverable, r.nackDisposition(&amqp.Message{}))
+	assert.Equal(t, nackModeModifyFailedUndeliverable, r.nackDisposition(withCount(100)))
+
+	r.maxDeliveryCount = 3
+	assert.Equal(t, nackModeModifyFailedUndeliverable, r.nackDisposition(&amqp.Message{}))
+	assert.Equal(t, nackModeModifyFailedUndeliverable, r.nackDisposition(withCount(1)))
+	assert.Equal(t, nackModeReject, r.nackDisposition(withCount(2)))
+	assert.Equal(t, nackModeReject, r.nackDisposition(withCount(10)))
+
+	r.maxDeliveryCount = 1
+	assert.Equal(t, nackModeReject, r.nackDisposition(&amqp.Message{}))
+}
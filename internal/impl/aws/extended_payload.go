@@ -0,0 +1,279 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gofrs/uuid"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	// Extended Payload Fields
+	epFieldExtendedPayload = "extended_payload"
+	epFieldEnabled         = "enabled"
+	epFieldBucket          = "bucket"
+	epFieldKeyPrefix       = "key_prefix"
+	epFieldThreshold       = "threshold"
+	epFieldAlwaysThroughS3 = "always_through_s3"
+	epFieldDeleteObject    = "delete_object"
+	epFieldForcePathStyle  = "force_path_style_urls"
+
+	// The pointer format and attributes used by the AWS extended clients, see
+	// https://github.com/awslabs/payload-offloading-java-common-lib-for-aws
+	epPointerClass          = "software.amazon.payloadoffloading.PayloadS3Pointer"
+	epSizeAttribute         = "ExtendedPayloadSize"
+	epLegacySizeAttribute   = "SQSLargePayloadSize"
+	epSizeAttributeDataType = "Number"
+	epDefaultThreshold      = 262144
+)
+
+func extendedPayloadOutputField() *service.ConfigField {
+	return service.NewObjectField(epFieldExtendedPayload,
+		service.NewBoolField(epFieldEnabled).
+			Description("Whether payloads that exceed the `threshold` are offloaded to S3.").
+			Default(false),
+		service.NewStringField(epFieldBucket).
+			Description("The S3 bucket to store offloaded payloads in.").
+			Default(""),
+		service.NewStringField(epFieldKeyPrefix).
+			Description("A prefix to add to the keys of offloaded payloads, which are otherwise random UUIDs.").
+			Default("").
+			Example("offloaded/"),
+		service.NewIntField(epFieldThreshold).
+			Description("The size in bytes of a message, including its attributes, above which its payload is offloaded.").
+			Default(epDefaultThreshold),
+		service.NewBoolField(epFieldAlwaysThroughS3).
+			Description("Whether to offload all payloads regardless of their size.").
+			Default(false),
+		service.NewBoolField(epFieldForcePathStyle).
+			Description("Forces the client API to use path style URLs when accessing S3.").
+			Default(false),
+	).
+		Description("Offloads large payloads to S3 and sends a pointer to the object in their place, using the same format as the AWS extended client libraries. This allows payloads larger than the limit of the service to be sent, which can then be consumed by an `aws_sqs` input with `extended_payload` enabled, or by any consumer that uses an extended client library.").
+		Version("4.33.0").
+		Advanced()
+}
+
+func extendedPayloadInputField() *service.ConfigField {
+	return service.NewObjectField(epFieldExtendedPayload,
+		service.NewBoolField(epFieldEnabled).
+			Description("Whether messages that point to a payload offloaded to S3 are replaced with the contents of the payload.").
+			Default(false),
+		service.NewBoolField(epFieldDeleteObject).
+			Description("Whether to delete offloaded payloads from S3 once the message that points to them has been deleted.").
+			Default(false),
+		service.NewBoolField(epFieldForcePathStyle).
+			Description("Forces the client API to use path style URLs when accessing S3.").
+			Default(false),
+	).
+		Description("Resolves payloads that were offloaded to S3 by an `aws_sqs` or `aws_sns` output with `extended_payload` enabled, or by a producer that uses an AWS extended client library.").
+		Version("4.33.0").
+		Advanced()
+}
+
+type extendedPayloadConfig struct {
+	Enabled         bool
+	Bucket          string
+	KeyPrefix       string
+	Threshold       int
+	AlwaysThroughS3 bool
+	DeleteObject    bool
+	UsePathStyle    bool
+}
+
+func extendedPayloadOutputConfigFromParsed(pConf *service.ParsedConfig) (conf extendedPayloadConfig, err error) {
+	pConf = pConf.Namespace(epFieldExtendedPayload)
+	if conf.Enabled, err = pConf.FieldBool(epFieldEnabled); err != nil || !conf.Enabled {
+		return
+	}
+	if conf.Bucket, err = pConf.FieldString(epFieldBucket); err != nil {
+		return
+	}
+	if conf.KeyPrefix, err = pConf.FieldString(epFieldKeyPrefix); err != nil {
+		return
+	}
+	if conf.Threshold, err = pConf.FieldInt(epFieldThreshold); err != nil {
+		return
+	}
+	if conf.AlwaysThroughS3, err = pConf.FieldBool(epFieldAlwaysThroughS3); err != nil {
+		return
+	}
+	if conf.UsePathStyle, err = pConf.FieldBool(epFieldForcePathStyle); err != nil {
+		return
+	}
+	if conf.Bucket == "" {
+		err = errors.New("an extended_payload.bucket must be specified when extended payloads are enabled")
+	}
+	return
+}
+
+func extendedPayloadInputConfigFromParsed(pConf *service.ParsedConfig) (conf extendedPayloadConfig, err error) {
+	pConf = pConf.Namespace(epFieldExtendedPayload)
+	if conf.Enabled, err = pConf.FieldBool(epFieldEnabled); err != nil || !conf.Enabled {
+		return
+	}
+	if conf.DeleteObject, err = pConf.FieldBool(epFieldDeleteObject); err != nil {
+		return
+	}
+	if conf.UsePathStyle, err = pConf.FieldBool(epFieldForcePathStyle); err != nil {
+		return
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// s3PayloadPointer identifies a payload that has been offloaded to S3.
+type s3PayloadPointer struct {
+	Bucket string `json:"s3BucketName"`
+	Key    string `json:"s3Key"`
+}
+
+// marshalS3PayloadPointer returns the message body that points to an offloaded
+// payload, which is a JSON array of the pointer class name and the pointer.
+func marshalS3PayloadPointer(p s3PayloadPointer) (string, error) {
+	b, err := json.Marshal([]any{epPointerClass, p})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parseS3PayloadPointer attempts to parse a message body as a pointer to an
+// offloaded payload.
+func parseS3PayloadPointer(body string) (s3PayloadPointer, error) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal([]byte(body), &parts); err != nil {
+		return s3PayloadPointer{}, fmt.Errorf("failed to parse payload pointer: %w", err)
+	}
+	if len(parts) != 2 {
+		return s3PayloadPointer{}, errors.New("failed to parse payload pointer: expected an array of two elements")
+	}
+
+	var class string
+	if err := json.Unmarshal(parts[0], &class); err != nil || class != epPointerClass {
+		return s3PayloadPointer{}, fmt.Errorf("failed to parse payload pointer: unexpected class %s", parts[0])
+	}
+
+	var p s3PayloadPointer
+	if err := json.Unmarshal(parts[1], &p); err != nil {
+		return s3PayloadPointer{}, fmt.Errorf("failed to parse payload pointer: %w", err)
+	}
+	if p.Bucket == "" || p.Key == "" {
+		return s3PayloadPointer{}, errors.New("failed to parse payload pointer: missing bucket or key")
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+type s3PayloadAPI interface {
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	DeleteObject(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// s3PayloadStore offloads payloads to and resolves payloads from S3.
+type s3PayloadStore struct {
+	conf extendedPayloadConfig
+	s3   s3PayloadAPI
+}
+
+func newS3PayloadStore(conf extendedPayloadConfig, aconf aws.Config) *s3PayloadStore {
+	if !conf.Enabled {
+		return nil
+	}
+	return &s3PayloadStore{
+		conf: conf,
+		s3: s3.NewFromConfig(aconf, func(o *s3.Options) {
+			o.UsePathStyle = conf.UsePathStyle
+		}),
+	}
+}
+
+// shouldOffload returns true if a message with the provided payload and
+// attributes size should be offloaded.
+func (s *s3PayloadStore) shouldOffload(payload []byte, attributesSize int) bool {
+	if s == nil {
+		return false
+	}
+	return s.conf.AlwaysThroughS3 || len(payload)+attributesSize > s.conf.Threshold
+}
+
+// offload writes a payload to S3 and returns its pointer along with the
+// message body that points to it.
+func (s *s3PayloadStore) offload(ctx context.Context, payload []byte) (s3PayloadPointer, string, error) {
+	u4, err := uuid.NewV4()
+	if err != nil {
+		return s3PayloadPointer{}, "", err
+	}
+
+	p := s3PayloadPointer{
+		Bucket: s.conf.Bucket,
+		Key:    s.conf.KeyPrefix + u4.String(),
+	}
+	if _, err := s.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &p.Bucket,
+		Key:           &p.Key,
+		Body:          bytes.NewReader(payload),
+		ContentLength: aws.Int64(int64(len(payload))),
+	}); err != nil {
+		return s3PayloadPointer{}, "", fmt.Errorf("failed to offload payload to S3: %w", err)
+	}
+	body, err := marshalS3PayloadPointer(p)
+	if err != nil {
+		return s3PayloadPointer{}, "", err
+	}
+	return p, body, nil
+}
+
+// resolve reads an offloaded payload from S3.
+func (s *s3PayloadStore) resolve(ctx context.Context, p s3PayloadPointer) ([]byte, error) {
+	res, err := s.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &p.Bucket,
+		Key:    &p.Key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch offloaded payload s3://%v/%v: %w", p.Bucket, p.Key, err)
+	}
+	defer res.Body.Close()
+
+	payload, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offloaded payload s3://%v/%v: %w", p.Bucket, p.Key, err)
+	}
+	return payload, nil
+}
+
+// remove deletes an offloaded payload from S3.
+func (s *s3PayloadStore) remove(ctx context.Context, p s3PayloadPointer) error {
+	if _, err := s.s3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &p.Bucket,
+		Key:    &p.Key,
+	}); err != nil {
+		return fmt.Errorf("failed to delete offloaded payload s3://%v/%v: %w", p.Bucket, p.Key, err)
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockS3Payloads struct {
+	mut     sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *mockS3Payloads) PutObject(ctx context.Context, input *s3.PutObjectInput, opts ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	b, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.mut.Lock()
+	m.objects[*input.Bucket+"/"+*input.Key] = b
+	m.mut.Unlock()
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3Payloads) GetObject(ctx context.Context, input *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.mut.Lock()
+	b, exists := m.objects[*input.Bucket+"/"+*input.Key]
+	m.mut.Unlock()
+	if !exists {
+		return nil, errors.New("object not found")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(b))}, nil
+}
+
+func (m *mockS3Payloads) DeleteObject(ctx context.Context, input *s3.DeleteObjectInput, opts ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	m.mut.Lock()
+	delete(m.objects, *input.Bucket+"/"+*input.Key)
+	m.mut.Unlock()
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (m *mockS3Payloads) len() int {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return len(m.objects)
+}
+
+func TestS3PayloadPointer(t *testing.T) {
+	// A pointer as produced by the Java extended client libraries.
+	javaPointer := `["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"foo-bucket","s3Key":"2ab3c4d5-ffff-4f0e-9b1e-8c0d1e2f3a4b"}]`
+
+	p, err := parseS3PayloadPointer(javaPointer)
+	require.NoError(t, err)
+	assert.Equal(t, s3PayloadPointer{Bucket: "foo-bucket", Key: "2ab3c4d5-ffff-4f0e-9b1e-8c0d1e2f3a4b"}, p)
+
+	body, err := marshalS3PayloadPointer(p)
+	require.NoError(t, err)
+	assert.JSONEq(t, javaPointer, body)
+
+	for _, invalid := range []string{
+		`hello world`,
+		`["software.amazon.payloadoffloading.PayloadS3Pointer"]`,
+		`["com.example.Other",{"s3BucketName":"foo","s3Key":"bar"}]`,
+		`["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"foo"}]`,
+	} {
+		_, err := parseS3PayloadPointer(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestS3PayloadStoreShouldOffload(t *testing.T) {
+	var nilStore *s3PayloadStore
+	assert.False(t, nilStore.shouldOffload(make([]byte, 1<<20), 0))
+
+	s := &s3PayloadStore{conf: extendedPayloadConfig{Enabled: true, Threshold: 10}}
+	assert.False(t, s.shouldOffload([]byte("hello"), 5))
+	assert.True(t, s.shouldOffload([]byte("hello"), 6))
+
+	s.conf.AlwaysThroughS3 = true
+	assert.True(t, s.shouldOffload([]byte("a"), 0))
+}
+
+func TestSQSExtendedPayloadOutput(t *testing.T) {
+	tCtx := context.Background()
+
+	conf, err := config.LoadDefaultConfig(context.Background(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("xxxxx", "xxxxx", "xxxxx")),
+	)
+	require.NoError(t, err)
+
+	w, err := newSQSWriter(sqsoConfig{
+		URL: "http://foo.example.com",
+		backoffCtor: func() backoff.BackOff {
+			return backoff.NewExponentialBackOff()
+		},
+		aconf: conf,
+	}, service.MockResources())
+	require.NoError(t, err)
+
+	mockS3 := &mockS3Payloads{objects: map[string][]byte{}}
+	w.payloads = &s3PayloadStore{
+		conf: extendedPayloadConfig{
+			Enabled:   true,
+			Bucket:    "foo-bucket",
+			KeyPrefix: "bar/",
+			Threshold: 20,
+		},
+		s3: mockS3,
+	}
+
+	var entries []types.SendMessageBatchRequestEntry
+	w.sqs = &mockSqs{
+		fn: func(smbi *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+			entries = append(entries, smbi.Entries...)
+			return &sqs.SendMessageBatchOutput{}, nil
+		},
+	}
+
+	large := strings.Repeat("x", 50)
+	require.NoError(t, w.WriteBatch(tCtx, service.MessageBatch{
+		service.NewMessage([]byte("small")),
+		service.NewMessage([]byte(large)),
+	}))
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "small", *entries[0].MessageBody)
+	assert.NotContains(t, entries[0].MessageAttributes, epSizeAttribute)
+
+	p, err := parseS3PayloadPointer(*entries[1].MessageBody)
+	require.NoError(t, err)
+	assert.Equal(t, "foo-bucket", p.Bucket)
+	assert.True(t, strings.HasPrefix(p.Key, "bar/"), p.Key)
+	assert.Equal(t, []byte(large), mockS3.objects["foo-bucket/"+p.Key])
+
+	sizeAttr := entries[1].MessageAttributes[epSizeAttribute]
+	assert.Equal(t, "Number", *sizeAttr.DataType)
+	assert.Equal(t, "50", *sizeAttr.StringValue)
+}
+
+func TestSQSExtendedPayloadInput(t *testing.T) {
+	tCtx := context.Background()
+
+	mockS3 := &mockS3Payloads{objects: map[string][]byte{
+		"foo-bucket/bar": []byte("hello from s3"),
+	}}
+
+	messages := []types.Message{
+		{
+			Body:          aws.String(`["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"foo-bucket","s3Key":"bar"}]`),
+			MessageId:     aws.String("message-1"),
+			ReceiptHandle: aws.String("message-1"),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				epSizeAttribute: {DataType: aws.String("Number"), StringValue: aws.String("13")},
+				"foo":           {DataType: aws.String("String"), StringValue: aws.String("bar")},
+			},
+		},
+		{
+			Body:          aws.String("hello from sqs"),
+			MessageId:     aws.String("message-2"),
+			ReceiptHandle: aws.String("message-2"),
+		},
+	}
+
+	conf, err := config.LoadDefaultConfig(context.Background(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("xxxxx", "xxxxx", "xxxxx")),
+	)
+	require.NoError(t, err)
+
+	r, err := newAWSSQSReader(
+		sqsiConfig{
+			URL:                 "http://foo.example.com",
+			DeleteMessage:       true,
+			ResetVisibility:     true,
+			MaxNumberOfMessages: 10,
+			ExtendedPayload: extendedPayloadConfig{
+				Enabled:      true,
+				DeleteObject: true,
+			},
+		},
+		conf,
+		nil,
+	)
+	require.NoError(t, err)
+
+	mockInput := &mockSqsInput{
+		mtx:          make(chan struct{}, 1),
+		queueTimeout: 10,
+		messages:     messages,
+		mesTimeouts:  make(map[string]int32, len(messages)),
+	}
+	mockInput.mtx <- struct{}{}
+	r.sqs = mockInput
+	r.payloads = &s3PayloadStore{conf: r.conf.ExtendedPayload, s3: mockS3}
+
+	defer r.closeSignal.TriggerHardStop()
+	require.NoError(t, r.Connect(tCtx))
+
+	received := map[string]*service.Message{}
+	var ackFns []service.AckFunc
+	for i := 0; i < len(messages); i++ {
+		m, aFn, err := r.Read(tCtx)
+		require.NoError(t, err)
+
+		id, _ := m.MetaGet("sqs_message_id")
+		received[id] = m
+		ackFns = append(ackFns, aFn)
+	}
+
+	mBytes, err := received["message-1"].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello from s3", string(mBytes))
+
+	v, _ := received["message-1"].MetaGet("foo")
+	assert.Equal(t, "bar", v)
+	_, exists := received["message-1"].MetaGet(epSizeAttribute)
+	assert.False(t, exists)
+
+	mBytes, err = received["message-2"].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello from sqs", string(mBytes))
+
+	assert.Equal(t, 1, mockS3.len())
+	for _, aFn := range ackFns {
+		require.NoError(t, aFn(tCtx, nil))
+	}
+
+	require.Eventually(t, func() bool {
+		msgsLen := 0
+		mockInput.do(func() {
+			msgsLen = len(mockInput.messages)
+		})
+		return msgsLen == 0 && mockS3.len() == 0
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestSQSExtendedPayloadOutputFailure(t *testing.T) {
+	tCtx := context.Background()
+
+	conf, err := config.LoadDefaultConfig(context.Background(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("xxxxx", "xxxxx", "xxxxx")),
+	)
+	require.NoError(t, err)
+
+	w, err := newSQSWriter(sqsoConfig{
+		URL: "http://foo.example.com",
+		backoffCtor: func() backoff.BackOff {
+			return backoff.NewExponentialBackOff()
+		},
+		aconf: conf,
+	}, service.MockResources())
+	require.NoError(t, err)
+
+	mockS3 := &mockS3Payloads{objects: map[string][]byte{}}
+	w.payloads = &s3PayloadStore{
+		conf: extendedPayloadConfig{
+			Enabled:         true,
+			Bucket:          "foo-bucket",
+			AlwaysThroughS3: true,
+		},
+		s3: mockS3,
+	}
+
+	var entries []types.SendMessageBatchRequestEntry
+	w.sqs = &mockSqs{
+		fn: func(smbi *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+			entries = append(entries, smbi.Entries...)
+			return &sqs.SendMessageBatchOutput{
+				Failed: []types.BatchResultErrorEntry{
+					{
+						Code:        aws.String("xx"),
+						Id:          aws.String("1"),
+						Message:     aws.String("test error"),
+						SenderFault: true,
+					},
+				},
+			}, nil
+		},
+	}
+
+	require.Error(t, w.WriteBatch(tCtx, service.MessageBatch{
+		service.NewMessage([]byte("hello world 1")),
+		service.NewMessage([]byte("hello world 2")),
+	}))
+	require.Len(t, entries, 2)
+
+	// Only the payload of the message that was sent remains.
+	p, err := parseS3PayloadPointer(*entries[0].MessageBody)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo-bucket/" + p.Key: []byte("hello world 1"),
+	}, mockS3.objects)
+}
@@ -0,0 +1,303 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/gofrs/uuid"
+	"github.com/linkedin/goavro/v2"
+	"github.com/xeipuuv/gojsonschema"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/redpanda-data/connect/v4/internal/impl/protobuf"
+)
+
+const (
+	// The Glue Schema Registry wire format consists of a header version byte, a
+	// compression byte and the 16 byte UUID of the schema version, followed by
+	// the (optionally compressed) serialized data.
+	gsrHeaderVersion   byte = 3
+	gsrCompressionNone byte = 0
+	gsrCompressionZlib byte = 5
+	gsrHeaderLength         = 18
+
+	gsrSchemaStaleAfter       = time.Minute * 10
+	gsrSchemaCachePurgePeriod = time.Minute
+	gsrRequestTimeout         = time.Second * 30
+)
+
+type glueSchemaRegistryAPI interface {
+	GetSchemaVersion(ctx context.Context, params *glue.GetSchemaVersionInput, optFns ...func(*glue.Options)) (*glue.GetSchemaVersionOutput, error)
+	GetSchemaByDefinition(ctx context.Context, params *glue.GetSchemaByDefinitionInput, optFns ...func(*glue.Options)) (*glue.GetSchemaByDefinitionOutput, error)
+	RegisterSchemaVersion(ctx context.Context, params *glue.RegisterSchemaVersionInput, optFns ...func(*glue.Options)) (*glue.RegisterSchemaVersionOutput, error)
+	CreateSchema(ctx context.Context, params *glue.CreateSchemaInput, optFns ...func(*glue.Options)) (*glue.CreateSchemaOutput, error)
+}
+
+// gsrEncodeWireFormat prefixes serialized data with the Glue Schema Registry
+// header of a schema version, compressing the data when a compression is
+// specified.
+func gsrEncodeWireFormat(versionID uuid.UUID, compression byte, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(gsrHeaderLength + len(data))
+	buf.WriteByte(gsrHeaderVersion)
+	buf.WriteByte(compression)
+	buf.Write(versionID.Bytes())
+
+	switch compression {
+	case gsrCompressionNone:
+		buf.Write(data)
+	case gsrCompressionZlib:
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("compression type %v not supported", compression)
+	}
+	return buf.Bytes(), nil
+}
+
+// gsrDecodeWireFormat extracts the schema version and decompressed serialized
+// data from a message in the Glue Schema Registry wire format.
+func gsrDecodeWireFormat(b []byte) (versionID uuid.UUID, data []byte, err error) {
+	if len(b) < gsrHeaderLength {
+		err = fmt.Errorf("message of %v bytes is too short to contain a schema registry header", len(b))
+		return
+	}
+	if b[0] != gsrHeaderVersion {
+		err = fmt.Errorf("header version %v not supported", b[0])
+		return
+	}
+	if versionID, err = uuid.FromBytes(b[2:gsrHeaderLength]); err != nil {
+		return
+	}
+
+	data = b[gsrHeaderLength:]
+	switch b[1] {
+	case gsrCompressionNone:
+	case gsrCompressionZlib:
+		var r io.ReadCloser
+		if r, err = zlib.NewReader(bytes.NewReader(data)); err != nil {
+			return
+		}
+		defer r.Close()
+		if data, err = io.ReadAll(r); err != nil {
+			return
+		}
+	default:
+		err = fmt.Errorf("compression type %v not supported", b[1])
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// gsrCodec transcodes between the JSON representation of documents and the
+// serialized form of a schema.
+type gsrCodec struct {
+	encode func(b []byte) ([]byte, error)
+	decode func(b []byte) ([]byte, error)
+}
+
+type gsrCodecOptions struct {
+	avroRawJSON         bool
+	protobufMessageName string
+}
+
+func newGSRCodec(format types.DataFormat, definition string, opts gsrCodecOptions) (*gsrCodec, error) {
+	switch format {
+	case types.DataFormatAvro:
+		return newGSRAvroCodec(definition, opts.avroRawJSON)
+	case types.DataFormatJson:
+		return newGSRJSONCodec(definition)
+	case types.DataFormatProtobuf:
+		return newGSRProtobufCodec(definition, opts.protobufMessageName)
+	}
+	return nil, fmt.Errorf("data format %v not supported", format)
+}
+
+func newGSRAvroCodec(definition string, rawJSON bool) (*gsrCodec, error) {
+	var codec *goavro.Codec
+	var err error
+	if rawJSON {
+		codec, err = goavro.NewCodecForStandardJSONFull(definition)
+	} else {
+		codec, err = goavro.NewCodec(definition)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &gsrCodec{
+		encode: func(b []byte) ([]byte, error) {
+			datum, _, err := codec.NativeFromTextual(b)
+			if err != nil {
+				return nil, err
+			}
+			return codec.BinaryFromNative(nil, datum)
+		},
+		decode: func(b []byte) ([]byte, error) {
+			native, _, err := codec.NativeFromBinary(b)
+			if err != nil {
+				return nil, err
+			}
+			return codec.TextualFromNative(nil, native)
+		},
+	}, nil
+}
+
+func newGSRJSONCodec(definition string) (*gsrCodec, error) {
+	sch, err := gojsonschema.NewSchemaLoader().Compile(gojsonschema.NewStringLoader(definition))
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON documents are serialized as they are and therefore only need to be
+	// validated against the schema.
+	validate := func(b []byte) ([]byte, error) {
+		res, err := sch.Validate(gojsonschema.NewBytesLoader(b))
+		if err != nil {
+			return nil, err
+		}
+		if !res.Valid() {
+			return nil, fmt.Errorf("json message does not conform to schema: %v", res.Errors())
+		}
+		return b, nil
+	}
+	return &gsrCodec{encode: validate, decode: validate}, nil
+}
+
+// gsrProtobufMessageDescriptors returns all message types of a schema,
+// including nested types, ordered by their full name. The position of a type
+// within this list is the message index that is serialized along with data.
+func gsrProtobufMessageDescriptors(file protoreflect.FileDescriptor) []protoreflect.MessageDescriptor {
+	var descs []protoreflect.MessageDescriptor
+	var walk func(msgs protoreflect.MessageDescriptors)
+	walk = func(msgs protoreflect.MessageDescriptors) {
+		for i := 0; i < msgs.Len(); i++ {
+			descs = append(descs, msgs.Get(i))
+			walk(msgs.Get(i).Messages())
+		}
+	}
+	walk(file.Messages())
+	sort.Slice(descs, func(i, j int) bool {
+		return descs[i].FullName() < descs[j].FullName()
+	})
+	return descs
+}
+
+func newGSRProtobufCodec(definition, messageName string) (*gsrCodec, error) {
+	files, msgTypes, err := protobuf.RegistriesFromMap(map[string]string{
+		".": definition,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proto schema: %v", err)
+	}
+
+	targetFile, err := files.FindFileByPath(".")
+	if err != nil {
+		return nil, err
+	}
+
+	descs := gsrProtobufMessageDescriptors(targetFile)
+	if len(descs) == 0 {
+		return nil, errors.New("proto schema does not contain any message types")
+	}
+
+	// Documents are encoded as the named message type, or the first message
+	// type defined by the schema.
+	encodeIndex := -1
+	for i, d := range descs {
+		if messageName == "" && d == targetFile.Messages().Get(0) {
+			encodeIndex = i
+		} else if messageName != "" && string(d.FullName()) == messageName {
+			encodeIndex = i
+		}
+	}
+	if encodeIndex == -1 && messageName != "" {
+		return nil, fmt.Errorf("message type %v not found in proto schema", messageName)
+	}
+
+	return &gsrCodec{
+		encode: func(b []byte) ([]byte, error) {
+			dynMsg := dynamicpb.NewMessage(descs[encodeIndex])
+			if err := (protojson.UnmarshalOptions{Resolver: msgTypes}).Unmarshal(b, dynMsg); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal JSON message: %w", err)
+			}
+
+			data := protowire.AppendVarint(nil, uint64(encodeIndex))
+			return (proto.MarshalOptions{}).MarshalAppend(data, dynMsg)
+		},
+		decode: func(b []byte) ([]byte, error) {
+			index, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to read message index: %w", protowire.ParseError(n))
+			}
+			if index >= uint64(len(descs)) {
+				return nil, fmt.Errorf("message index (%v) is greater than available message definitions (%v)", index, len(descs))
+			}
+
+			dynMsg := dynamicpb.NewMessage(descs[index])
+			if err := proto.Unmarshal(b[n:], dynMsg); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+			}
+			return protojson.MarshalOptions{Resolver: msgTypes}.Marshal(dynMsg)
+		},
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// gsrWaitForSchemaVersion polls a newly registered schema version until it is
+// available for use.
+func gsrWaitForSchemaVersion(ctx context.Context, client glueSchemaRegistryAPI, versionID string) error {
+	for {
+		out, err := client.GetSchemaVersion(ctx, &glue.GetSchemaVersionInput{
+			SchemaVersionId: aws.String(versionID),
+		})
+		if err != nil {
+			return err
+		}
+		switch out.Status {
+		case types.SchemaVersionStatusAvailable:
+			return nil
+		case types.SchemaVersionStatusPending:
+		default:
+			return fmt.Errorf("schema version %v has status %v", versionID, out.Status)
+		}
+		select {
+		case <-time.After(time.Millisecond * 200):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
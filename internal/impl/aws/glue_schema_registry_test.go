@@ -0,0 +1,322 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockGSRVersion struct {
+	id         string
+	schemaName string
+	format     types.DataFormat
+	definition string
+}
+
+type mockGlueSchemaRegistry struct {
+	mut      sync.Mutex
+	versions []mockGSRVersion
+	requests int
+}
+
+func (m *mockGlueSchemaRegistry) addVersion(schemaName string, format types.DataFormat, definition string) string {
+	id := uuid.Must(uuid.NewV4()).String()
+	m.versions = append(m.versions, mockGSRVersion{
+		id:         id,
+		schemaName: schemaName,
+		format:     format,
+		definition: definition,
+	})
+	return id
+}
+
+func (m *mockGlueSchemaRegistry) GetSchemaVersion(ctx context.Context, params *glue.GetSchemaVersionInput, optFns ...func(*glue.Options)) (*glue.GetSchemaVersionOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.requests++
+
+	for i := len(m.versions) - 1; i >= 0; i-- {
+		v := m.versions[i]
+		if (params.SchemaVersionId != nil && *params.SchemaVersionId == v.id) ||
+			(params.SchemaId != nil && *params.SchemaId.SchemaName == v.schemaName) {
+			return &glue.GetSchemaVersionOutput{
+				SchemaVersionId:  aws.String(v.id),
+				DataFormat:       v.format,
+				SchemaDefinition: aws.String(v.definition),
+				Status:           types.SchemaVersionStatusAvailable,
+			}, nil
+		}
+	}
+	return nil, &types.EntityNotFoundException{Message: aws.String("nope")}
+}
+
+func (m *mockGlueSchemaRegistry) GetSchemaByDefinition(ctx context.Context, params *glue.GetSchemaByDefinitionInput, optFns ...func(*glue.Options)) (*glue.GetSchemaByDefinitionOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.requests++
+
+	for _, v := range m.versions {
+		if v.schemaName == *params.SchemaId.SchemaName && v.definition == *params.SchemaDefinition {
+			return &glue.GetSchemaByDefinitionOutput{
+				SchemaVersionId: aws.String(v.id),
+				DataFormat:      v.format,
+				Status:          types.SchemaVersionStatusAvailable,
+			}, nil
+		}
+	}
+	return nil, &types.EntityNotFoundException{Message: aws.String("nope")}
+}
+
+func (m *mockGlueSchemaRegistry) RegisterSchemaVersion(ctx context.Context, params *glue.RegisterSchemaVersionInput, optFns ...func(*glue.Options)) (*glue.RegisterSchemaVersionOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.requests++
+
+	for _, v := range m.versions {
+		if v.schemaName == *params.SchemaId.SchemaName {
+			return &glue.RegisterSchemaVersionOutput{
+				SchemaVersionId: aws.String(m.addVersion(v.schemaName, v.format, *params.SchemaDefinition)),
+				Status:          types.SchemaVersionStatusPending,
+			}, nil
+		}
+	}
+	return nil, &types.EntityNotFoundException{Message: aws.String("nope")}
+}
+
+func (m *mockGlueSchemaRegistry) CreateSchema(ctx context.Context, params *glue.CreateSchemaInput, optFns ...func(*glue.Options)) (*glue.CreateSchemaOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.requests++
+
+	return &glue.CreateSchemaOutput{
+		SchemaVersionId: aws.String(m.addVersion(*params.SchemaName, params.DataFormat, *params.SchemaDefinition)),
+	}, nil
+}
+
+func TestGlueSchemaRegistryWireFormat(t *testing.T) {
+	versionID := uuid.Must(uuid.FromString("b7b4a7f0-9c96-4e4d-a3a7-1ad3e4e5f001"))
+
+	b, err := gsrEncodeWireFormat(versionID, gsrCompressionNone, []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{3, 0}, append(versionID.Bytes(), "hello"...)...), b)
+
+	id, data, err := gsrDecodeWireFormat(b)
+	require.NoError(t, err)
+	assert.Equal(t, versionID, id)
+	assert.Equal(t, "hello", string(data))
+
+	b, err = gsrEncodeWireFormat(versionID, gsrCompressionZlib, []byte("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, gsrCompressionZlib, b[1])
+
+	id, data, err = gsrDecodeWireFormat(b)
+	require.NoError(t, err)
+	assert.Equal(t, versionID, id)
+	assert.Equal(t, "hello world", string(data))
+
+	for _, invalid := range [][]byte{
+		nil,
+		[]byte("too short"),
+		append([]byte{0, 0}, versionID.Bytes()...),
+		append([]byte{3, 9}, versionID.Bytes()...),
+	} {
+		_, _, err := gsrDecodeWireFormat(invalid)
+		assert.Error(t, err)
+	}
+}
+
+func TestGlueSchemaRegistryProtobufCodec(t *testing.T) {
+	schema := `
+syntax = "proto3";
+package example;
+
+message Order {
+  string id = 1;
+  Item item = 2;
+
+  message Item {
+    string name = 1;
+  }
+}
+
+message Customer {
+  string name = 1;
+}
+`
+
+	codec, err := newGSRProtobufCodec(schema, "")
+	require.NoError(t, err)
+
+	b, err := codec.encode([]byte(`{"id":"foo","item":{"name":"bar"}}`))
+	require.NoError(t, err)
+
+	// Message types are indexed by their full name, where example.Customer
+	// precedes example.Order.
+	assert.Equal(t, byte(1), b[0])
+
+	out, err := codec.decode(b)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"foo","item":{"name":"bar"}}`, string(out))
+
+	codec, err = newGSRProtobufCodec(schema, "example.Order.Item")
+	require.NoError(t, err)
+
+	b, err = codec.encode([]byte(`{"name":"baz"}`))
+	require.NoError(t, err)
+	assert.Equal(t, byte(2), b[0])
+
+	_, err = newGSRProtobufCodec(schema, "example.Nope")
+	require.Error(t, err)
+}
+
+func TestGlueSchemaRegistryJSONCodec(t *testing.T) {
+	codec, err := newGSRJSONCodec(`{"type":"object","properties":{"id":{"type":"string"}},"required":["id"]}`)
+	require.NoError(t, err)
+
+	b, err := codec.encode([]byte(`{"id":"foo"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"foo"}`, string(b))
+
+	_, err = codec.encode([]byte(`{"id":5}`))
+	require.Error(t, err)
+}
+
+const gsrTestAvroSchema = `{
+  "type": "record",
+  "name": "Order",
+  "fields": [
+    { "name": "id", "type": "string" },
+    { "name": "quantity", "type": "int" }
+  ]
+}`
+
+func TestGlueSchemaRegistryEncodeDecode(t *testing.T) {
+	registry := &mockGlueSchemaRegistry{}
+	versionID := registry.addVersion("orders", types.DataFormatAvro, gsrTestAvroSchema)
+
+	pConf, err := gsreProcessorSpec().ParseYAML(`
+schema_name: ${! meta("schema") }
+compression: zlib
+`, nil)
+	require.NoError(t, err)
+
+	eConf, err := gsreConfigFromParsed(pConf)
+	require.NoError(t, err)
+
+	encoder := newGlueSchemaRegistryEncoder(eConf, registry, service.MockResources().Logger())
+	t.Cleanup(func() {
+		_ = encoder.Close(context.Background())
+	})
+
+	decoder := newGlueSchemaRegistryDecoder(gsrdConfig{}, registry, service.MockResources().Logger())
+	t.Cleanup(func() {
+		_ = decoder.Close(context.Background())
+	})
+
+	var batch service.MessageBatch
+	for _, content := range []string{
+		`{"id":"foo","quantity":1}`,
+		`{"id":"bar","quantity":2}`,
+		`{"nope":"baz"}`,
+	} {
+		msg := service.NewMessage([]byte(content))
+		msg.MetaSetMut("schema", "orders")
+		batch = append(batch, msg)
+	}
+
+	batches, err := encoder.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 3)
+	require.Error(t, batches[0][2].GetError())
+
+	// Schema versions are cached
+	assert.Equal(t, 1, registry.requests)
+
+	for i, exp := range []string{`{"id":"foo","quantity":1}`, `{"id":"bar","quantity":2}`} {
+		msg := batches[0][i]
+		require.NoError(t, msg.GetError())
+
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		id, _, err := gsrDecodeWireFormat(b)
+		require.NoError(t, err)
+		assert.Equal(t, versionID, id.String())
+
+		res, err := decoder.Process(context.Background(), msg)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		b, err = res[0].AsBytes()
+		require.NoError(t, err)
+		assert.JSONEq(t, exp, string(b))
+	}
+	assert.Equal(t, 2, registry.requests)
+}
+
+func TestGlueSchemaRegistryEncodeAutoRegister(t *testing.T) {
+	registry := &mockGlueSchemaRegistry{}
+
+	pConf, err := gsreProcessorSpec().ParseYAML(`
+schema_name: orders
+schema_definition: '`+gsrTestAvroSchema+`'
+auto_register: true
+`, nil)
+	require.NoError(t, err)
+
+	eConf, err := gsreConfigFromParsed(pConf)
+	require.NoError(t, err)
+
+	encoder := newGlueSchemaRegistryEncoder(eConf, registry, service.MockResources().Logger())
+	t.Cleanup(func() {
+		_ = encoder.Close(context.Background())
+	})
+
+	batches, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo","quantity":1}`)),
+	})
+	require.NoError(t, err)
+	require.NoError(t, batches[0][0].GetError())
+
+	require.Len(t, registry.versions, 1)
+	assert.Equal(t, "orders", registry.versions[0].schemaName)
+	assert.Equal(t, types.DataFormatAvro, registry.versions[0].format)
+
+	b, err := batches[0][0].AsBytes()
+	require.NoError(t, err)
+	id, _, err := gsrDecodeWireFormat(b)
+	require.NoError(t, err)
+	assert.Equal(t, registry.versions[0].id, id.String())
+
+	pConf, err = gsreProcessorSpec().ParseYAML(`
+schema_name: orders
+auto_register: true
+`, nil)
+	require.NoError(t, err)
+
+	_, err = gsreConfigFromParsed(pConf)
+	require.Error(t, err)
+}
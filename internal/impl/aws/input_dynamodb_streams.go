@@ -0,0 +1,883 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gofrs/uuid"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+
+	"github.com/redpanda-data/connect/v4/internal/impl/aws/config"
+)
+
+const (
+	// DynamoDB Streams Input Fields
+	ddbsFieldTable           = "table"
+	ddbsFieldCheckpointer    = "checkpointer"
+	ddbsFieldCheckpointLimit = "checkpoint_limit"
+	ddbsFieldCommitPeriod    = "commit_period"
+	ddbsFieldLeasePeriod     = "lease_period"
+	ddbsFieldRebalancePeriod = "rebalance_period"
+	ddbsFieldStartFromOldest = "start_from_oldest"
+	ddbsFieldBatching        = "batching"
+)
+
+// ddbsShardEnd is the checkpointed sequence of a shard that has been consumed
+// in its entirety, which allows its children to be consumed.
+const ddbsShardEnd = "SHARD_END"
+
+type ddbsConfig struct {
+	Table           string
+	Checkpointer    kiddbConfig
+	CheckpointLimit int
+	CommitPeriod    time.Duration
+	LeasePeriod     time.Duration
+	RebalancePeriod time.Duration
+	StartFromOldest bool
+}
+
+func ddbsConfigFromParsed(pConf *service.ParsedConfig) (conf ddbsConfig, err error) {
+	if conf.Table, err = pConf.FieldString(ddbsFieldTable); err != nil {
+		return
+	}
+	if conf.Checkpointer, err = kinesisInputDynamoDBConfigFromParsed(pConf.Namespace(ddbsFieldCheckpointer)); err != nil {
+		return
+	}
+	if conf.CheckpointLimit, err = pConf.FieldInt(ddbsFieldCheckpointLimit); err != nil {
+		return
+	}
+	if conf.CommitPeriod, err = pConf.FieldDuration(ddbsFieldCommitPeriod); err != nil {
+		return
+	}
+	if conf.LeasePeriod, err = pConf.FieldDuration(ddbsFieldLeasePeriod); err != nil {
+		return
+	}
+	if conf.RebalancePeriod, err = pConf.FieldDuration(ddbsFieldRebalancePeriod); err != nil {
+		return
+	}
+	if conf.StartFromOldest, err = pConf.FieldBool(ddbsFieldStartFromOldest); err != nil {
+		return
+	}
+	if conf.Checkpointer.Table == "" {
+		err = errors.New("a checkpointer table must be specified")
+	}
+	return
+}
+
+func ddbsInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.33.0").
+		Categories("Services", "AWS").
+		Summary("Receive change data capture events from the stream of a DynamoDB table.").
+		Description(`
+Consumes the DynamoDB stream of a table, automatically balancing its shards across other instances of this input. The latest record sequence consumed from each shard is stored within a DynamoDB checkpointer table using the same <<table-schema,schema>> as the `+"`aws_kinesis`"+` input, which allows it to resume at the correct sequence during restarts. This table is also used for coordination across distributed inputs when shard balancing.
+
+Shards of a DynamoDB stream are split over time, in which case a child shard is only consumed once its parent shard has been consumed in its entirety, which preserves the order of changes to each item.
+
+Redpanda Connect will not store a consumed sequence unless it is acknowledged at the output level, which ensures at-least-once delivery guarantees.
+
+== Records
+
+Each record is converted into a JSON document of the following form, where the keys and images of the item are converted from DynamoDB attribute values into plain JSON values:
+
+`+"```json"+`
+{
+  "event_id": "f0c8b3c3a3b9d7e4c0a1f7d5e2b4c6a8",
+  "event_name": "MODIFY",
+  "keys": { "id": "foo" },
+  "new_image": { "id": "foo", "count": 2 },
+  "old_image": { "id": "foo", "count": 1 }
+}
+`+"```"+`
+
+Whether `+"`new_image`"+` and `+"`old_image`"+` are present depends on the stream view type of the table. Numbers are converted into integers where possible, and binary values are base64 encoded.
+
+Records that cannot be converted are still delivered, containing only the fields that could be extracted, and flagged as failed with the conversion error so that they can be handled with xref:configuration:error_handling.adoc[error handling].
+
+== Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- dynamodb_stream_arn
+- dynamodb_shard
+- dynamodb_sequence_number
+- dynamodb_event_id
+- dynamodb_event_name
+- dynamodb_approximate_creation_time
+`+"```"+`
+
+== Ordering
+
+By default messages of a shard can be processed in parallel, up to a limit determined by the field `+"`checkpoint_limit`"+`. However, if strict ordered processing is required then this value must be set to 1 in order to process shard messages in lock-step.
+
+== Table schema
+
+It's possible to configure Redpanda Connect to create the DynamoDB table required for checkpointing if it does not already exist. However, if you wish to create this yourself (recommended) then create a table with a string HASH key `+"`StreamID`"+` and a string RANGE key `+"`ShardID`"+`.
+`).
+		Fields(
+			service.NewStringField(ddbsFieldTable).
+				Description("The name of the table to consume the stream of. The table must have a stream enabled.").
+				Example("my-table"),
+			service.NewObjectField(ddbsFieldCheckpointer, kinesisInputDynamoDBFields()...).
+				Description("Determines the table used for storing and accessing the latest consumed sequence for shards, and for coordinating balanced consumers of the stream."),
+			service.NewIntField(ddbsFieldCheckpointLimit).
+				Description("The maximum gap between the in flight sequence versus the latest acknowledged sequence at a given time. Increasing this limit enables parallel processing and batching at the output level to work on individual shards. Any given sequence will not be committed unless all messages under that offset are delivered in order to preserve at least once delivery guarantees.").
+				Default(1024),
+			service.NewAutoRetryNacksToggleField(),
+			service.NewDurationField(ddbsFieldCommitPeriod).
+				Description("The period of time between each update to the checkpoint table.").
+				Default("5s"),
+			service.NewDurationField(ddbsFieldRebalancePeriod).
+				Description("The period of time between each attempt to discover new shards and rebalance shards across clients.").
+				Default("30s").
+				Advanced(),
+			service.NewDurationField(ddbsFieldLeasePeriod).
+				Description("The period of time after which a client that has failed to update a shard checkpoint is assumed to be inactive.").
+				Default("30s").
+				Advanced(),
+			service.NewBoolField(ddbsFieldStartFromOldest).
+				Description("Whether to consume from the oldest record of the stream when a checkpoint does not yet exist. When `false` only new records are consumed, and shards that were closed before this input first started are skipped.").
+				Default(true),
+		).
+		Fields(config.SessionFields()...).
+		Field(service.NewBatchPolicyField(ddbsFieldBatching))
+}
+
+func init() {
+	err := service.RegisterBatchInput("aws_dynamodb_streams", ddbsInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			r, err := newDynamoDBStreamsReaderFromParsed(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatchedToggled(conf, r)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dynamoDBStreamsReader struct {
+	conf     ddbsConfig
+	clientID string
+
+	sess    aws.Config
+	batcher service.BatchPolicy
+	log     *service.Logger
+	mgr     *service.Resources
+
+	boffPool sync.Pool
+
+	svc          *dynamodbstreams.Client
+	checkpointer *awsKinesisCheckpointer
+	streamARN    string
+
+	cMut    sync.Mutex
+	msgChan chan asyncMessage
+
+	ctx  context.Context
+	done func()
+
+	closeOnce  sync.Once
+	closedChan chan struct{}
+}
+
+func newDynamoDBStreamsReaderFromParsed(pConf *service.ParsedConfig, mgr *service.Resources) (*dynamoDBStreamsReader, error) {
+	conf, err := ddbsConfigFromParsed(pConf)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := GetSession(context.TODO(), pConf)
+	if err != nil {
+		return nil, err
+	}
+	batcher, err := pConf.FieldBatchPolicy(ddbsFieldBatching)
+	if err != nil {
+		return nil, err
+	}
+	if batcher.IsNoop() {
+		batcher.Count = 1
+	}
+
+	d := &dynamoDBStreamsReader{
+		conf:       conf,
+		sess:       sess,
+		batcher:    batcher,
+		log:        mgr.Logger(),
+		mgr:        mgr,
+		closedChan: make(chan struct{}),
+	}
+	d.ctx, d.done = context.WithCancel(context.Background())
+
+	u4, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	d.clientID = u4.String()
+
+	d.boffPool = sync.Pool{
+		New: func() any {
+			boff := backoff.NewExponentialBackOff()
+			boff.InitialInterval = time.Millisecond * 300
+			boff.MaxInterval = time.Second * 5
+			boff.MaxElapsedTime = 0
+			return boff
+		},
+	}
+	return d, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *dynamoDBStreamsReader) getIter(shardID, sequence string, iterType types.ShardIteratorType) (string, error) {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         &d.streamARN,
+		ShardId:           &shardID,
+		ShardIteratorType: iterType,
+	}
+	if sequence != "" {
+		input.ShardIteratorType = types.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = &sequence
+	}
+
+	res, err := d.svc.GetShardIterator(d.ctx, input)
+	if err != nil {
+		var trimmedErr *types.TrimmedDataAccessException
+		if sequence == "" || !errors.As(err, &trimmedErr) {
+			return "", err
+		}
+
+		// If the checkpointed sequence has been trimmed from the stream we
+		// start from the oldest record remaining.
+		d.log.Warnf("Checkpointed sequence of shard '%v' has been trimmed, resuming from the oldest available record", shardID)
+		if res, err = d.svc.GetShardIterator(d.ctx, &dynamodbstreams.GetShardIteratorInput{
+			StreamArn:         &d.streamARN,
+			ShardId:           &shardID,
+			ShardIteratorType: types.ShardIteratorTypeTrimHorizon,
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	if res.ShardIterator == nil || *res.ShardIterator == "" {
+		return "", errors.New("failed to obtain shard iterator")
+	}
+	return *res.ShardIterator, nil
+}
+
+// IMPORTANT TO NOTE: The returned shard iterator (second return parameter) will
+// always be the input iterator when the error parameter is nil, therefore
+// replacing the current iterator with this return param should always be safe.
+func (d *dynamoDBStreamsReader) getRecords(shardIter string) ([]types.Record, string, error) {
+	res, err := d.svc.GetRecords(d.ctx, &dynamodbstreams.GetRecordsInput{
+		ShardIterator: &shardIter,
+	})
+	if err != nil {
+		return nil, shardIter, err
+	}
+
+	nextIter := ""
+	if res.NextShardIterator != nil {
+		nextIter = *res.NextShardIterator
+	}
+	return res.Records, nextIter, nil
+}
+
+func (d *dynamoDBStreamsReader) runConsumer(wg *sync.WaitGroup, shardID, startingSequence string, iterType types.ShardIteratorType) (initErr error) {
+	defer func() {
+		if initErr != nil {
+			wg.Done()
+			if _, err := d.checkpointer.Checkpoint(context.Background(), d.streamARN, shardID, startingSequence, true); err != nil {
+				d.log.Errorf("Failed to gracefully yield checkpoint: %v\n", err)
+			}
+		}
+	}()
+
+	var recordBatcher *awsKinesisRecordBatcher
+	if recordBatcher, initErr = newAWSShardRecordBatcher(d.batcher, d.mgr, d.conf.CheckpointLimit, d.streamARN, shardID, startingSequence); initErr != nil {
+		return initErr
+	}
+
+	boff := d.boffPool.Get().(backoff.BackOff)
+
+	var pending []types.Record
+	var iter string
+	if iter, initErr = d.getIter(shardID, startingSequence, iterType); initErr != nil {
+		return initErr
+	}
+
+	state := awsKinesisConsumerConsuming
+	var pendingMsg asyncMessage
+
+	unblockedChan, blockedChan := make(chan time.Time), make(chan time.Time)
+	close(unblockedChan)
+
+	// These channels follow the same semantics as the consumers of the
+	// aws_kinesis input.
+	var nextTimedBatchChan <-chan time.Time
+	var nextPullChan <-chan time.Time = unblockedChan
+	var nextFlushChan chan<- asyncMessage
+	commitCtx, commitCtxClose := context.WithTimeout(d.ctx, d.conf.CommitPeriod)
+
+	go func() {
+		defer func() {
+			commitCtxClose()
+			recordBatcher.Close(context.Background(), state == awsKinesisConsumerFinished)
+			boff.Reset()
+			d.boffPool.Put(boff)
+
+			reason := ""
+			switch state {
+			case awsKinesisConsumerFinished:
+				reason = " because the shard is closed"
+				if _, err := d.checkpointer.Checkpoint(context.Background(), d.streamARN, shardID, ddbsShardEnd, true); err != nil {
+					d.log.Errorf("Failed to store final checkpoint for finished shard '%v': %v", shardID, err)
+				}
+			case awsKinesisConsumerYielding:
+				reason = " because the shard has been claimed by another client"
+				if err := d.checkpointer.Yield(d.ctx, d.streamARN, shardID, recordBatcher.GetSequence()); err != nil {
+					d.log.Errorf("Failed to yield checkpoint for stolen shard '%v': %v", shardID, err)
+				}
+			case awsKinesisConsumerClosing:
+				reason = " because the pipeline is shutting down"
+				if _, err := d.checkpointer.Checkpoint(context.Background(), d.streamARN, shardID, recordBatcher.GetSequence(), true); err != nil {
+					d.log.Errorf("Failed to store final checkpoint for shard '%v': %v", shardID, err)
+				}
+			}
+
+			wg.Done()
+			d.log.Debugf("Closing shard '%v' as client '%v'%v", shardID, d.clientID, reason)
+		}()
+
+		d.log.Debugf("Consuming shard '%v' as client '%v'", shardID, d.clientID)
+
+		unblockPullChan := func() {
+			if nextPullChan == blockedChan {
+				nextPullChan = unblockedChan
+			}
+		}
+
+		for {
+			var err error
+			if state == awsKinesisConsumerConsuming && len(pending) == 0 && nextPullChan == unblockedChan {
+				if pending, iter, err = d.getRecords(iter); err != nil {
+					if !awsErrIsTimeout(err) {
+						nextPullChan = time.After(boff.NextBackOff())
+
+						var aerr *types.ExpiredIteratorException
+						if errors.As(err, &aerr) {
+							d.log.Warn("Shard iterator expired, attempting to refresh")
+							newIter, err := d.getIter(shardID, recordBatcher.GetSequence(), iterType)
+							if err != nil {
+								d.log.Errorf("Failed to refresh shard iterator: %v", err)
+							} else {
+								iter = newIter
+							}
+						} else {
+							d.log.Errorf("Failed to pull DynamoDB stream records: %v\n", err)
+						}
+					}
+				} else if len(pending) == 0 {
+					nextPullChan = time.After(boff.NextBackOff())
+				} else {
+					boff.Reset()
+					nextPullChan = blockedChan
+				}
+				if iter == "" {
+					state = awsKinesisConsumerFinished
+				}
+			} else {
+				unblockPullChan()
+			}
+
+			if pendingMsg.msg == nil {
+				if len(pending) == 0 && state == awsKinesisConsumerFinished {
+					if pendingMsg, _ = recordBatcher.FlushMessage(d.ctx); pendingMsg.msg == nil {
+						return
+					}
+				} else if recordBatcher.HasPendingMessage() {
+					if pendingMsg, err = recordBatcher.FlushMessage(commitCtx); err != nil {
+						d.log.Errorf("Failed to dispatch message due to checkpoint error: %v\n", err)
+					}
+				} else if len(pending) > 0 {
+					var i int
+					var r types.Record
+					for i, r = range pending {
+						msg, sequence, err := ddbsRecordToMessage(d.streamARN, shardID, r)
+						if err != nil {
+							// The record is still delivered, flagged with the
+							// error, so that it can be handled by the pipeline
+							// rather than being skipped by the checkpoint.
+							d.log.Errorf("Failed to convert DynamoDB stream record: %v", err)
+							msg.SetError(err)
+						}
+						if recordBatcher.AddMessage(msg, sequence) {
+							if pendingMsg, err = recordBatcher.FlushMessage(commitCtx); err != nil {
+								d.log.Errorf("Failed to dispatch message due to checkpoint error: %v\n", err)
+							}
+							break
+						}
+					}
+					if pending = pending[i+1:]; len(pending) == 0 {
+						unblockPullChan()
+					}
+				} else {
+					unblockPullChan()
+				}
+			}
+
+			if pendingMsg.msg != nil {
+				nextFlushChan = d.msgChan
+			} else {
+				nextFlushChan = nil
+			}
+
+			if nextTimedBatchChan == nil {
+				if tNext, exists := recordBatcher.UntilNext(); exists {
+					nextTimedBatchChan = time.After(tNext)
+				}
+			}
+
+			select {
+			case <-commitCtx.Done():
+				if d.ctx.Err() != nil {
+					state = awsKinesisConsumerClosing
+					return
+				}
+
+				commitCtxClose()
+				commitCtx, commitCtxClose = context.WithTimeout(d.ctx, d.conf.CommitPeriod)
+
+				stillOwned, err := d.checkpointer.Checkpoint(d.ctx, d.streamARN, shardID, recordBatcher.GetSequence(), false)
+				if err != nil {
+					d.log.Errorf("Failed to store checkpoint for shard '%v': %v", shardID, err)
+				} else if !stillOwned {
+					state = awsKinesisConsumerYielding
+					return
+				}
+			case <-nextTimedBatchChan:
+				nextTimedBatchChan = nil
+			case nextFlushChan <- pendingMsg:
+				pendingMsg = asyncMessage{}
+			case <-nextPullChan:
+				nextPullChan = unblockedChan
+			case <-d.ctx.Done():
+				state = awsKinesisConsumerClosing
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *dynamoDBStreamsReader) listShards(ctx context.Context) ([]types.Shard, error) {
+	var shards []types.Shard
+	input := &dynamodbstreams.DescribeStreamInput{
+		StreamArn: &d.streamARN,
+	}
+	for {
+		res, err := d.svc.DescribeStream(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, res.StreamDescription.Shards...)
+		if res.StreamDescription.LastEvaluatedShardId == nil {
+			return shards, nil
+		}
+		input.ExclusiveStartShardId = res.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// ddbsReadyShards returns the shards of a stream that are ready to be consumed
+// along with the iterator type to use for each when it has no checkpoint. A
+// shard is ready once it is unfinished and its parent, if still present in the
+// stream, has been consumed in its entirety.
+func ddbsReadyShards(shards []types.Shard, checkpoints map[string]string, startFromOldest bool) map[string]types.ShardIteratorType {
+	present := make(map[string]types.Shard, len(shards))
+	for _, s := range shards {
+		present[*s.ShardId] = s
+	}
+
+	// Shards that were closed before they were ever consumed are skipped when
+	// we are only interested in new records.
+	isSkipped := func(s types.Shard) bool {
+		if startFromOldest {
+			return false
+		}
+		_, exists := checkpoints[*s.ShardId]
+		return !exists && s.SequenceNumberRange != nil && s.SequenceNumberRange.EndingSequenceNumber != nil
+	}
+
+	ready := map[string]types.ShardIteratorType{}
+	for _, s := range shards {
+		if checkpoints[*s.ShardId] == ddbsShardEnd || isSkipped(s) {
+			continue
+		}
+
+		iterType := types.ShardIteratorTypeTrimHorizon
+		if !startFromOldest {
+			iterType = types.ShardIteratorTypeLatest
+		}
+
+		if s.ParentShardId != nil {
+			if parent, exists := present[*s.ParentShardId]; exists {
+				if checkpoints[*s.ParentShardId] == ddbsShardEnd {
+					// The parent has been consumed and so this shard must be
+					// consumed from its beginning.
+					iterType = types.ShardIteratorTypeTrimHorizon
+				} else if !isSkipped(parent) {
+					continue
+				}
+			}
+		}
+		ready[*s.ShardId] = iterType
+	}
+	return ready
+}
+
+func (d *dynamoDBStreamsReader) runBalancedShards() {
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		d.closeOnce.Do(func() {
+			close(d.msgChan)
+			close(d.closedChan)
+		})
+	}()
+
+	for {
+		shards, err := d.listShards(d.ctx)
+
+		var checkpoints map[string]string
+		if err == nil {
+			checkpoints, err = d.checkpointer.AllCheckpoints(d.ctx, d.streamARN)
+		}
+
+		var clientClaims map[string][]awsKinesisClientClaim
+		if err == nil {
+			clientClaims, err = d.checkpointer.AllClaims(d.ctx, d.streamARN)
+		}
+		if err != nil {
+			if d.ctx.Err() != nil {
+				return
+			}
+			d.log.Errorf("Failed to obtain stream shards or claims: %v", err)
+		} else {
+			d.balanceShards(&wg, shards, checkpoints, clientClaims)
+		}
+
+		select {
+		case <-time.After(d.conf.RebalancePeriod):
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *dynamoDBStreamsReader) balanceShards(
+	wg *sync.WaitGroup,
+	shards []types.Shard,
+	checkpoints map[string]string,
+	clientClaims map[string][]awsKinesisClientClaim,
+) {
+	// Checkpoints of finished shards are kept until the shard is trimmed from
+	// the stream, as until then they are needed in order to order its
+	// children.
+	present := make(map[string]struct{}, len(shards))
+	for _, s := range shards {
+		present[*s.ShardId] = struct{}{}
+	}
+	for shardID, sequence := range checkpoints {
+		if _, exists := present[shardID]; !exists && sequence == ddbsShardEnd {
+			if err := d.checkpointer.Delete(d.ctx, d.streamARN, shardID); err != nil {
+				d.log.Errorf("Failed to remove checkpoint for trimmed shard '%v': %v", shardID, err)
+			}
+		}
+	}
+
+	readyShards := ddbsReadyShards(shards, checkpoints, d.conf.StartFromOldest)
+
+	unclaimedShards := make(map[string]string, len(readyShards))
+	for shardID := range readyShards {
+		unclaimedShards[shardID] = ""
+	}
+	for clientID, claims := range clientClaims {
+		for _, claim := range claims {
+			if _, isReady := readyShards[claim.ShardID]; !isReady {
+				continue
+			}
+			if time.Since(claim.LeaseTimeout) > d.conf.LeasePeriod*2 {
+				unclaimedShards[claim.ShardID] = clientID
+			} else {
+				delete(unclaimedShards, claim.ShardID)
+			}
+		}
+	}
+
+	// Have a go at grabbing any unclaimed shards
+	if len(unclaimedShards) > 0 {
+		for shardID, clientID := range unclaimedShards {
+			sequence, err := d.checkpointer.Claim(d.ctx, d.streamARN, shardID, clientID)
+			if err != nil {
+				if d.ctx.Err() == nil && !errors.Is(err, ErrLeaseNotAcquired) {
+					d.log.Errorf("Failed to claim unclaimed shard '%v': %v", shardID, err)
+				}
+				continue
+			}
+			wg.Add(1)
+			if err = d.runConsumer(wg, shardID, sequence, readyShards[shardID]); err != nil {
+				d.log.Errorf("Failed to start consumer: %v\n", err)
+			}
+		}
+		return
+	}
+
+	// There were no unclaimed shards, let's look for a shard to steal using
+	// the same naive approach as the aws_kinesis input.
+	selfClaims := len(clientClaims[d.clientID])
+	for clientID, claims := range clientClaims {
+		if clientID == d.clientID || len(claims) <= (selfClaims+1) {
+			continue
+		}
+
+		randomShard := claims[(rand.Int() % len(claims))].ShardID
+		iterType, isReady := readyShards[randomShard]
+		if !isReady {
+			continue
+		}
+
+		d.log.Debugf("Attempting to steal shard '%v' from client '%v' as client '%v'", randomShard, clientID, d.clientID)
+
+		sequence, err := d.checkpointer.Claim(d.ctx, d.streamARN, randomShard, clientID)
+		if err != nil {
+			if d.ctx.Err() == nil && !errors.Is(err, ErrLeaseNotAcquired) {
+				d.log.Errorf("Failed to steal shard '%v': %v", randomShard, err)
+			}
+			continue
+		}
+
+		d.log.Debugf("Successfully stole shard '%v' from client '%v' as client '%v'", randomShard, clientID, d.clientID)
+		wg.Add(1)
+		if err = d.runConsumer(wg, randomShard, sequence, iterType); err != nil {
+			d.log.Errorf("Failed to start consumer: %v\n", err)
+		} else {
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ddbsRecordToMessage converts a stream record into a message, returning the
+// sequence number of the record.
+func ddbsRecordToMessage(streamARN, shardID string, r types.Record) (*service.Message, string, error) {
+	if r.Dynamodb == nil || r.Dynamodb.SequenceNumber == nil {
+		// Whatever can be extracted from the record is returned along with
+		// the error.
+		msg := service.NewMessage(nil)
+		doc := map[string]any{
+			"event_name": string(r.EventName),
+		}
+		if r.EventID != nil {
+			doc["event_id"] = *r.EventID
+		}
+		msg.SetStructuredMut(doc)
+		msg.MetaSetMut("dynamodb_stream_arn", streamARN)
+		msg.MetaSetMut("dynamodb_shard", shardID)
+		return msg, "", errors.New("record is missing a sequence number")
+	}
+	sequence := *r.Dynamodb.SequenceNumber
+
+	doc := map[string]any{
+		"event_name": string(r.EventName),
+	}
+	if r.EventID != nil {
+		doc["event_id"] = *r.EventID
+	}
+	if r.Dynamodb.Keys != nil {
+		doc["keys"] = ddbsAttributeMapToValue(r.Dynamodb.Keys)
+	}
+	if r.Dynamodb.NewImage != nil {
+		doc["new_image"] = ddbsAttributeMapToValue(r.Dynamodb.NewImage)
+	}
+	if r.Dynamodb.OldImage != nil {
+		doc["old_image"] = ddbsAttributeMapToValue(r.Dynamodb.OldImage)
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructuredMut(doc)
+	msg.MetaSetMut("dynamodb_stream_arn", streamARN)
+	msg.MetaSetMut("dynamodb_shard", shardID)
+	msg.MetaSetMut("dynamodb_sequence_number", sequence)
+	msg.MetaSetMut("dynamodb_event_name", string(r.EventName))
+	if r.EventID != nil {
+		msg.MetaSetMut("dynamodb_event_id", *r.EventID)
+	}
+	if t := r.Dynamodb.ApproximateCreationDateTime; t != nil {
+		msg.MetaSetMut("dynamodb_approximate_creation_time", t.Format(time.RFC3339))
+	}
+	return msg, sequence, nil
+}
+
+func ddbsAttributeMapToValue(m map[string]types.AttributeValue) map[string]any {
+	obj := make(map[string]any, len(m))
+	for k, v := range m {
+		obj[k] = ddbsAttributeToValue(v)
+	}
+	return obj
+}
+
+func ddbsNumberToValue(n string) any {
+	if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(n, 64); err == nil {
+		return f
+	}
+	return n
+}
+
+// ddbsAttributeToValue converts a DynamoDB attribute value into a plain value.
+func ddbsAttributeToValue(v types.AttributeValue) any {
+	switch t := v.(type) {
+	case *types.AttributeValueMemberS:
+		return t.Value
+	case *types.AttributeValueMemberN:
+		return ddbsNumberToValue(t.Value)
+	case *types.AttributeValueMemberB:
+		return t.Value
+	case *types.AttributeValueMemberBOOL:
+		return t.Value
+	case *types.AttributeValueMemberNULL:
+		return nil
+	case *types.AttributeValueMemberM:
+		return ddbsAttributeMapToValue(t.Value)
+	case *types.AttributeValueMemberL:
+		arr := make([]any, len(t.Value))
+		for i, e := range t.Value {
+			arr[i] = ddbsAttributeToValue(e)
+		}
+		return arr
+	case *types.AttributeValueMemberSS:
+		arr := make([]any, len(t.Value))
+		for i, e := range t.Value {
+			arr[i] = e
+		}
+		return arr
+	case *types.AttributeValueMemberNS:
+		arr := make([]any, len(t.Value))
+		for i, e := range t.Value {
+			arr[i] = ddbsNumberToValue(e)
+		}
+		return arr
+	case *types.AttributeValueMemberBS:
+		arr := make([]any, len(t.Value))
+		for i, e := range t.Value {
+			arr[i] = e
+		}
+		return arr
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *dynamoDBStreamsReader) Connect(ctx context.Context) error {
+	d.cMut.Lock()
+	defer d.cMut.Unlock()
+	if d.msgChan != nil {
+		return nil
+	}
+
+	tableRes, err := dynamodb.NewFromConfig(d.sess).DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: &d.conf.Table,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe table %v: %w", d.conf.Table, err)
+	}
+	if tableRes.Table.LatestStreamArn == nil {
+		return fmt.Errorf("table %v does not have a stream enabled", d.conf.Table)
+	}
+
+	checkpointer, err := newAWSKinesisCheckpointer(d.sess, d.clientID, d.conf.Checkpointer, d.conf.LeasePeriod, d.conf.CommitPeriod)
+	if err != nil {
+		return err
+	}
+
+	d.svc = dynamodbstreams.NewFromConfig(d.sess)
+	d.checkpointer = checkpointer
+	d.streamARN = *tableRes.Table.LatestStreamArn
+	d.msgChan = make(chan asyncMessage)
+
+	go d.runBalancedShards()
+	return nil
+}
+
+func (d *dynamoDBStreamsReader) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	d.cMut.Lock()
+	msgChan := d.msgChan
+	d.cMut.Unlock()
+
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case m, open := <-msgChan:
+		if !open {
+			return nil, nil, service.ErrNotConnected
+		}
+		return m.msg, m.ackFn, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (d *dynamoDBStreamsReader) Close(ctx context.Context) error {
+	d.done()
+
+	d.cMut.Lock()
+	started := d.msgChan != nil
+	d.cMut.Unlock()
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-d.closedChan:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
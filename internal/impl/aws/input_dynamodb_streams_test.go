@@ -0,0 +1,233 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBStreamsReadyShards(t *testing.T) {
+	openShard := func(id, parent string) types.Shard {
+		s := types.Shard{
+			ShardId: aws.String(id),
+			SequenceNumberRange: &types.SequenceNumberRange{
+				StartingSequenceNumber: aws.String("1"),
+			},
+		}
+		if parent != "" {
+			s.ParentShardId = aws.String(parent)
+		}
+		return s
+	}
+	closedShard := func(id, parent string) types.Shard {
+		s := openShard(id, parent)
+		s.SequenceNumberRange.EndingSequenceNumber = aws.String("10")
+		return s
+	}
+
+	tests := []struct {
+		name            string
+		shards          []types.Shard
+		checkpoints     map[string]string
+		startFromOldest bool
+		expected        map[string]types.ShardIteratorType
+	}{
+		{
+			name:            "single open shard from oldest",
+			shards:          []types.Shard{openShard("a", "")},
+			startFromOldest: true,
+			expected: map[string]types.ShardIteratorType{
+				"a": types.ShardIteratorTypeTrimHorizon,
+			},
+		},
+		{
+			name:   "single open shard from latest",
+			shards: []types.Shard{openShard("a", "")},
+			expected: map[string]types.ShardIteratorType{
+				"a": types.ShardIteratorTypeLatest,
+			},
+		},
+		{
+			name:            "child waits for parent",
+			shards:          []types.Shard{closedShard("a", ""), openShard("b", "a"), openShard("c", "a")},
+			startFromOldest: true,
+			expected: map[string]types.ShardIteratorType{
+				"a": types.ShardIteratorTypeTrimHorizon,
+			},
+		},
+		{
+			name:            "children ready after parent finished",
+			shards:          []types.Shard{closedShard("a", ""), openShard("b", "a"), openShard("c", "a")},
+			checkpoints:     map[string]string{"a": ddbsShardEnd, "b": "5"},
+			startFromOldest: true,
+			expected: map[string]types.ShardIteratorType{
+				"b": types.ShardIteratorTypeTrimHorizon,
+				"c": types.ShardIteratorTypeTrimHorizon,
+			},
+		},
+		{
+			name:        "children of finished parent consumed from start with latest",
+			shards:      []types.Shard{closedShard("a", ""), openShard("b", "a")},
+			checkpoints: map[string]string{"a": ddbsShardEnd},
+			expected: map[string]types.ShardIteratorType{
+				"b": types.ShardIteratorTypeTrimHorizon,
+			},
+		},
+		{
+			name:   "closed shards without checkpoints skipped with latest",
+			shards: []types.Shard{closedShard("a", ""), closedShard("b", "a"), openShard("c", "b")},
+			expected: map[string]types.ShardIteratorType{
+				"c": types.ShardIteratorTypeLatest,
+			},
+		},
+		{
+			name:        "checkpointed closed shard not skipped with latest",
+			shards:      []types.Shard{closedShard("a", ""), openShard("b", "a")},
+			checkpoints: map[string]string{"a": "3"},
+			expected: map[string]types.ShardIteratorType{
+				"a": types.ShardIteratorTypeLatest,
+			},
+		},
+		{
+			name:            "trimmed parent",
+			shards:          []types.Shard{openShard("b", "a")},
+			startFromOldest: true,
+			expected: map[string]types.ShardIteratorType{
+				"b": types.ShardIteratorTypeTrimHorizon,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			checkpoints := test.checkpoints
+			if checkpoints == nil {
+				checkpoints = map[string]string{}
+			}
+			assert.Equal(t, test.expected, ddbsReadyShards(test.shards, checkpoints, test.startFromOldest))
+		})
+	}
+}
+
+func TestDynamoDBStreamsRecordToMessage(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	msg, seq, err := ddbsRecordToMessage("foo-arn", "shard-1", types.Record{
+		EventID:   aws.String("event-1"),
+		EventName: types.OperationTypeModify,
+		Dynamodb: &types.StreamRecord{
+			SequenceNumber:              aws.String("100"),
+			ApproximateCreationDateTime: &created,
+			Keys: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: "foo"},
+			},
+			NewImage: map[string]types.AttributeValue{
+				"id":     &types.AttributeValueMemberS{Value: "foo"},
+				"count":  &types.AttributeValueMemberN{Value: "2"},
+				"ratio":  &types.AttributeValueMemberN{Value: "0.5"},
+				"active": &types.AttributeValueMemberBOOL{Value: true},
+				"none":   &types.AttributeValueMemberNULL{Value: true},
+				"data":   &types.AttributeValueMemberB{Value: []byte("hello")},
+				"tags":   &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+				"nums":   &types.AttributeValueMemberNS{Value: []string{"1", "2.5"}},
+				"list": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+					&types.AttributeValueMemberS{Value: "x"},
+					&types.AttributeValueMemberN{Value: "3"},
+				}},
+				"nested": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"inner": &types.AttributeValueMemberS{Value: "y"},
+				}},
+			},
+			OldImage: map[string]types.AttributeValue{
+				"id":    &types.AttributeValueMemberS{Value: "foo"},
+				"count": &types.AttributeValueMemberN{Value: "1"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "100", seq)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "event_id": "event-1",
+  "event_name": "MODIFY",
+  "keys": { "id": "foo" },
+  "new_image": {
+    "id": "foo",
+    "count": 2,
+    "ratio": 0.5,
+    "active": true,
+    "none": null,
+    "data": "aGVsbG8=",
+    "tags": ["a", "b"],
+    "nums": [1, 2.5],
+    "list": ["x", 3],
+    "nested": { "inner": "y" }
+  },
+  "old_image": { "id": "foo", "count": 1 }
+}`, string(b))
+
+	for k, v := range map[string]string{
+		"dynamodb_stream_arn":                "foo-arn",
+		"dynamodb_shard":                     "shard-1",
+		"dynamodb_sequence_number":           "100",
+		"dynamodb_event_id":                  "event-1",
+		"dynamodb_event_name":                "MODIFY",
+		"dynamodb_approximate_creation_time": "2024-01-02T03:04:05Z",
+	} {
+		actual, exists := msg.MetaGet(k)
+		assert.True(t, exists, k)
+		assert.Equal(t, v, actual, k)
+	}
+}
+
+func TestDynamoDBStreamsRecordToMessageRemove(t *testing.T) {
+	msg, _, err := ddbsRecordToMessage("foo-arn", "shard-1", types.Record{
+		EventName: types.OperationTypeRemove,
+		Dynamodb: &types.StreamRecord{
+			SequenceNumber: aws.String("101"),
+			Keys: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberN{Value: "5"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event_name":"REMOVE","keys":{"id":5}}`, string(b))
+
+	// Records that can't be converted are still returned so that they can be
+	// delivered with the error
+	msg, seq, err := ddbsRecordToMessage("foo-arn", "shard-1", types.Record{
+		EventID:   aws.String("event-2"),
+		EventName: types.OperationTypeInsert,
+	})
+	require.EqualError(t, err, "record is missing a sequence number")
+	require.NotNil(t, msg)
+	assert.Empty(t, seq)
+
+	b, err = msg.AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event_id":"event-2","event_name":"INSERT"}`, string(b))
+}
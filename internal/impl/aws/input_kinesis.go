@@ -42,6 +42,10 @@ const (
 	kiddbFieldWriteCapacityUnits = "write_capacity_units"
 	kiddbFieldBillingMode        = "billing_mode"
 
+	// Kinesis Input Enhanced Fan-Out Fields
+	kiefoFieldEnabled      = "enabled"
+	kiefoFieldConsumerName = "consumer_name"
+
 	// Kinesis Input Fields
 	kiFieldDynamoDB        = "dynamodb"
 	kiFieldStreams         = "streams"
@@ -51,6 +55,7 @@ const (
 	kiFieldRebalancePeriod = "rebalance_period"
 	kiFieldStartFromOldest = "start_from_oldest"
 	kiFieldBatching        = "batching"
+	kiFieldEnhancedFanOut  = "enhanced_fan_out"
 )
 
 type kiConfig struct {
@@ -61,6 +66,12 @@ type kiConfig struct {
 	LeasePeriod     string
 	RebalancePeriod string
 	StartFromOldest bool
+	EnhancedFanOut  kiEFOConfig
+}
+
+type kiEFOConfig struct {
+	Enabled      bool
+	ConsumerName string
 }
 
 func kinesisInputConfigFromParsed(pConf *service.ParsedConfig) (conf kiConfig, err error) {
@@ -87,6 +98,16 @@ func kinesisInputConfigFromParsed(pConf *service.ParsedConfig) (conf kiConfig, e
 	if conf.StartFromOldest, err = pConf.FieldBool(kiFieldStartFromOldest); err != nil {
 		return
 	}
+	efoConf := pConf.Namespace(kiFieldEnhancedFanOut)
+	if conf.EnhancedFanOut.Enabled, err = efoConf.FieldBool(kiefoFieldEnabled); err != nil {
+		return
+	}
+	if conf.EnhancedFanOut.ConsumerName, err = efoConf.FieldString(kiefoFieldConsumerName); err != nil {
+		return
+	}
+	if conf.EnhancedFanOut.Enabled && conf.EnhancedFanOut.ConsumerName == "" {
+		err = errors.New("an enhanced fan-out consumer_name must be specified")
+	}
 	return
 }
 
@@ -112,30 +133,17 @@ It's possible to configure Redpanda Connect to create the DynamoDB table require
 == Batching
 
 Use the `+"`batching`"+` fields to configure an optional xref:configuration:batching.adoc#batch-policy[batching policy]. Each stream shard will be batched separately in order to ensure that acknowledgements aren't contaminated.
+
+== Enhanced fan-out
+
+By default shards are consumed by polling for records, which shares the read throughput of each shard with all other consumers of the stream. When `+"`enhanced_fan_out.enabled`"+` is `+"`true`"+` a stream consumer is registered with the configured name, and records are instead pushed to this input over subscriptions with a dedicated throughput of 2MB/s per shard. Subscriptions expire after five minutes, after which they are renewed from the last consumed record.
+
+Each independent pipeline consuming the same stream should use a distinct consumer name, whereas balanced instances of the same pipeline should share one. Shards are still balanced and checkpointed using the DynamoDB table as normal.
 `).Fields(
 		service.NewStringListField(kiFieldStreams).
 			Description("One or more Kinesis data streams to consume from. Streams can either be specified by their name or full ARN. Shards of a stream are automatically balanced across consumers by coordinating through the provided DynamoDB table. Multiple comma separated streams can be listed in a single element. Shards are automatically distributed across consumers of a stream by coordinating through the provided DynamoDB table. Alternatively, it's possible to specify an explicit shard to consume from with a colon after the stream name, e.g. `foo:0` would consume the shard `0` of the stream `foo`.").
 			Examples([]any{"foo", "arn:aws:kinesis:*:111122223333:stream/my-stream"}),
-		service.NewObjectField(kiFieldDynamoDB,
-			service.NewStringField(kiddbFieldTable).
-				Description("The name of the table to access.").
-				Default(""),
-			service.NewBoolField(kiddbFieldCreate).
-				Description("Whether, if the table does not exist, it should be created.").
-				Default(false),
-			service.NewStringEnumField(kiddbFieldBillingMode, "PROVISIONED", "PAY_PER_REQUEST").
-				Description("When creating the table determines the billing mode.").
-				Default("PAY_PER_REQUEST").
-				Advanced(),
-			service.NewIntField(kiddbFieldReadCapacityUnits).
-				Description("Set the provisioned read capacity when creating the table with a `billing_mode` of `PROVISIONED`.").
-				Default(0).
-				Advanced(),
-			service.NewIntField(kiddbFieldWriteCapacityUnits).
-				Description("Set the provisioned write capacity when creating the table with a `billing_mode` of `PROVISIONED`.").
-				Default(0).
-				Advanced(),
-		).
+		service.NewObjectField(kiFieldDynamoDB, kinesisInputDynamoDBFields()...).
 			Description("Determines the table used for storing and accessing the latest consumed sequence for shards, and for coordinating balanced consumers of streams."),
 		service.NewIntField(kiFieldCheckpointLimit).
 			Description("The maximum gap between the in flight sequence versus the latest acknowledged sequence at a given time. Increasing this limit enables parallel processing and batching at the output level to work on individual shards. Any given sequence will not be committed unless all messages under that offset are delivered in order to preserve at least once delivery guarantees.").
@@ -155,6 +163,18 @@ Use the `+"`batching`"+` fields to configure an optional xref:configuration:batc
 		service.NewBoolField(kiFieldStartFromOldest).
 			Description("Whether to consume from the oldest message when a sequence does not yet exist for the stream.").
 			Default(true),
+		service.NewObjectField(kiFieldEnhancedFanOut,
+			service.NewBoolField(kiefoFieldEnabled).
+				Description("Whether to consume shards with enhanced fan-out subscriptions rather than by polling.").
+				Default(false),
+			service.NewStringField(kiefoFieldConsumerName).
+				Description("The name of the stream consumer to register, or use if it already exists.").
+				Default("").
+				Example("my-pipeline"),
+		).
+			Description("Consume shards with enhanced fan-out, which provides each registered stream consumer with its own read throughput.").
+			Version("4.33.0").
+			Advanced(),
 	).
 		Fields(config.SessionFields()...).
 		Field(service.NewBatchPolicyField(kiFieldBatching))
@@ -188,6 +208,7 @@ type streamInfo struct {
 	explicitShards []string
 	id             string // Either a name or arn, extracted from config and used for balancing shards
 	arn            string
+	consumerARN    string // Set when consuming with enhanced fan-out
 }
 
 type kinesisReader struct {
@@ -452,8 +473,18 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, info streamInfo, shardID
 	// Stores consumed records that have yet to be added to the batcher.
 	var pending []types.Record
 	var iter string
-	if iter, initErr = k.getIter(info, shardID, startingSequence); initErr != nil {
-		return initErr
+	if info.consumerARN == "" {
+		if iter, initErr = k.getIter(info, shardID, startingSequence); initErr != nil {
+			return initErr
+		}
+	}
+
+	// When consuming with enhanced fan-out records are pushed to us by a
+	// subscription, which is closed when this consumer exits.
+	var subChan, nextSubChan <-chan []types.Record
+	subCtx, subDone := context.WithCancel(k.ctx)
+	if info.consumerARN != "" {
+		subChan = k.subscribeToShard(subCtx, info, shardID, startingSequence)
 	}
 
 	// Keeps track of the latest state of the consumer.
@@ -468,18 +499,25 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, info streamInfo, shardID
 	// 1. Timed batches, this might be nil when timed batches are disabled.
 	// 2. Record pulling, this might be unblocked (closed channel) when we run
 	//    out of pending records, or a timed channel when our last attempt
-	//    yielded zero records.
+	//    yielded zero records. When consuming with enhanced fan-out this is
+	//    always blocked and records are instead received from the
+	//    subscription, which is only listened to when we run out of pending
+	//    records.
 	// 3. Message flush, this is the target of our current batched message, and
 	//    is nil when our current batched message is a zero value (we don't have
 	//    one prepared).
 	// 4. Next commit, is "done" when the next commit is due.
 	var nextTimedBatchChan <-chan time.Time
 	var nextPullChan <-chan time.Time = unblockedChan
+	if subChan != nil {
+		nextPullChan = blockedChan
+	}
 	var nextFlushChan chan<- asyncMessage
 	commitCtx, commitCtxClose := context.WithTimeout(k.ctx, k.commitPeriod)
 
 	go func() {
 		defer func() {
+			subDone()
 			commitCtxClose()
 			recordBatcher.Close(context.Background(), state == awsKinesisConsumerFinished)
 			boff.Reset()
@@ -514,14 +552,19 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, info streamInfo, shardID
 		// as otherwise it's set to a timed channel that we do not want to
 		// disturb.
 		unblockPullChan := func() {
-			if nextPullChan == blockedChan {
+			if nextPullChan == blockedChan && subChan == nil {
 				nextPullChan = unblockedChan
 			}
 		}
 
 		for {
 			var err error
-			if state == awsKinesisConsumerConsuming && len(pending) == 0 && nextPullChan == unblockedChan {
+			if subChan != nil {
+				// Wait for the subscription to push more records.
+				if state == awsKinesisConsumerConsuming && len(pending) == 0 {
+					nextSubChan = subChan
+				}
+			} else if state == awsKinesisConsumerConsuming && len(pending) == 0 && nextPullChan == unblockedChan {
 				if pending, iter, err = k.getRecords(info, shardID, iter); err != nil {
 					if !awsErrIsTimeout(err) {
 						nextPullChan = time.After(boff.NextBackOff())
@@ -623,6 +666,12 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, info streamInfo, shardID
 				pendingMsg = asyncMessage{}
 			case <-nextPullChan:
 				nextPullChan = unblockedChan
+			case records, open := <-nextSubChan:
+				nextSubChan = nil
+				if !open {
+					state = awsKinesisConsumerFinished
+				}
+				pending = records
 			case <-k.ctx.Done():
 				state = awsKinesisConsumerClosing
 				return
@@ -865,12 +914,22 @@ func (k *kinesisReader) Connect(ctx context.Context) error {
 
 	k.svc = svc
 	k.checkpointer = checkpointer
-	k.msgChan = make(chan asyncMessage)
 
 	if err = k.waitUntilStreamsExists(ctx); err != nil {
 		return err
 	}
 
+	if k.conf.EnhancedFanOut.Enabled {
+		for _, info := range k.streams {
+			if info.consumerARN, err = k.registerStreamConsumer(ctx, info.arn); err != nil {
+				return fmt.Errorf("failed to register enhanced fan-out consumer for stream '%v': %w", info.id, err)
+			}
+		}
+	}
+
+	// The message channel indicates that the input is connected and is
+	// therefore only set once all streams are ready to be consumed.
+	k.msgChan = make(chan asyncMessage)
 	if len(k.streams[0].explicitShards) > 0 {
 		go k.runExplicitShards()
 	} else {
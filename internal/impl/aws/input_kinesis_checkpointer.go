@@ -42,6 +42,29 @@ type kiddbConfig struct {
 	BillingMode        string
 }
 
+func kinesisInputDynamoDBFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField(kiddbFieldTable).
+			Description("The name of the table to access.").
+			Default(""),
+		service.NewBoolField(kiddbFieldCreate).
+			Description("Whether, if the table does not exist, it should be created.").
+			Default(false),
+		service.NewStringEnumField(kiddbFieldBillingMode, "PROVISIONED", "PAY_PER_REQUEST").
+			Description("When creating the table determines the billing mode.").
+			Default("PAY_PER_REQUEST").
+			Advanced(),
+		service.NewIntField(kiddbFieldReadCapacityUnits).
+			Description("Set the provisioned read capacity when creating the table with a `billing_mode` of `PROVISIONED`.").
+			Default(0).
+			Advanced(),
+		service.NewIntField(kiddbFieldWriteCapacityUnits).
+			Description("Set the provisioned write capacity when creating the table with a `billing_mode` of `PROVISIONED`.").
+			Default(0).
+			Advanced(),
+	}
+}
+
 func kinesisInputDynamoDBConfigFromParsed(pConf *service.ParsedConfig) (conf kiddbConfig, err error) {
 	if conf.Table, err = pConf.FieldString(kiddbFieldTable); err != nil {
 		return
@@ -420,6 +443,40 @@ func (k *awsKinesisCheckpointer) Yield(ctx context.Context, streamID, shardID, s
 	return err
 }
 
+// AllCheckpoints returns the checkpointed sequence of each shard of a stream,
+// regardless of whether the shard is currently claimed.
+func (k *awsKinesisCheckpointer) AllCheckpoints(ctx context.Context, streamID string) (map[string]string, error) {
+	checkpoints := map[string]string{}
+
+	paginator := dynamodb.NewScanPaginator(k.svc, &dynamodb.ScanInput{
+		TableName:        aws.String(k.conf.Table),
+		FilterExpression: aws.String("StreamID = :stream_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":stream_id": &types.AttributeValueMemberS{
+				Value: streamID,
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range page.Items {
+			shardID, ok := i["ShardID"].(*types.AttributeValueMemberS)
+			if !ok {
+				return nil, errors.New("failed to extract shard id from checkpoint")
+			}
+			var sequence string
+			if s, ok := i["SequenceNumber"].(*types.AttributeValueMemberS); ok {
+				sequence = s.Value
+			}
+			checkpoints[shardID.Value] = sequence
+		}
+	}
+	return checkpoints, nil
+}
+
 // Delete attempts to delete a checkpoint, this should be called when a shard is
 // emptied.
 func (k *awsKinesisCheckpointer) Delete(ctx context.Context, streamID, shardID string) error {
@@ -0,0 +1,186 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Kinesis closes enhanced fan-out subscriptions after five minutes, we allow
+// some leeway on top of this before assuming a subscription has been lost.
+const kinesisSubscriptionPeriod = 5*time.Minute + 30*time.Second
+
+// registerStreamConsumer registers the configured enhanced fan-out consumer
+// with a stream, or reuses it if it already exists, and waits until it is
+// active.
+func (k *kinesisReader) registerStreamConsumer(ctx context.Context, streamARN string) (string, error) {
+	consumerName := k.conf.EnhancedFanOut.ConsumerName
+
+	var consumerARN string
+	res, err := k.svc.RegisterStreamConsumer(ctx, &kinesis.RegisterStreamConsumerInput{
+		StreamARN:    &streamARN,
+		ConsumerName: &consumerName,
+	})
+	if err != nil {
+		var aerr *types.ResourceInUseException
+		if !errors.As(err, &aerr) {
+			return "", err
+		}
+	} else {
+		consumerARN = *res.Consumer.ConsumerARN
+	}
+
+	for {
+		input := &kinesis.DescribeStreamConsumerInput{}
+		if consumerARN != "" {
+			input.ConsumerARN = &consumerARN
+		} else {
+			input.StreamARN = &streamARN
+			input.ConsumerName = &consumerName
+		}
+
+		desc, err := k.svc.DescribeStreamConsumer(ctx, input)
+		if err != nil {
+			return "", err
+		}
+
+		consumerARN = *desc.ConsumerDescription.ConsumerARN
+		if desc.ConsumerDescription.ConsumerStatus == types.ConsumerStatusActive {
+			return consumerARN, nil
+		}
+
+		k.log.Debugf("Waiting for enhanced fan-out consumer '%v' to become active", consumerName)
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// subscribeToShard continuously subscribes to a shard with enhanced fan-out,
+// starting after the provided sequence, and pushes records to the returned
+// channel. Subscriptions are renewed from the last record pushed each time they
+// expire, and the channel is closed once the end of the shard has been reached.
+func (k *kinesisReader) subscribeToShard(ctx context.Context, info streamInfo, shardID, sequence string) <-chan []types.Record {
+	recordsChan := make(chan []types.Record)
+
+	go func() {
+		boff := k.boffPool.Get().(backoff.BackOff)
+		defer func() {
+			boff.Reset()
+			k.boffPool.Put(boff)
+		}()
+
+		for {
+			finished, err := k.runShardSubscription(ctx, info, shardID, &sequence, recordsChan)
+			if finished {
+				close(recordsChan)
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err == nil {
+				boff.Reset()
+				k.log.Tracef("Renewing subscription to stream '%v' shard '%v'", info.id, shardID)
+				continue
+			}
+
+			var inUseErr *types.ResourceInUseException
+			if errors.As(err, &inUseErr) {
+				// A previous subscription to the shard, possibly from another
+				// client that owned it, is still active.
+				k.log.Debugf("Subscription to stream '%v' shard '%v' is in use, retrying", info.id, shardID)
+			} else {
+				k.log.Errorf("Failed to subscribe to stream '%v' shard '%v': %v", info.id, shardID, err)
+			}
+
+			select {
+			case <-time.After(boff.NextBackOff()):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return recordsChan
+}
+
+// runShardSubscription runs a single subscription until it expires, updating
+// the sequence each time records are pushed. Returns true if the end of the
+// shard was reached.
+func (k *kinesisReader) runShardSubscription(ctx context.Context, info streamInfo, shardID string, sequence *string, recordsChan chan<- []types.Record) (bool, error) {
+	startingPosition := &types.StartingPosition{
+		Type: types.ShardIteratorTypeTrimHorizon,
+	}
+	if *sequence != "" {
+		startingPosition.Type = types.ShardIteratorTypeAfterSequenceNumber
+		startingPosition.SequenceNumber = sequence
+	} else if !k.conf.StartFromOldest {
+		startingPosition.Type = types.ShardIteratorTypeLatest
+	}
+
+	subCtx, done := context.WithTimeout(ctx, kinesisSubscriptionPeriod)
+	defer done()
+
+	res, err := k.svc.SubscribeToShard(subCtx, &kinesis.SubscribeToShardInput{
+		ConsumerARN:      &info.consumerARN,
+		ShardId:          &shardID,
+		StartingPosition: startingPosition,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	stream := res.GetStream()
+	defer stream.Close()
+
+	for event := range stream.Events() {
+		e, ok := event.(*types.SubscribeToShardEventStreamMemberSubscribeToShardEvent)
+		if !ok {
+			continue
+		}
+
+		if len(e.Value.Records) > 0 {
+			select {
+			case recordsChan <- e.Value.Records:
+			case <-subCtx.Done():
+				return false, subCtx.Err()
+			}
+			if seq := e.Value.Records[len(e.Value.Records)-1].SequenceNumber; seq != nil {
+				*sequence = *seq
+			}
+		}
+
+		// A missing continuation sequence indicates that the shard has been
+		// closed and all of its records have been delivered.
+		if e.Value.ContinuationSequenceNumber == nil {
+			return true, nil
+		}
+	}
+
+	if err := stream.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return false, err
+	}
+	return false, nil
+}
@@ -44,17 +44,29 @@ type awsKinesisRecordBatcher struct {
 }
 
 func (k *kinesisReader) newAWSKinesisRecordBatcher(info streamInfo, shardID, sequence string) (*awsKinesisRecordBatcher, error) {
-	batchPolicy, err := k.batcher.NewBatcher(k.mgr)
+	return newAWSShardRecordBatcher(k.batcher, k.mgr, k.conf.CheckpointLimit, info.id, shardID, sequence)
+}
+
+// newAWSShardRecordBatcher creates a batcher for the records of a shard, which
+// is shared by inputs that consume sharded streams.
+func newAWSShardRecordBatcher(
+	batcher service.BatchPolicy,
+	mgr *service.Resources,
+	checkpointLimit int,
+	streamID, shardID, sequence string,
+) (*awsKinesisRecordBatcher, error) {
+	batchPolicy, err := batcher.NewBatcher(mgr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize batch policy for shard consumer: %w", err)
 	}
 
 	return &awsKinesisRecordBatcher{
-		streamID:      info.id,
-		shardID:       shardID,
-		batchPolicy:   batchPolicy,
-		checkpointer:  checkpoint.NewCapped[string](int64(k.conf.CheckpointLimit)),
-		ackedSequence: sequence,
+		streamID:        streamID,
+		shardID:         shardID,
+		batchPolicy:     batchPolicy,
+		checkpointer:    checkpoint.NewCapped[string](int64(checkpointLimit)),
+		batchedSequence: sequence,
+		ackedSequence:   sequence,
 	}, nil
 }
 
@@ -66,8 +78,17 @@ func (a *awsKinesisRecordBatcher) AddRecord(r types.Record) bool {
 		p.MetaSetMut("kinesis_partition_key", *r.PartitionKey)
 	}
 	p.MetaSetMut("kinesis_sequence_number", *r.SequenceNumber)
+	return a.AddMessage(p, *r.SequenceNumber)
+}
 
-	a.batchedSequence = *r.SequenceNumber
+// AddMessage adds a message converted from a record with a given sequence,
+// returns true if a batch is ready to be flushed.
+func (a *awsKinesisRecordBatcher) AddMessage(p *service.Message, sequence string) bool {
+	// Messages without a sequence are tracked under the latest known sequence,
+	// which prevents the checkpoint from moving past them.
+	if sequence != "" {
+		a.batchedSequence = sequence
+	}
 	if a.flushedMessage != nil {
 		// Upstream shouldn't really be adding records if a prior flush was
 		// unsuccessful. However, we can still accommodate this by appending it
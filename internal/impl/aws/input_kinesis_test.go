@@ -15,10 +15,22 @@
 package aws
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
 )
 
 func TestStreamIDParser(t *testing.T) {
@@ -78,3 +90,140 @@ func TestStreamIDParser(t *testing.T) {
 		})
 	}
 }
+
+func TestKinesisEnhancedFanOutConfig(t *testing.T) {
+	pConf, err := kinesisInputSpec().ParseYAML(`
+streams: [ foo ]
+enhanced_fan_out:
+  enabled: true
+  consumer_name: my-pipeline
+`, nil)
+	require.NoError(t, err)
+
+	conf, err := kinesisInputConfigFromParsed(pConf)
+	require.NoError(t, err)
+	assert.Equal(t, kiEFOConfig{Enabled: true, ConsumerName: "my-pipeline"}, conf.EnhancedFanOut)
+
+	pConf, err = kinesisInputSpec().ParseYAML(`
+streams: [ foo ]
+enhanced_fan_out:
+  enabled: true
+`, nil)
+	require.NoError(t, err)
+
+	_, err = kinesisInputConfigFromParsed(pConf)
+	require.EqualError(t, err, "an enhanced fan-out consumer_name must be specified")
+}
+
+type fakeKinesisEFOServer struct {
+	mut               sync.Mutex
+	registerFailures  int
+	registerCalls     int
+	subscribedARNChan chan string
+}
+
+func (f *fakeKinesisEFOServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	respond := func(status int, resBody string) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(resBody))
+	}
+
+	const (
+		streamARN   = "arn:aws:kinesis:us-east-1:000000000000:stream/foo"
+		consumerARN = streamARN + "/consumer/bar:1"
+	)
+
+	target := r.Header.Get("X-Amz-Target")
+	switch target[strings.LastIndex(target, ".")+1:] {
+	case "DescribeTable":
+		respond(http.StatusOK, `{"Table":{"TableName":"foo","TableStatus":"ACTIVE"}}`)
+	case "DescribeStream":
+		respond(http.StatusOK, `{"StreamDescription":{"StreamName":"foo","StreamARN":"`+streamARN+`","StreamStatus":"ACTIVE"}}`)
+	case "RegisterStreamConsumer":
+		f.mut.Lock()
+		f.registerCalls++
+		fail := f.registerCalls <= f.registerFailures
+		f.mut.Unlock()
+		if fail {
+			respond(http.StatusBadRequest, `{"__type":"InvalidArgumentException","message":"nope"}`)
+			return
+		}
+		respond(http.StatusOK, `{"Consumer":{"ConsumerName":"bar","ConsumerARN":"`+consumerARN+`","ConsumerStatus":"ACTIVE"}}`)
+	case "DescribeStreamConsumer":
+		respond(http.StatusOK, `{"ConsumerDescription":{"ConsumerName":"bar","ConsumerARN":"`+consumerARN+`","ConsumerStatus":"ACTIVE","StreamARN":"`+streamARN+`"}}`)
+	case "SubscribeToShard":
+		var input struct {
+			ConsumerARN string
+		}
+		_ = json.Unmarshal(body, &input)
+		select {
+		case f.subscribedARNChan <- input.ConsumerARN:
+		default:
+		}
+		respond(http.StatusBadRequest, `{"__type":"ResourceNotFoundException","message":"nope"}`)
+	default:
+		respond(http.StatusOK, `{}`)
+	}
+}
+
+func TestKinesisEnhancedFanOutReconnect(t *testing.T) {
+	fake := &fakeKinesisEFOServer{
+		registerFailures:  1,
+		subscribedARNChan: make(chan string, 1),
+	}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	pConf, err := kinesisInputSpec().ParseYAML(`
+streams: [ foo:0 ]
+dynamodb:
+  table: foo
+enhanced_fan_out:
+  enabled: true
+  consumer_name: bar
+`, nil)
+	require.NoError(t, err)
+
+	conf, err := kinesisInputConfigFromParsed(pConf)
+	require.NoError(t, err)
+
+	sess := aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("xxxxx", "xxxxx", "xxxxx"),
+		BaseEndpoint: &srv.URL,
+		Retryer: func() aws.Retryer {
+			return aws.NopRetryer{}
+		},
+	}
+
+	k, err := newKinesisReaderFromConfig(conf, service.BatchPolicy{}, sess, service.MockResources())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	// A failed registration must leave the input disconnected so that the
+	// next attempt registers the consumer again.
+	require.ErrorContains(t, k.Connect(ctx), "failed to register enhanced fan-out consumer")
+	_, _, err = k.ReadBatch(ctx)
+	require.ErrorIs(t, err, service.ErrNotConnected)
+
+	require.NoError(t, k.Connect(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, k.Close(context.Background()))
+	})
+
+	select {
+	case arn := <-fake.subscribedARNChan:
+		assert.Equal(t, "arn:aws:kinesis:us-east-1:000000000000:stream/foo/consumer/bar:1", arn)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for shard subscription")
+	}
+
+	fake.mut.Lock()
+	assert.Equal(t, 2, fake.registerCalls)
+	fake.mut.Unlock()
+}
@@ -15,19 +15,23 @@
 package aws
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/Jeffail/checkpoint"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 
@@ -54,6 +58,20 @@ const (
 	s3iFieldForcePathStyleURLs = "force_path_style_urls"
 	s3iFieldDeleteObjects      = "delete_objects"
 	s3iFieldSQS                = "sqs"
+	s3iFieldCheckpoint         = "checkpoint"
+	s3iFieldDownload           = "download"
+
+	// S3 Input Checkpoint Fields
+	s3iCheckpointFieldCache = "cache"
+	s3iCheckpointFieldKey   = "key"
+	s3iCheckpointFieldMode  = "mode"
+
+	// S3 Input Download Fields
+	s3iDownloadFieldConcurrency = "concurrency"
+	s3iDownloadFieldPartSize    = "part_size"
+
+	s3iCheckpointModeLastKey       = "last_key"
+	s3iCheckpointModeProcessedKeys = "processed_keys"
 )
 
 type s3iSQSConfig struct {
@@ -95,12 +113,48 @@ func s3iSQSConfigFromParsed(pConf *service.ParsedConfig) (conf s3iSQSConfig, err
 	return
 }
 
+type s3iCheckpointConfig struct {
+	Cache string
+	Key   string
+	Mode  string
+}
+
+func s3iCheckpointConfigFromParsed(pConf *service.ParsedConfig) (conf s3iCheckpointConfig, err error) {
+	if conf.Cache, err = pConf.FieldString(s3iCheckpointFieldCache); err != nil {
+		return
+	}
+	if conf.Key, err = pConf.FieldString(s3iCheckpointFieldKey); err != nil {
+		return
+	}
+	if conf.Mode, err = pConf.FieldString(s3iCheckpointFieldMode); err != nil {
+		return
+	}
+	return
+}
+
+type s3iDownloadConfig struct {
+	Concurrency int
+	PartSize    int64
+}
+
+func s3iDownloadConfigFromParsed(pConf *service.ParsedConfig) (conf s3iDownloadConfig, err error) {
+	if conf.Concurrency, err = pConf.FieldInt(s3iDownloadFieldConcurrency); err != nil {
+		return
+	}
+	if conf.PartSize, err = int64Field(pConf, s3iDownloadFieldPartSize); err != nil {
+		return
+	}
+	return
+}
+
 type s3iConfig struct {
 	Bucket             string
 	Prefix             string
 	ForcePathStyleURLs bool
 	DeleteObjects      bool
 	SQS                s3iSQSConfig
+	Checkpoint         s3iCheckpointConfig
+	Download           s3iDownloadConfig
 	CodecCtor          codec.DeprecatedFallbackCodec
 }
 
@@ -125,6 +179,12 @@ func s3iConfigFromParsed(pConf *service.ParsedConfig) (conf s3iConfig, err error
 			return
 		}
 	}
+	if conf.Checkpoint, err = s3iCheckpointConfigFromParsed(pConf.Namespace(s3iFieldCheckpoint)); err != nil {
+		return
+	}
+	if conf.Download, err = s3iDownloadConfigFromParsed(pConf.Namespace(s3iFieldDownload)); err != nil {
+		return
+	}
 	return
 }
 
@@ -148,6 +208,17 @@ When using SQS please make sure you have sensible values for `+"`sqs.max_message
 
 When downloading large files it's often necessary to process it in streamed parts in order to avoid loading the entire file in memory at a given time. In order to do this a `+"<<scanner, `scanner`>>"+` can be specified that determines how to break the input into smaller individual messages.
 
+Large objects can also be downloaded faster by setting `+"`download.concurrency`"+` above one, in which case objects found by walking the bucket that are larger than `+"`download.part_size`"+` are fetched with parallel byte-range requests, and the parts are fed to the scanner in order. Up to `+"`download.concurrency`"+` parts of each object are held in memory at any given time.
+
+== Resume walking a bucket
+
+When walking a bucket without deleting objects a restart of Redpanda Connect consumes the entire bucket (or prefix) again. In order to avoid this a `+"`checkpoint.cache`"+` can be specified, which is a xref:components:caches/about.adoc[cache resource] used for storing the progress of the walk once objects have been acknowledged:
+
+- With the mode `+"`last_key`"+` the key of the last object, for which it and all objects before it have been processed, is stored under `+"`checkpoint.key`"+`, and a subsequent run only walks objects that come after it in lexicographical order. This mode is well suited to buckets where keys are written in order, such as those prefixed with a timestamp.
+- With the mode `+"`processed_keys`"+` the entity tag of each processed object is stored under a key consisting of `+"`checkpoint.key`"+` followed by the object key, and a subsequent run walks the entire bucket but skips objects that have already been processed and have not been modified since. This mode requires a request to the cache for each object walked.
+
+Checkpoints are not supported when consuming SQS notifications, and in both modes objects might be processed more than once in the event of a crash.
+
 == Credentials
 
 By default Redpanda Connect will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more  in xref:guides:cloud/aws.adoc[].
@@ -222,6 +293,31 @@ You can access these metadata fields using xref:configuration:interpolation.adoc
 			).
 				Description("Consume SQS messages in order to trigger key downloads.").
 				Optional(),
+			service.NewObjectField(s3iFieldCheckpoint,
+				service.NewStringField(s3iCheckpointFieldCache).
+					Description("A xref:components:caches/about.adoc[cache resource] used for storing the progress of walking the bucket once objects have been acknowledged, allowing the walk to resume upon restart. When empty the walk always starts from the beginning.").
+					Default(""),
+				service.NewStringField(s3iCheckpointFieldKey).
+					Description("The key under which the progress is stored, or the prefix of the keys of processed objects when the mode is `processed_keys`. This should be unique to each input sharing the same cache.").
+					Default("aws_s3_checkpoint"),
+				service.NewStringEnumField(s3iCheckpointFieldMode, s3iCheckpointModeLastKey, s3iCheckpointModeProcessedKeys).
+					Description("The way in which the progress of walking the bucket is stored.").
+					Default(s3iCheckpointModeLastKey).
+					Advanced(),
+			).
+				Description("Checkpoint the progress of walking a bucket in a cache resource, allowing it to resume after a restart.").
+				Version("4.33.0"),
+			service.NewObjectField(s3iFieldDownload,
+				service.NewIntField(s3iDownloadFieldConcurrency).
+					Description("The maximum number of parts of an object to download in parallel. When set to one objects are downloaded with a single request.").
+					Default(1),
+				service.NewIntField(s3iDownloadFieldPartSize).
+					Description("The size in bytes of each part of an object downloaded in parallel.").
+					Default(8*1024*1024),
+			).
+				Description("Download large objects with parallel byte-range requests.").
+				Advanced().
+				Version("4.33.0"),
 		)
 }
 
@@ -261,6 +357,7 @@ func init() {
 type s3ObjectTarget struct {
 	key            string
 	bucket         string
+	size           int64
 	notificationAt time.Time
 
 	ackFn func(context.Context, error) error
@@ -280,10 +377,16 @@ type s3ObjectTargetReader interface {
 	Close(ctx context.Context) error
 }
 
+type s3ReaderAPI interface {
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
 //------------------------------------------------------------------------------
 
 func deleteS3ObjectAckFn(
-	s3Client *s3.Client,
+	s3Client s3ReaderAPI,
 	bucket, key string,
 	del bool,
 	prev service.AckFunc,
@@ -309,65 +412,157 @@ func deleteS3ObjectAckFn(
 
 type staticTargetReader struct {
 	pending    []*s3ObjectTarget
-	s3         *s3.Client
+	s3         s3ReaderAPI
 	conf       s3iConfig
+	mgr        *service.Resources
 	startAfter *string
+	listed     bool
+
+	checkpointer *checkpoint.Capped[string]
 }
 
 func newStaticTargetReader(
 	ctx context.Context,
 	conf s3iConfig,
-	log *service.Logger,
-	s3Client *s3.Client,
+	mgr *service.Resources,
+	s3Client s3ReaderAPI,
 ) (*staticTargetReader, error) {
-	maxKeys := int32(100)
-	listInput := &s3.ListObjectsV2Input{
-		Bucket:  &conf.Bucket,
-		MaxKeys: &maxKeys,
+	staticKeys := staticTargetReader{
+		s3:           s3Client,
+		conf:         conf,
+		mgr:          mgr,
+		checkpointer: checkpoint.NewCapped[string](1024),
 	}
-	if conf.Prefix != "" {
-		listInput.Prefix = &conf.Prefix
+	if conf.Checkpoint.Cache != "" && conf.Checkpoint.Mode == s3iCheckpointModeLastKey {
+		lastKey, exists, err := staticKeys.getCheckpoint(ctx, conf.Checkpoint.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain listing checkpoint: %w", err)
+		}
+		if exists {
+			mgr.Logger().Infof("Resuming listing of bucket %v after key %v", conf.Bucket, lastKey)
+			staticKeys.startAfter = &lastKey
+		}
 	}
-	output, err := s3Client.ListObjectsV2(ctx, listInput)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list objects: %v", err)
+	if err := staticKeys.listObjects(ctx); err != nil {
+		return nil, err
 	}
-	staticKeys := staticTargetReader{
-		s3:   s3Client,
-		conf: conf,
+	return &staticKeys, nil
+}
+
+func (s *staticTargetReader) getCheckpoint(ctx context.Context, key string) (value string, exists bool, err error) {
+	var cacheErr error
+	if err = s.mgr.AccessCache(ctx, s.conf.Checkpoint.Cache, func(c service.Cache) {
+		var b []byte
+		if b, cacheErr = c.Get(ctx, key); cacheErr == nil {
+			value, exists = string(b), true
+		} else if errors.Is(cacheErr, service.ErrKeyNotFound) {
+			cacheErr = nil
+		}
+	}); err != nil {
+		return
 	}
-	for _, obj := range output.Contents {
-		ackFn := deleteS3ObjectAckFn(s3Client, conf.Bucket, *obj.Key, conf.DeleteObjects, nil)
-		staticKeys.pending = append(staticKeys.pending, newS3ObjectTarget(*obj.Key, conf.Bucket, time.Time{}, ackFn))
+	err = cacheErr
+	return
+}
+
+func (s *staticTargetReader) setCheckpoint(ctx context.Context, key, value string) error {
+	var cacheErr error
+	if err := s.mgr.AccessCache(ctx, s.conf.Checkpoint.Cache, func(c service.Cache) {
+		cacheErr = c.Set(ctx, key, []byte(value), nil)
+	}); err != nil {
+		return err
 	}
-	if len(output.Contents) > 0 {
-		staticKeys.startAfter = output.Contents[len(output.Contents)-1].Key
+	return cacheErr
+}
+
+// checkpointAckFn returns a function to be called once an object has been
+// processed in order to store the progress of the listing.
+func (s *staticTargetReader) checkpointAckFn(ctx context.Context, obj s3types.Object) (service.AckFunc, error) {
+	if s.conf.Checkpoint.Cache == "" {
+		return nil, nil
 	}
-	return &staticKeys, nil
+
+	if s.conf.Checkpoint.Mode == s3iCheckpointModeProcessedKeys {
+		cacheKey, etag := s.conf.Checkpoint.Key+*obj.Key, aws.ToString(obj.ETag)
+		return func(ctx context.Context, err error) error {
+			if err != nil {
+				return nil
+			}
+			return s.setCheckpoint(ctx, cacheKey, etag)
+		}, nil
+	}
+
+	release, err := s.checkpointer.Track(ctx, *obj.Key, 1)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, err error) error {
+		// Objects that fail to download are abandoned by the reader, and
+		// therefore we release them regardless of the error in order to avoid
+		// stalling the checkpoint.
+		highest := release()
+		if highest == nil {
+			return nil
+		}
+		return s.setCheckpoint(ctx, s.conf.Checkpoint.Key, *highest)
+	}, nil
 }
 
-func (s *staticTargetReader) Pop(ctx context.Context) (*s3ObjectTarget, error) {
+// alreadyProcessed returns whether an object has already been processed
+// according to the checkpoint cache.
+func (s *staticTargetReader) alreadyProcessed(ctx context.Context, obj s3types.Object) (bool, error) {
+	if s.conf.Checkpoint.Cache == "" || s.conf.Checkpoint.Mode != s3iCheckpointModeProcessedKeys {
+		return false, nil
+	}
+	etag, exists, err := s.getCheckpoint(ctx, s.conf.Checkpoint.Key+*obj.Key)
+	if err != nil {
+		return false, fmt.Errorf("failed to obtain checkpoint of key %v: %w", *obj.Key, err)
+	}
+	return exists && etag == aws.ToString(obj.ETag), nil
+}
+
+func (s *staticTargetReader) listObjects(ctx context.Context) error {
 	maxKeys := int32(100)
-	if len(s.pending) == 0 && s.startAfter != nil {
-		s.pending = nil
-		listInput := &s3.ListObjectsV2Input{
-			Bucket:     &s.conf.Bucket,
-			MaxKeys:    &maxKeys,
-			StartAfter: s.startAfter,
+	listInput := &s3.ListObjectsV2Input{
+		Bucket:     &s.conf.Bucket,
+		MaxKeys:    &maxKeys,
+		StartAfter: s.startAfter,
+	}
+	if s.conf.Prefix != "" {
+		listInput.Prefix = &s.conf.Prefix
+	}
+	output, err := s.s3.ListObjectsV2(ctx, listInput)
+	if err != nil {
+		return fmt.Errorf("failed to list objects: %v", err)
+	}
+	for _, obj := range output.Contents {
+		processed, err := s.alreadyProcessed(ctx, obj)
+		if err != nil {
+			return err
 		}
-		if s.conf.Prefix != "" {
-			listInput.Prefix = &s.conf.Prefix
+		if processed {
+			continue
 		}
-		output, err := s.s3.ListObjectsV2(ctx, listInput)
+		checkpointFn, err := s.checkpointAckFn(ctx, obj)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list objects: %v", err)
+			return err
 		}
-		for _, obj := range output.Contents {
-			ackFn := deleteS3ObjectAckFn(s.s3, s.conf.Bucket, *obj.Key, s.conf.DeleteObjects, nil)
-			s.pending = append(s.pending, newS3ObjectTarget(*obj.Key, s.conf.Bucket, time.Time{}, ackFn))
-		}
-		if len(output.Contents) > 0 {
-			s.startAfter = output.Contents[len(output.Contents)-1].Key
+		ackFn := deleteS3ObjectAckFn(s.s3, s.conf.Bucket, *obj.Key, s.conf.DeleteObjects, checkpointFn)
+		target := newS3ObjectTarget(*obj.Key, s.conf.Bucket, time.Time{}, ackFn)
+		target.size = aws.ToInt64(obj.Size)
+		s.pending = append(s.pending, target)
+	}
+	if len(output.Contents) > 0 {
+		s.startAfter = output.Contents[len(output.Contents)-1].Key
+	}
+	s.listed = len(output.Contents) == 0 || !aws.ToBool(output.IsTruncated)
+	return nil
+}
+
+func (s *staticTargetReader) Pop(ctx context.Context) (*s3ObjectTarget, error) {
+	for len(s.pending) == 0 && !s.listed {
+		if err := s.listObjects(ctx); err != nil {
+			return nil, err
 		}
 	}
 	if len(s.pending) == 0 {
@@ -378,7 +573,7 @@ func (s *staticTargetReader) Pop(ctx context.Context) (*s3ObjectTarget, error) {
 	return obj, nil
 }
 
-func (s staticTargetReader) Close(context.Context) error {
+func (s *staticTargetReader) Close(context.Context) error {
 	return nil
 }
 
@@ -388,7 +583,7 @@ type sqsTargetReader struct {
 	conf s3iConfig
 	log  *service.Logger
 	sqs  *sqs.Client
-	s3   *s3.Client
+	s3   s3ReaderAPI
 
 	nextRequest time.Time
 
@@ -398,7 +593,7 @@ type sqsTargetReader struct {
 func newSQSTargetReader(
 	conf s3iConfig,
 	log *service.Logger,
-	s3 *s3.Client,
+	s3 s3ReaderAPI,
 	sqs *sqs.Client,
 ) *sqsTargetReader {
 	return &sqsTargetReader{conf: conf, log: log, sqs: sqs, s3: s3, nextRequest: time.Time{}, pending: nil}
@@ -653,7 +848,7 @@ type awsS3Reader struct {
 	keyReader         s3ObjectTargetReader
 
 	awsConf aws.Config
-	s3      *s3.Client
+	s3      s3ReaderAPI
 	sqs     *sqs.Client
 
 	gracePeriod time.Duration
@@ -661,6 +856,7 @@ type awsS3Reader struct {
 	objectMut sync.Mutex
 	object    *s3PendingObject
 
+	mgr *service.Resources
 	log *service.Logger
 }
 
@@ -679,9 +875,24 @@ func newAmazonS3Reader(conf s3iConfig, awsConf aws.Config, nm *service.Resources
 	if conf.Prefix != "" && conf.SQS.URL != "" {
 		return nil, errors.New("cannot specify both a prefix and sqs.url")
 	}
+	if conf.Checkpoint.Cache != "" {
+		if conf.SQS.URL != "" {
+			return nil, errors.New("cannot specify both a checkpoint.cache and sqs.url")
+		}
+		if !nm.HasCache(conf.Checkpoint.Cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", conf.Checkpoint.Cache)
+		}
+	}
+	if conf.Download.Concurrency < 1 {
+		return nil, errors.New("download.concurrency must be at least one")
+	}
+	if conf.Download.PartSize < 1 {
+		return nil, errors.New("download.part_size must be greater than zero")
+	}
 	s := &awsS3Reader{
 		conf:              conf,
 		awsConf:           awsConf,
+		mgr:               nm,
 		log:               nm.Logger(),
 		objectScannerCtor: conf.CodecCtor,
 	}
@@ -698,20 +909,22 @@ func (a *awsS3Reader) getTargetReader(ctx context.Context) (s3ObjectTargetReader
 	if a.sqs != nil {
 		return newSQSTargetReader(a.conf, a.log, a.s3, a.sqs), nil
 	}
-	return newStaticTargetReader(ctx, a.conf, a.log, a.s3)
+	return newStaticTargetReader(ctx, a.conf, a.mgr, a.s3)
 }
 
 // Connect attempts to establish a connection to the target S3 bucket
 // and any relevant queues used to traverse the objects (SQS, etc).
 func (a *awsS3Reader) Connect(ctx context.Context) error {
-	if a.s3 != nil {
+	if a.keyReader != nil {
 		return nil
 	}
 
-	a.s3 = s3.NewFromConfig(a.awsConf, func(o *s3.Options) {
-		o.UsePathStyle = a.conf.ForcePathStyleURLs
-	})
-	if a.conf.SQS.URL != "" {
+	if a.s3 == nil {
+		a.s3 = s3.NewFromConfig(a.awsConf, func(o *s3.Options) {
+			o.UsePathStyle = a.conf.ForcePathStyleURLs
+		})
+	}
+	if a.conf.SQS.URL != "" && a.sqs == nil {
 		sqsConf := a.awsConf.Copy()
 		if a.conf.SQS.Endpoint != "" {
 			sqsConf.BaseEndpoint = &a.conf.SQS.Endpoint
@@ -719,12 +932,11 @@ func (a *awsS3Reader) Connect(ctx context.Context) error {
 		a.sqs = sqs.NewFromConfig(sqsConf)
 	}
 
-	var err error
-	if a.keyReader, err = a.getTargetReader(ctx); err != nil {
-		a.s3 = nil
-		a.sqs = nil
+	keyReader, err := a.getTargetReader(ctx)
+	if err != nil {
 		return err
 	}
+	a.keyReader = keyReader
 	return nil
 }
 
@@ -772,10 +984,7 @@ func (a *awsS3Reader) getObjectTarget(ctx context.Context) (*s3PendingObject, er
 		}
 	}
 
-	obj, err := a.s3.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(target.bucket),
-		Key:    aws.String(target.key),
-	})
+	obj, err := a.getObject(ctx, target)
 	if err != nil {
 		_ = target.ackFn(ctx, err)
 		return nil, err
@@ -802,11 +1011,41 @@ func (a *awsS3Reader) getObjectTarget(ctx context.Context) (*s3PendingObject, er
 	return object, nil
 }
 
+// getObject downloads a target object, where objects larger than a single part
+// are downloaded in parallel byte ranges when a download concurrency is
+// configured.
+func (a *awsS3Reader) getObject(ctx context.Context, target *s3ObjectTarget) (*s3.GetObjectOutput, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(target.bucket),
+		Key:    aws.String(target.key),
+	}
+
+	partSize := a.conf.Download.PartSize
+	if a.conf.Download.Concurrency <= 1 || target.size <= partSize {
+		return a.s3.GetObject(ctx, input)
+	}
+
+	input.Range = aws.String(s3ByteRange(0, partSize))
+	obj, err := a.s3.GetObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// The object might have changed since it was listed, and so we take the
+	// size from the response and pin all remaining parts to the same version.
+	size := target.size
+	if total, ok := s3ContentRangeSize(aws.ToString(obj.ContentRange)); ok {
+		size = total
+	}
+	obj.Body = newS3RangeReader(a.s3, target.bucket, target.key, obj.ETag, obj.Body, size, a.conf.Download)
+	return obj, nil
+}
+
 // ReadBatch attempts to read a new message from the target S3 bucket.
 func (a *awsS3Reader) ReadBatch(ctx context.Context) (msg service.MessageBatch, ackFn service.AckFunc, err error) {
 	a.objectMut.Lock()
 	defer a.objectMut.Unlock()
-	if a.s3 == nil {
+	if a.keyReader == nil {
 		return nil, nil, service.ErrNotConnected
 	}
 
@@ -862,3 +1101,131 @@ func (a *awsS3Reader) Close(ctx context.Context) (err error) {
 	}
 	return
 }
+
+//------------------------------------------------------------------------------
+
+func s3ByteRange(start, end int64) string {
+	return fmt.Sprintf("bytes=%v-%v", start, end-1)
+}
+
+// s3ContentRangeSize extracts the total size of an object from the
+// Content-Range header of a ranged request, e.g. "bytes 0-1023/146515".
+func s3ContentRangeSize(contentRange string) (int64, bool) {
+	i := strings.LastIndexByte(contentRange, '/')
+	if i == -1 {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+type s3RangePart struct {
+	data []byte
+	err  error
+}
+
+// s3RangeReader reads an object in order from parts that are downloaded in
+// parallel, with the first part being streamed from the initial request.
+type s3RangeReader struct {
+	current io.ReadCloser
+	pending chan chan s3RangePart
+	cancel  context.CancelFunc
+	err     error
+}
+
+func newS3RangeReader(
+	client s3ReaderAPI,
+	bucket, key string,
+	etag *string,
+	first io.ReadCloser,
+	size int64,
+	conf s3iDownloadConfig,
+) *s3RangeReader {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The buffer of pending parts along with the part being read limits the
+	// number of parts downloaded or held in memory to the concurrency.
+	r := &s3RangeReader{
+		current: first,
+		pending: make(chan chan s3RangePart, conf.Concurrency-1),
+		cancel:  cancel,
+	}
+
+	go func() {
+		defer close(r.pending)
+		for start := conf.PartSize; start < size; start += conf.PartSize {
+			end := min(start+conf.PartSize, size)
+
+			resChan := make(chan s3RangePart, 1)
+			select {
+			case r.pending <- resChan:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				data, err := s3GetRange(ctx, client, bucket, key, etag, start, end)
+				resChan <- s3RangePart{data: data, err: err}
+			}()
+		}
+	}()
+	return r
+}
+
+func s3GetRange(ctx context.Context, client s3ReaderAPI, bucket, key string, etag *string, start, end int64) ([]byte, error) {
+	obj, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Range:   aws.String(s3ByteRange(start, end)),
+		IfMatch: etag,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download range %v-%v of key %v: %w", start, end-1, key, err)
+	}
+	defer obj.Body.Close()
+
+	data := make([]byte, end-start)
+	if _, err := io.ReadFull(obj.Body, data); err != nil {
+		return nil, fmt.Errorf("failed to read range %v-%v of key %v: %w", start, end-1, key, err)
+	}
+	return data, nil
+}
+
+func (r *s3RangeReader) Read(p []byte) (int, error) {
+	for r.err == nil {
+		if r.current != nil {
+			n, err := r.current.Read(p)
+			if errors.Is(err, io.EOF) {
+				_ = r.current.Close()
+				r.current, err = nil, nil
+				if n == 0 {
+					continue
+				}
+			}
+			return n, err
+		}
+
+		resChan, open := <-r.pending
+		if !open {
+			r.err = io.EOF
+			break
+		}
+		part := <-resChan
+		if part.err != nil {
+			r.err = part.err
+			break
+		}
+		r.current = io.NopCloser(bytes.NewReader(part.data))
+	}
+	return 0, r.err
+}
+
+func (r *s3RangeReader) Close() error {
+	r.cancel()
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
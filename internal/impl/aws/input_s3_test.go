@@ -0,0 +1,350 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockS3Reader struct {
+	mut     sync.Mutex
+	objects map[string][]byte
+	ranges  []string
+}
+
+func (m *mockS3Reader) etag(key string) string {
+	return fmt.Sprintf(`"%v-%v"`, key, len(m.objects[key]))
+}
+
+func (m *mockS3Reader) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	var keys []string
+	for k := range m.objects {
+		if strings.HasPrefix(k, aws.ToString(params.Prefix)) && k > aws.ToString(params.StartAfter) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	out := &s3.ListObjectsV2Output{}
+	if len(keys) > int(*params.MaxKeys) {
+		keys = keys[:*params.MaxKeys]
+		out.IsTruncated = aws.Bool(true)
+	}
+	for _, k := range keys {
+		out.Contents = append(out.Contents, types.Object{
+			Key:  aws.String(k),
+			ETag: aws.String(m.etag(k)),
+			Size: aws.Int64(int64(len(m.objects[k]))),
+		})
+	}
+	return out, nil
+}
+
+func (m *mockS3Reader) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	data, exists := m.objects[*params.Key]
+	if !exists {
+		return nil, &types.NoSuchKey{}
+	}
+	if params.IfMatch != nil && *params.IfMatch != m.etag(*params.Key) {
+		return nil, errors.New("precondition failed")
+	}
+
+	out := &s3.GetObjectOutput{ETag: aws.String(m.etag(*params.Key))}
+	if params.Range != nil {
+		m.ranges = append(m.ranges, *params.Range)
+
+		var start, end int
+		if _, err := fmt.Sscanf(*params.Range, "bytes=%d-%d", &start, &end); err != nil {
+			return nil, err
+		}
+		end = min(end, len(data)-1)
+		out.ContentRange = aws.String(fmt.Sprintf("bytes %v-%v/%v", start, end, len(data)))
+		data = data[start : end+1]
+	}
+	out.Body = io.NopCloser(bytes.NewReader(data))
+	return out, nil
+}
+
+func (m *mockS3Reader) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	delete(m.objects, *params.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func testS3Reader(t testing.TB, client s3ReaderAPI, res *service.Resources, yamlStr string) *awsS3Reader {
+	t.Helper()
+
+	pConf, err := s3InputSpec().ParseYAML(yamlStr, nil)
+	require.NoError(t, err)
+
+	conf, err := s3iConfigFromParsed(pConf)
+	require.NoError(t, err)
+
+	r, err := newAmazonS3Reader(conf, aws.Config{}, res)
+	require.NoError(t, err)
+
+	r.s3 = client
+	require.NoError(t, r.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = r.Close(context.Background())
+	})
+	return r
+}
+
+type s3TestRead struct {
+	key   string
+	ackFn service.AckFunc
+}
+
+func readS3Objects(t testing.TB, r *awsS3Reader, n int) (reads []s3TestRead) {
+	t.Helper()
+
+	for len(reads) < n || n == -1 {
+		batch, ackFn, err := r.ReadBatch(context.Background())
+		if n == -1 && errors.Is(err, service.ErrEndOfInput) {
+			return
+		}
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+
+		key, _ := batch[0].MetaGet("s3_key")
+		b, err := batch[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, "content of "+key, string(b))
+
+		reads = append(reads, s3TestRead{key: key, ackFn: ackFn})
+	}
+	return
+}
+
+func testS3Objects(n int) map[string][]byte {
+	objects := map[string][]byte{}
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("foo/%03d", i)
+		objects[key] = []byte("content of " + key)
+	}
+	return objects
+}
+
+func TestS3InputCheckpointLastKey(t *testing.T) {
+	client := &mockS3Reader{objects: testS3Objects(250)}
+	res := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+
+	conf := `
+bucket: foo
+prefix: foo/
+checkpoint:
+  cache: foocache
+`
+
+	r := testS3Reader(t, client, res, conf)
+	reads := readS3Objects(t, r, 150)
+
+	// Acknowledge out of order, leaving a gap at the 121st object
+	for i := len(reads) - 1; i >= 0; i-- {
+		if i != 120 {
+			require.NoError(t, reads[i].ackFn(context.Background(), nil))
+		}
+	}
+	require.NoError(t, r.Close(context.Background()))
+
+	r = testS3Reader(t, client, res, conf)
+	reads = readS3Objects(t, r, -1)
+	require.Len(t, reads, 130)
+	assert.Equal(t, "foo/120", reads[0].key)
+	assert.Equal(t, "foo/249", reads[len(reads)-1].key)
+
+	for _, read := range reads {
+		require.NoError(t, read.ackFn(context.Background(), nil))
+	}
+	require.NoError(t, r.Close(context.Background()))
+
+	// Only new keys are consumed after a full walk
+	client.objects["foo/250"] = []byte("content of foo/250")
+	client.objects["bar/000"] = []byte("content of bar/000")
+
+	r = testS3Reader(t, client, res, conf)
+	reads = readS3Objects(t, r, -1)
+	require.Len(t, reads, 1)
+	assert.Equal(t, "foo/250", reads[0].key)
+}
+
+func TestS3InputCheckpointProcessedKeys(t *testing.T) {
+	client := &mockS3Reader{objects: testS3Objects(150)}
+	res := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+
+	conf := `
+bucket: foo
+checkpoint:
+  cache: foocache
+  key: foo_
+  mode: processed_keys
+`
+
+	r := testS3Reader(t, client, res, conf)
+	reads := readS3Objects(t, r, -1)
+	require.Len(t, reads, 150)
+
+	for i, read := range reads {
+		if i%2 == 0 {
+			require.NoError(t, read.ackFn(context.Background(), nil))
+		}
+	}
+	require.NoError(t, r.Close(context.Background()))
+
+	// Modified objects are consumed again
+	client.objects["foo/000"] = []byte("content of foo/000 again")
+
+	r = testS3Reader(t, client, res, conf)
+	_, _, err := r.ReadBatch(context.Background())
+	require.NoError(t, err)
+
+	reads = readS3Objects(t, r, -1)
+	require.Len(t, reads, 75)
+	for _, read := range reads {
+		assert.NotEqual(t, "0", read.key[len(read.key)-1:], read.key)
+	}
+}
+
+func TestS3InputCheckpointConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`
+bucket: foo
+checkpoint:
+  cache: nope
+`,
+		`
+sqs:
+  url: http://example.com
+checkpoint:
+  cache: foocache
+`,
+		`
+bucket: foo
+download:
+  concurrency: 0
+`,
+	} {
+		pConf, err := s3InputSpec().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		sConf, err := s3iConfigFromParsed(pConf)
+		require.NoError(t, err)
+
+		_, err = newAmazonS3Reader(sConf, aws.Config{}, service.MockResources(service.MockResourcesOptAddCache("foocache")))
+		require.Error(t, err, conf)
+	}
+}
+
+func TestS3InputRangedDownload(t *testing.T) {
+	var content bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&content, "line %v\n", i)
+	}
+
+	client := &mockS3Reader{objects: map[string][]byte{
+		"large": content.Bytes(),
+		"small": []byte("small"),
+	}}
+
+	r := testS3Reader(t, client, service.MockResources(), `
+bucket: foo
+scanner:
+  lines: {}
+download:
+  concurrency: 4
+  part_size: 100
+`)
+
+	var lines []string
+	for {
+		batch, _, err := r.ReadBatch(context.Background())
+		if errors.Is(err, service.ErrEndOfInput) {
+			break
+		}
+		require.NoError(t, err)
+		for _, msg := range batch {
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+			lines = append(lines, string(b))
+		}
+	}
+
+	require.Len(t, lines, 1001)
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, fmt.Sprintf("line %v", i), lines[i])
+	}
+	assert.Equal(t, "small", lines[1000])
+
+	// Only the large object is downloaded in ranges
+	size := content.Len()
+	require.Len(t, client.ranges, (size+99)/100)
+	assert.Contains(t, client.ranges, "bytes=0-99")
+	assert.Contains(t, client.ranges, fmt.Sprintf("bytes=%v-%v", (size-1)/100*100, size-1))
+}
+
+func TestS3RangeReaderError(t *testing.T) {
+	client := &mockS3Reader{objects: map[string][]byte{
+		"foo": []byte(strings.Repeat("x", 1000)),
+	}}
+
+	first := io.NopCloser(bytes.NewReader(client.objects["foo"][:100]))
+	r := newS3RangeReader(client, "bucket", "foo", aws.String("nope"), first, 1000, s3iDownloadConfig{
+		Concurrency: 3,
+		PartSize:    100,
+	})
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "precondition failed")
+	assert.Len(t, b, 100)
+}
+
+func TestS3ContentRangeSize(t *testing.T) {
+	size, ok := s3ContentRangeSize("bytes 0-1023/146515")
+	assert.True(t, ok)
+	assert.Equal(t, int64(146515), size)
+
+	_, ok = s3ContentRangeSize("bytes 0-1023/*")
+	assert.False(t, ok)
+
+	_, ok = s3ContentRangeSize("")
+	assert.False(t, ok)
+}
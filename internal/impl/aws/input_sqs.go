@@ -49,6 +49,7 @@ type sqsiConfig struct {
 	DeleteMessage       bool
 	ResetVisibility     bool
 	MaxNumberOfMessages int
+	ExtendedPayload     extendedPayloadConfig
 }
 
 func sqsiConfigFromParsed(pConf *service.ParsedConfig) (conf sqsiConfig, err error) {
@@ -67,6 +68,9 @@ func sqsiConfigFromParsed(pConf *service.ParsedConfig) (conf sqsiConfig, err err
 	if conf.MaxNumberOfMessages, err = pConf.FieldInt(sqsiFieldMaxNumberOfMessages); err != nil {
 		return
 	}
+	if conf.ExtendedPayload, err = extendedPayloadInputConfigFromParsed(pConf); err != nil {
+		return
+	}
 	return
 }
 
@@ -93,7 +97,16 @@ This input adds the following metadata fields to each message:
 - All message attributes
 
 You can access these metadata fields using
-xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].`).
+xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+
+== Large payloads
+
+Messages that point to a payload offloaded to S3, either by an `+"`aws_sqs`"+` or
+`+"`aws_sns`"+` output with `+"`extended_payload`"+` enabled or by a producer using an AWS
+extended client library, are resolved transparently when `+"`extended_payload`"+` is
+enabled. The payload is fetched from S3 and replaces the body of the message,
+and when `+"`extended_payload.delete_object`"+` is enabled the object is deleted once
+the message has been deleted from the queue.`).
 		Fields(
 			service.NewURLField(sqsiFieldURL).
 				Description("The SQS URL to consume from."),
@@ -114,6 +127,7 @@ xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].`
 				Description("Whether to set the wait time. Enabling this activates long-polling. Valid values: 0 to 20.").
 				Default(0).
 				Advanced(),
+			extendedPayloadInputField(),
 		).
 		Fields(config.SessionFields()...)
 }
@@ -150,8 +164,9 @@ type sqsAPI interface {
 type awsSQSReader struct {
 	conf sqsiConfig
 
-	aconf aws.Config
-	sqs   sqsAPI
+	aconf    aws.Config
+	sqs      sqsAPI
+	payloads *s3PayloadStore
 
 	messagesChan     chan types.Message
 	ackMessagesChan  chan sqsMessageHandle
@@ -179,6 +194,9 @@ func (a *awsSQSReader) Connect(ctx context.Context) error {
 	if a.sqs == nil {
 		a.sqs = sqs.NewFromConfig(a.aconf)
 	}
+	if a.payloads == nil {
+		a.payloads = newS3PayloadStore(a.conf.ExtendedPayload, a.aconf)
+	}
 
 	ift := &sqsInFlightTracker{
 		handles: map[string]sqsInFlightHandle{},
@@ -257,10 +275,10 @@ func (t *sqsInFlightTracker) AddNew(messages ...types.Message) {
 	}
 }
 
-func flushMapToHandles(m map[string]string) (s []sqsMessageHandle) {
+func flushMapToHandles(m map[string]sqsMessageHandle) (s []sqsMessageHandle) {
 	s = make([]sqsMessageHandle, 0, len(m))
 	for k, v := range m {
-		s = append(s, sqsMessageHandle{id: k, receiptHandle: v})
+		s = append(s, v)
 		delete(m, k)
 	}
 	return
@@ -272,7 +290,7 @@ func (a *awsSQSReader) ackLoop(wg *sync.WaitGroup, inFlightTracker *sqsInFlightT
 	closeNowCtx, done := a.closeSignal.HardStopCtx(context.Background())
 	defer done()
 
-	flushFinishedHandles := func(m map[string]string, erase bool) {
+	flushFinishedHandles := func(m map[string]sqsMessageHandle, erase bool) {
 		handles := flushMapToHandles(m)
 		if len(handles) == 0 {
 			return
@@ -301,21 +319,21 @@ func (a *awsSQSReader) ackLoop(wg *sync.WaitGroup, inFlightTracker *sqsInFlightT
 	flushTimer := time.NewTicker(time.Second)
 	defer flushTimer.Stop()
 
-	// Both maps are of the message ID to the receipt handle
-	pendingAcks := map[string]string{}
-	pendingNacks := map[string]string{}
+	// Both maps are of the message ID to the message handle
+	pendingAcks := map[string]sqsMessageHandle{}
+	pendingNacks := map[string]sqsMessageHandle{}
 
 ackLoop:
 	for {
 		select {
 		case h := <-a.ackMessagesChan:
-			pendingAcks[h.id] = h.receiptHandle
+			pendingAcks[h.id] = h
 			inFlightTracker.Remove(h.id)
 			if len(pendingAcks) >= a.conf.MaxNumberOfMessages {
 				flushFinishedHandles(pendingAcks, true)
 			}
 		case h := <-a.nackMessagesChan:
-			pendingNacks[h.id] = h.receiptHandle
+			pendingNacks[h.id] = h
 			inFlightTracker.Remove(h.id)
 			if len(pendingNacks) >= a.conf.MaxNumberOfMessages {
 				flushFinishedHandles(pendingNacks, false)
@@ -414,6 +432,9 @@ func (a *awsSQSReader) readLoop(wg *sync.WaitGroup, inFlightTracker *sqsInFlight
 
 type sqsMessageHandle struct {
 	id, receiptHandle string
+
+	// The offloaded payload of the message to delete along with it, if any.
+	payload *s3PayloadPointer
 }
 
 func (a *awsSQSReader) deleteMessages(ctx context.Context, msgs ...sqsMessageHandle) error {
@@ -434,14 +455,25 @@ func (a *awsSQSReader) deleteMessages(ctx context.Context, msgs ...sqsMessageHan
 			}
 		}
 
+		deleted := msgs[:len(input.Entries)]
 		msgs = msgs[len(input.Entries):]
 		response, err := a.sqs.DeleteMessageBatch(ctx, &input)
 		if err != nil {
 			return err
 		}
+		failed := make(map[string]struct{}, len(response.Failed))
 		for _, fail := range response.Failed {
+			failed[*fail.Id] = struct{}{}
 			a.log.Errorf("Failed to delete consumed SQS message '%v', response code: %v\n", *fail.Id, *fail.Code)
 		}
+		for _, msg := range deleted {
+			if _, isFailed := failed[msg.id]; isFailed || msg.payload == nil {
+				continue
+			}
+			if err := a.payloads.remove(ctx, *msg.payload); err != nil {
+				a.log.Errorf("Failed to delete offloaded payload of SQS message '%v': %v\n", msg.id, err)
+			}
+		}
 	}
 	return nil
 }
@@ -498,6 +530,45 @@ func addSQSMetadata(p *service.Message, sqsMsg types.Message) {
 	}
 }
 
+// sqsHasExtendedPayload returns true if a message carries the attribute that
+// extended clients add to messages with an offloaded payload.
+func sqsHasExtendedPayload(m types.Message) bool {
+	if _, exists := m.MessageAttributes[epSizeAttribute]; exists {
+		return true
+	}
+	_, exists := m.MessageAttributes[epLegacySizeAttribute]
+	return exists
+}
+
+// resolvePayload fetches the offloaded payload of a message, returning the
+// pointer to the payload if it should be deleted along with the message.
+func (a *awsSQSReader) resolvePayload(ctx context.Context, m types.Message) ([]byte, *s3PayloadPointer, error) {
+	pointer, err := parseS3PayloadPointer(*m.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := a.payloads.resolve(ctx, pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !a.conf.ExtendedPayload.DeleteObject {
+		return body, nil, nil
+	}
+	return body, &pointer, nil
+}
+
+// nackUnreadMessage returns a message that could not be read to the queue.
+func (a *awsSQSReader) nackUnreadMessage(ctx context.Context, h sqsMessageHandle) {
+	if h.receiptHandle == "" {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-a.closeSignal.SoftStopChan():
+	case a.nackMessagesChan <- h:
+	}
+}
+
 // ReadBatch attempts to read a new message from the target SQS.
 func (a *awsSQSReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
 	if a.sqs == nil {
@@ -521,15 +592,27 @@ func (a *awsSQSReader) Read(ctx context.Context) (*service.Message, service.AckF
 		return nil, nil, context.Canceled
 	}
 
-	msg := service.NewMessage([]byte(*next.Body))
-	addSQSMetadata(msg, next)
-
 	mHandle := sqsMessageHandle{
 		id: *next.MessageId,
 	}
 	if next.ReceiptHandle != nil {
 		mHandle.receiptHandle = *next.ReceiptHandle
 	}
+
+	body := []byte(*next.Body)
+	if a.payloads != nil && sqsHasExtendedPayload(next) {
+		var err error
+		if body, mHandle.payload, err = a.resolvePayload(ctx, next); err != nil {
+			a.nackUnreadMessage(ctx, mHandle)
+			return nil, nil, err
+		}
+		delete(next.MessageAttributes, epSizeAttribute)
+		delete(next.MessageAttributes, epLegacySizeAttribute)
+	}
+
+	msg := service.NewMessage(body)
+	addSQSMetadata(msg, next)
+
 	return msg, func(rctx context.Context, res error) error {
 		if mHandle.receiptHandle == "" {
 			return nil
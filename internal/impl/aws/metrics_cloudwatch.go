@@ -16,8 +16,10 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"sync"
 	"time"
 
@@ -32,13 +34,29 @@ import (
 
 const (
 	// CW Metrics Fields
-	cwmFieldNamespace   = "namespace"
-	cwmFieldFlushPeriod = "flush_period"
+	cwmFieldNamespace      = "namespace"
+	cwmFieldFlushPeriod    = "flush_period"
+	cwmFieldMode           = "mode"
+	cwmFieldEMF            = "emf"
+	cwmFieldEMFDestination = "destination"
+	cwmFieldEMFLogGroup    = "log_group"
+	cwmFieldEMFLogStream   = "log_stream"
+
+	cwmModePutMetricData = "put_metric_data"
+	cwmModeEMF           = "emf"
 )
 
+type cwmEMFConfig struct {
+	Destination string
+	LogGroup    string
+	LogStream   string
+}
+
 type cwmConfig struct {
 	Namespace   string
 	FlushPeriod time.Duration
+	Mode        string
+	EMF         cwmEMFConfig
 }
 
 func cwmConfigFromParsed(pConf *service.ParsedConfig) (conf cwmConfig, err error) {
@@ -48,6 +66,25 @@ func cwmConfigFromParsed(pConf *service.ParsedConfig) (conf cwmConfig, err error
 	if conf.FlushPeriod, err = pConf.FieldDuration(cwmFieldFlushPeriod); err != nil {
 		return
 	}
+	if conf.Mode, err = pConf.FieldString(cwmFieldMode); err != nil {
+		return
+	}
+	if conf.Mode != cwmModeEMF {
+		return
+	}
+	emfConf := pConf.Namespace(cwmFieldEMF)
+	if conf.EMF.Destination, err = emfConf.FieldString(cwmFieldEMFDestination); err != nil {
+		return
+	}
+	if conf.EMF.LogGroup, err = emfConf.FieldString(cwmFieldEMFLogGroup); err != nil {
+		return
+	}
+	if conf.EMF.LogStream, err = emfConf.FieldString(cwmFieldEMFLogStream); err != nil {
+		return
+	}
+	if conf.EMF.Destination == cwmEMFDestinationLogGroup && conf.EMF.LogGroup == "" {
+		err = errors.New("an emf.log_group must be specified when the emf destination is log_group")
+	}
 	return
 }
 
@@ -55,8 +92,12 @@ func cwMetricsSpec() *service.ConfigSpec {
 	return service.NewConfigSpec().
 		Stable().
 		Version("3.36.0").
-		Summary(`Send metrics to AWS CloudWatch using the PutMetricData endpoint.`).
+		Summary(`Send metrics to AWS CloudWatch using the PutMetricData endpoint, or as documents in the CloudWatch Embedded Metric Format.`).
 		Description(`
+== Embedded Metric Format
+
+When `+"`mode`"+` is set to `+"`emf`"+` metrics are written each flush period as https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html[CloudWatch Embedded Metric Format^] (EMF) JSON documents, either to stdout or to a CloudWatch Logs log group, from which CloudWatch extracts the metrics asynchronously. This avoids the cost and dimensionality limits of PutMetricData, and when running within AWS Lambda the documents written to stdout are ingested without any extra API calls. It is recommended to increase the `+"`flush_period`"+` when using this mode.
+
 == Timing metrics
 
 The smallest timing unit that CloudWatch supports is microseconds, therefore timing metrics are automatically downgraded to microseconds (by dividing delta values by 1000). This conversion will also apply to custom timing metrics produced with a `+"`metric`"+` processor.
@@ -81,9 +122,33 @@ metrics:
 				Description("The namespace used to distinguish metrics from other services.").
 				Default("Benthos"),
 			service.NewDurationField(cwmFieldFlushPeriod).
-				Description("The period of time between PutMetricData requests.").
+				Description("The period of time between PutMetricData requests, or between writes of EMF documents.").
 				Advanced().
 				Default("100ms"),
+			service.NewStringAnnotatedEnumField(cwmFieldMode, map[string]string{
+				cwmModePutMetricData: "Send metrics with PutMetricData requests.",
+				cwmModeEMF:           "Write metrics as Embedded Metric Format documents.",
+			}).
+				Description("The mechanism used to send metrics to CloudWatch.").
+				Version("4.33.0").
+				Default(cwmModePutMetricData),
+			service.NewObjectField(cwmFieldEMF,
+				service.NewStringAnnotatedEnumField(cwmFieldEMFDestination, map[string]string{
+					cwmEMFDestinationStdout:   "Write documents to stdout, one per line.",
+					cwmEMFDestinationLogGroup: "Write documents as events to a CloudWatch Logs log group, which is created if it does not exist.",
+				}).
+					Description("Where EMF documents are written.").
+					Default(cwmEMFDestinationStdout),
+				service.NewStringField(cwmFieldEMFLogGroup).
+					Description("The log group to write documents to when the `destination` is `log_group`.").
+					Default(""),
+				service.NewStringField(cwmFieldEMFLogStream).
+					Description("The log stream to write documents to when the `destination` is `log_group`. When empty the hostname is used.").
+					Default(""),
+			).
+				Description("Configures the `emf` mode.").
+				Version("4.33.0").
+				Advanced(),
 		).
 		Fields(config.SessionFields()...)
 }
@@ -296,6 +361,7 @@ type cloudWatchAPI interface {
 
 type cwMetrics struct {
 	client cloudWatchAPI
+	emf    emfWriter
 
 	datumses  map[string]*cloudWatchDatum
 	datumLock *sync.Mutex
@@ -316,7 +382,19 @@ func newCloudWatch(config cwmConfig, sess aws.Config, log *service.Logger) (serv
 	}
 
 	c.ctx, c.cancel = context.WithCancel(context.Background())
-	c.client = cloudwatch.NewFromConfig(sess)
+	if config.Mode == cwmModeEMF {
+		if config.EMF.Destination == cwmEMFDestinationLogGroup {
+			emf, err := newEMFLogGroupWriter(config.EMF, sess, log)
+			if err != nil {
+				return nil, err
+			}
+			c.emf = emf
+		} else {
+			c.emf = &emfStdoutWriter{w: os.Stdout}
+		}
+	} else {
+		c.client = cloudwatch.NewFromConfig(sess)
+	}
 	go c.loop()
 	return c, nil
 }
@@ -467,6 +545,10 @@ func (c *cwMetrics) flush() error {
 	c.datumses = map[string]*cloudWatchDatum{}
 	c.datumLock.Unlock()
 
+	if c.emf != nil {
+		return c.flushEMF(datumMap)
+	}
+
 	datums := []types.MetricDatum{}
 	for _, v := range datumMap {
 		if v != nil {
@@ -0,0 +1,268 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	cwmEMFDestinationStdout   = "stdout"
+	cwmEMFDestinationLogGroup = "log_group"
+
+	// The maximum number of metrics within a single EMF document, and the
+	// maximum number of values of a single metric.
+	maxEMFMetrics = 100
+	maxEMFValues  = 100
+)
+
+// emfWriter writes EMF documents to their destination.
+type emfWriter interface {
+	writeDocuments(ctx context.Context, docs [][]byte) error
+}
+
+type emfStdoutWriter struct {
+	w io.Writer
+}
+
+func (e *emfStdoutWriter) writeDocuments(ctx context.Context, docs [][]byte) error {
+	for _, d := range docs {
+		if _, err := e.w.Write(append(d, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type emfLogGroupWriter struct {
+	logs *cloudWatchLogsWriter
+}
+
+func newEMFLogGroupWriter(conf cwmEMFConfig, sess aws.Config, log *service.Logger) (*emfLogGroupWriter, error) {
+	logGroup, err := service.NewInterpolatedString(conf.LogGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	logStreamName := conf.LogStream
+	if logStreamName == "" {
+		if logStreamName, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("failed to obtain hostname for the log stream name: %w", err)
+		}
+	}
+	logStream, err := service.NewInterpolatedString(logStreamName)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := newCloudWatchLogsWriter(cwloConfig{
+		LogGroup:   logGroup,
+		LogStream:  logStream,
+		AutoCreate: true,
+		aconf:      sess,
+		backoffCtor: func() backoff.BackOff {
+			boff := backoff.NewExponentialBackOff()
+			boff.MaxElapsedTime = 30 * time.Second
+			return boff
+		},
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+	if err := logs.Connect(context.Background()); err != nil {
+		return nil, err
+	}
+	return &emfLogGroupWriter{logs: logs}, nil
+}
+
+func (e *emfLogGroupWriter) writeDocuments(ctx context.Context, docs [][]byte) error {
+	batch := make(service.MessageBatch, len(docs))
+	for i, d := range docs {
+		batch[i] = service.NewMessage(d)
+	}
+	return e.logs.WriteBatch(ctx, batch)
+}
+
+//------------------------------------------------------------------------------
+
+type emfMetricDefinition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit,omitempty"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string                `json:"Namespace"`
+	Dimensions [][]string            `json:"Dimensions"`
+	Metrics    []emfMetricDefinition `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+// emfValues expands a map of values to their counts into a slice of values
+// where each value is repeated by its count, up to the maximum number of values
+// allowed for a metric.
+func emfValues(m map[int64]int64) []float64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	counts := make([]int64, len(keys))
+	for i, k := range keys {
+		counts[i] = m[k]
+	}
+
+	// Values are taken in rounds so that when the maximum is reached the
+	// distribution of values is preserved as much as possible.
+	var values []float64
+	for {
+		added := false
+		for i, k := range keys {
+			if counts[i] <= 0 {
+				continue
+			}
+			if len(values) == maxEMFValues {
+				return values
+			}
+			values = append(values, float64(k))
+			counts[i]--
+			added = true
+		}
+		if !added {
+			return values
+		}
+	}
+}
+
+// emfDocuments converts a snapshot of datums into EMF documents, where each
+// document contains the metrics that share a set of dimensions.
+func emfDocuments(namespace string, datumMap map[string]*cloudWatchDatum, now time.Time) ([][]byte, error) {
+	type dimensionSet struct {
+		names  []string
+		values map[string]string
+		datums []*cloudWatchDatum
+	}
+
+	sets := map[string]*dimensionSet{}
+	for _, d := range datumMap {
+		if d == nil {
+			continue
+		}
+
+		names := make([]string, 0, len(d.Dimensions))
+		values := make(map[string]string, len(d.Dimensions))
+		var keyBuilder strings.Builder
+		for _, dim := range d.Dimensions {
+			name, value := aws.ToString(dim.Name), aws.ToString(dim.Value)
+			names = append(names, name)
+			values[name] = value
+			fmt.Fprintf(&keyBuilder, "%q=%q,", name, value)
+		}
+
+		key := keyBuilder.String()
+		set, exists := sets[key]
+		if !exists {
+			set = &dimensionSet{names: names, values: values}
+			sets[key] = set
+		}
+		set.datums = append(set.datums, d)
+	}
+
+	keys := make([]string, 0, len(sets))
+	for k := range sets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var docs [][]byte
+	for _, k := range keys {
+		set := sets[k]
+		sort.Slice(set.datums, func(i, j int) bool {
+			return set.datums[i].MetricName < set.datums[j].MetricName
+		})
+
+		for len(set.datums) > 0 {
+			datums := set.datums
+			if len(datums) > maxEMFMetrics {
+				datums = datums[:maxEMFMetrics]
+			}
+			set.datums = set.datums[len(datums):]
+
+			doc := make(map[string]any, len(set.values)+len(datums)+1)
+			for name, value := range set.values {
+				doc[name] = value
+			}
+
+			directive := emfMetricDirective{
+				Namespace:  namespace,
+				Dimensions: [][]string{set.names},
+			}
+			for _, d := range datums {
+				directive.Metrics = append(directive.Metrics, emfMetricDefinition{
+					Name: d.MetricName,
+					Unit: string(d.Unit),
+				})
+				if len(d.Values) > 0 {
+					doc[d.MetricName] = emfValues(d.Values)
+				} else {
+					doc[d.MetricName] = d.Value
+				}
+			}
+
+			doc["_aws"] = emfMetadata{
+				Timestamp:         now.UnixMilli(),
+				CloudWatchMetrics: []emfMetricDirective{directive},
+			}
+
+			b, err := json.Marshal(doc)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, b)
+		}
+	}
+	return docs, nil
+}
+
+func (c *cwMetrics) flushEMF(datumMap map[string]*cloudWatchDatum) error {
+	docs, err := emfDocuments(c.config.Namespace, datumMap, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := c.emf.writeDocuments(c.ctx, docs); err != nil {
+		c.log.Errorf("Failed to write embedded metric format documents: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudWatchEMF(t *testing.T) {
+	var buf bytes.Buffer
+	cw := cwmMock(nil)
+	cw.emf = &emfStdoutWriter{w: &buf}
+	cw.ctx, cw.cancel = context.WithCancel(context.Background())
+
+	cw.NewCounterCtor("counter.foo")().Incr(7)
+	cw.NewGaugeCtor("gauge.foo")().Set(5)
+
+	ctrBar := cw.NewCounterCtor("counter.bar", "topic", "partition")
+	ctrBar("foo", "1").Incr(3)
+	ctrBar("foo", "2").Incr(4)
+
+	tmgBar := cw.NewTimerCtor("timer.bar", "topic", "partition")
+	tmgBar("foo", "1").Timing(23000)
+	tmgBar("foo", "1").Timing(23000)
+	tmgBar("foo", "1").Timing(87000)
+
+	require.NoError(t, cw.flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var docs []map[string]any
+	for _, l := range lines {
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal([]byte(l), &doc))
+
+		meta := doc["_aws"].(map[string]any)
+		assert.NotZero(t, meta["Timestamp"])
+		delete(doc, "_aws")
+
+		directives := meta["CloudWatchMetrics"].([]any)
+		require.Len(t, directives, 1)
+		doc["_directive"] = directives[0]
+		docs = append(docs, doc)
+	}
+
+	assert.Equal(t, []map[string]any{
+		{
+			"_directive": map[string]any{
+				"Namespace":  "Benthos",
+				"Dimensions": []any{[]any{}},
+				"Metrics": []any{
+					map[string]any{"Name": "counter.foo", "Unit": "Count"},
+					map[string]any{"Name": "gauge.foo", "Unit": "None"},
+				},
+			},
+			"counter.foo": float64(7),
+			"gauge.foo":   []any{float64(5)},
+		},
+		{
+			"_directive": map[string]any{
+				"Namespace":  "Benthos",
+				"Dimensions": []any{[]any{"topic", "partition"}},
+				"Metrics": []any{
+					map[string]any{"Name": "counter.bar", "Unit": "Count"},
+					map[string]any{"Name": "timer.bar", "Unit": "Microseconds"},
+				},
+			},
+			"topic":       "foo",
+			"partition":   "1",
+			"counter.bar": float64(3),
+			"timer.bar":   []any{float64(23), float64(87), float64(23)},
+		},
+		{
+			"_directive": map[string]any{
+				"Namespace":  "Benthos",
+				"Dimensions": []any{[]any{"topic", "partition"}},
+				"Metrics": []any{
+					map[string]any{"Name": "counter.bar", "Unit": "Count"},
+				},
+			},
+			"topic":       "foo",
+			"partition":   "2",
+			"counter.bar": float64(4),
+		},
+	}, docs)
+}
+
+func TestCloudWatchEMFLimits(t *testing.T) {
+	var buf bytes.Buffer
+	cw := cwmMock(nil)
+	cw.emf = &emfStdoutWriter{w: &buf}
+	cw.ctx, cw.cancel = context.WithCancel(context.Background())
+
+	for i := 0; i < 150; i++ {
+		cw.NewCounterCtor(fmt.Sprintf("counter.%03d", i))().Incr(1)
+	}
+	gge := cw.NewGaugeCtor("gauge.foo")()
+	for i := 0; i < 200; i++ {
+		gge.Set(int64(i % 2))
+	}
+
+	require.NoError(t, cw.flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var total int
+	for _, l := range lines {
+		var doc struct {
+			AWS emfMetadata `json:"_aws"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(l), &doc))
+		require.Len(t, doc.AWS.CloudWatchMetrics, 1)
+		assert.LessOrEqual(t, len(doc.AWS.CloudWatchMetrics[0].Metrics), maxEMFMetrics)
+		total += len(doc.AWS.CloudWatchMetrics[0].Metrics)
+	}
+	assert.Equal(t, 151, total)
+
+	values := emfValues(map[int64]int64{0: 100, 1: 100})
+	require.Len(t, values, maxEMFValues)
+
+	var zeros int
+	for _, v := range values {
+		if v == 0 {
+			zeros++
+		}
+	}
+	assert.Equal(t, maxEMFValues/2, zeros)
+}
+
+func TestCloudWatchEMFConfig(t *testing.T) {
+	pConf, err := cwMetricsSpec().ParseYAML(`
+mode: emf
+emf:
+  destination: log_group
+`, nil)
+	require.NoError(t, err)
+
+	_, err = cwmConfigFromParsed(pConf)
+	require.Error(t, err)
+
+	pConf, err = cwMetricsSpec().ParseYAML(`
+mode: emf
+emf:
+  destination: log_group
+  log_group: foo
+`, nil)
+	require.NoError(t, err)
+
+	conf, err := cwmConfigFromParsed(pConf)
+	require.NoError(t, err)
+	assert.Equal(t, cwmEMFConfig{Destination: "log_group", LogGroup: "foo"}, conf.EMF)
+}
@@ -0,0 +1,471 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+
+	"github.com/redpanda-data/connect/v4/internal/impl/aws/config"
+	"github.com/redpanda-data/connect/v4/internal/retries"
+)
+
+const (
+	// CloudWatch Logs Output Fields
+	cwloFieldLogGroup      = "log_group"
+	cwloFieldLogStream     = "log_stream"
+	cwloFieldTimestamp     = "timestamp"
+	cwloFieldAutoCreate    = "auto_create"
+	cwloFieldRetentionDays = "retention_days"
+	cwloFieldBatching      = "batching"
+
+	// Limits of a single PutLogEvents call.
+	cwlMaxEventsCount   = 10000
+	cwlMaxBatchBytes    = 1048576
+	cwlEventOverhead    = 26
+	cwlMaxEventBytes    = 262144 - cwlEventOverhead
+	cwlMaxBatchTimeSpan = 24 * time.Hour
+)
+
+// The retention periods accepted by PutRetentionPolicy.
+var cwlRetentionDays = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+type cwloConfig struct {
+	LogGroup      *service.InterpolatedString
+	LogStream     *service.InterpolatedString
+	Timestamp     *service.InterpolatedString
+	AutoCreate    bool
+	RetentionDays int
+
+	aconf       aws.Config
+	backoffCtor func() backoff.BackOff
+}
+
+func cwloConfigFromParsed(pConf *service.ParsedConfig) (conf cwloConfig, err error) {
+	if conf.LogGroup, err = pConf.FieldInterpolatedString(cwloFieldLogGroup); err != nil {
+		return
+	}
+	if conf.LogStream, err = pConf.FieldInterpolatedString(cwloFieldLogStream); err != nil {
+		return
+	}
+	if pConf.Contains(cwloFieldTimestamp) {
+		if conf.Timestamp, err = pConf.FieldInterpolatedString(cwloFieldTimestamp); err != nil {
+			return
+		}
+	}
+	if conf.AutoCreate, err = pConf.FieldBool(cwloFieldAutoCreate); err != nil {
+		return
+	}
+	if conf.RetentionDays, err = pConf.FieldInt(cwloFieldRetentionDays); err != nil {
+		return
+	}
+	if conf.RetentionDays != 0 {
+		valid := false
+		for _, d := range cwlRetentionDays {
+			if d == conf.RetentionDays {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			err = fmt.Errorf("retention_days must be one of %v", cwlRetentionDays)
+			return
+		}
+	}
+	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
+		return
+	}
+	if conf.backoffCtor, err = retries.CommonRetryBackOffCtorFromParsed(pConf); err != nil {
+		return
+	}
+	return
+}
+
+func cwloOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.33.0").
+		Categories("Services", "AWS").
+		Summary(`Sends messages as log events to CloudWatch Logs.`).
+		Description(`
+The fields `+"`log_group`, `log_stream` and `timestamp`"+` can be set dynamically using xref:configuration:interpolation.adoc#bloblang-queries[function interpolations], which are resolved individually for each message of a batch.
+
+Messages of a batch are grouped by their log group and stream, sorted by their timestamp and then sent in as few `+"`PutLogEvents`"+` calls as possible within its limits of 10,000 events and 1MB per call, where the events of each call span no more than 24 hours. Events larger than 256KB are rejected, as are events that CloudWatch Logs refuses to accept due to their timestamps being too old, too new or beyond the retention period of the log group.
+
+== Credentials
+
+By default Redpanda Connect will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more in xref:guides:cloud/aws.adoc[].`+service.OutputPerformanceDocs(true, true)).
+		Fields(
+			service.NewInterpolatedStringField(cwloFieldLogGroup).
+				Description("The log group to write events to.").
+				Example("my-app"),
+			service.NewInterpolatedStringField(cwloFieldLogStream).
+				Description("The log stream to write events to.").
+				Example(`${! hostname() }`).
+				Example(`${! meta("kafka_partition") }`),
+			service.NewInterpolatedStringField(cwloFieldTimestamp).
+				Description("An optional timestamp of each event, which is used for ordering the events of a batch. The timestamp must either be an integer of milliseconds since the Unix epoch, or a string in RFC 3339 format. When not set the time at which a message is written is used.").
+				Example(`${! metadata("kafka_timestamp_unix") * 1000 }`).
+				Example(`${! this.created_at }`).
+				Optional(),
+			service.NewBoolField(cwloFieldAutoCreate).
+				Description("Whether to create the log group and stream when they do not exist.").
+				Default(true),
+			service.NewIntField(cwloFieldRetentionDays).
+				Description(fmt.Sprintf("The number of days to retain events for in log groups created by this output, which is also applied to existing log groups that this output creates a log stream within. When set to zero events are retained indefinitely. Must be one of %v.", cwlRetentionDays)).
+				Default(0).
+				Advanced(),
+			service.NewOutputMaxInFlightField(),
+			service.NewBatchPolicyField(cwloFieldBatching),
+		).
+		Fields(config.SessionFields()...).
+		Fields(retries.CommonRetryBackOffFields(0, "1s", "5s", "30s")...)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("aws_cloudwatch_logs", cwloOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(cwloFieldBatching); err != nil {
+				return
+			}
+			var wConf cwloConfig
+			if wConf, err = cwloConfigFromParsed(conf); err != nil {
+				return
+			}
+			out, err = newCloudWatchLogsWriter(wConf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type cloudWatchLogsAPI interface {
+	PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+	CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error)
+	CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+	PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error)
+}
+
+type cloudWatchLogsWriter struct {
+	conf cwloConfig
+	cwl  cloudWatchLogsAPI
+	log  *service.Logger
+
+	// Log groups that have had their retention policy set.
+	retainedMut sync.Mutex
+	retained    map[string]struct{}
+
+	now func() time.Time
+}
+
+func newCloudWatchLogsWriter(conf cwloConfig, log *service.Logger) (*cloudWatchLogsWriter, error) {
+	return &cloudWatchLogsWriter{
+		conf:     conf,
+		log:      log,
+		retained: map[string]struct{}{},
+		now:      time.Now,
+	}, nil
+}
+
+func (c *cloudWatchLogsWriter) Connect(ctx context.Context) error {
+	if c.cwl != nil {
+		return nil
+	}
+	c.cwl = cloudwatchlogs.NewFromConfig(c.conf.aconf)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type cwlDestination struct {
+	group, stream string
+}
+
+// cwlEvent is a log event along with the index of its message within a batch.
+type cwlEvent struct {
+	index     int
+	timestamp int64
+	message   string
+}
+
+// cwlEventChunks splits events, which must be sorted by their timestamp, into
+// chunks that can each be sent with a single PutLogEvents call.
+func cwlEventChunks(events []cwlEvent) (chunks [][]cwlEvent) {
+	var current []cwlEvent
+	var currentBytes int
+	for _, e := range events {
+		eBytes := len(e.message) + cwlEventOverhead
+		if len(current) > 0 && (len(current) >= cwlMaxEventsCount ||
+			currentBytes+eBytes > cwlMaxBatchBytes ||
+			time.Duration(e.timestamp-current[0].timestamp)*time.Millisecond >= cwlMaxBatchTimeSpan) {
+			chunks = append(chunks, current)
+			current, currentBytes = nil, 0
+		}
+		current = append(current, e)
+		currentBytes += eBytes
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return
+}
+
+// cwlParseTimestamp parses a timestamp that is either milliseconds since the
+// Unix epoch or an RFC 3339 string.
+func cwlParseTimestamp(s string) (int64, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, fmt.Errorf("timestamp %q is neither an integer of milliseconds nor in RFC 3339 format", s)
+	}
+	return t.UnixMilli(), nil
+}
+
+func (c *cloudWatchLogsWriter) toEvents(batch service.MessageBatch) (map[cwlDestination][]cwlEvent, error) {
+	groupExec := batch.InterpolationExecutor(c.conf.LogGroup)
+	streamExec := batch.InterpolationExecutor(c.conf.LogStream)
+	var tsExec *service.MessageBatchInterpolationExecutor
+	if c.conf.Timestamp != nil {
+		tsExec = batch.InterpolationExecutor(c.conf.Timestamp)
+	}
+
+	now := c.now().UnixMilli()
+	dests := map[cwlDestination][]cwlEvent{}
+
+	err := batch.WalkWithBatchedErrors(func(i int, m *service.Message) error {
+		var dest cwlDestination
+		var err error
+		if dest.group, err = groupExec.TryString(i); err != nil {
+			return fmt.Errorf("log group interpolation: %w", err)
+		}
+		if dest.stream, err = streamExec.TryString(i); err != nil {
+			return fmt.Errorf("log stream interpolation: %w", err)
+		}
+
+		e := cwlEvent{index: i, timestamp: now}
+		if tsExec != nil {
+			tsStr, err := tsExec.TryString(i)
+			if err != nil {
+				return fmt.Errorf("timestamp interpolation: %w", err)
+			}
+			if e.timestamp, err = cwlParseTimestamp(tsStr); err != nil {
+				return err
+			}
+		}
+
+		mBytes, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+		if len(mBytes) > cwlMaxEventBytes {
+			return fmt.Errorf("message size %v exceeds the maximum CloudWatch Logs event size of %v", len(mBytes), cwlMaxEventBytes)
+		}
+		e.message = string(mBytes)
+
+		dests[dest] = append(dests[dest], e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, events := range dests {
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].timestamp < events[j].timestamp
+		})
+	}
+	return dests, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (c *cloudWatchLogsWriter) createDestination(ctx context.Context, dest cwlDestination) error {
+	var existsErr *types.ResourceAlreadyExistsException
+
+	if _, err := c.cwl.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: &dest.group,
+	}); err != nil {
+		if !errors.As(err, &existsErr) {
+			return fmt.Errorf("failed to create log group %v: %w", dest.group, err)
+		}
+	}
+
+	// The retention policy is also set when the group already exists, as a
+	// previous attempt may have created the group but failed to set it.
+	if err := c.setRetention(ctx, dest.group); err != nil {
+		return err
+	}
+
+	if _, err := c.cwl.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  &dest.group,
+		LogStreamName: &dest.stream,
+	}); err != nil && !errors.As(err, &existsErr) {
+		return fmt.Errorf("failed to create log stream %v: %w", dest.stream, err)
+	}
+	return nil
+}
+
+func (c *cloudWatchLogsWriter) setRetention(ctx context.Context, group string) error {
+	if c.conf.RetentionDays <= 0 {
+		return nil
+	}
+
+	c.retainedMut.Lock()
+	_, exists := c.retained[group]
+	c.retainedMut.Unlock()
+	if exists {
+		return nil
+	}
+
+	if _, err := c.cwl.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{
+		LogGroupName:    &group,
+		RetentionInDays: aws.Int32(int32(c.conf.RetentionDays)),
+	}); err != nil {
+		return fmt.Errorf("failed to set retention policy of log group %v: %w", group, err)
+	}
+
+	c.retainedMut.Lock()
+	c.retained[group] = struct{}{}
+	c.retainedMut.Unlock()
+	return nil
+}
+
+// cwlRejectedEvents returns the errors of events rejected by CloudWatch Logs,
+// keyed by their index within a PutLogEvents call.
+func cwlRejectedEvents(r *types.RejectedLogEventsInfo, n int) map[int]error {
+	rejected := map[int]error{}
+	if r == nil {
+		return rejected
+	}
+	if end := r.TooOldLogEventEndIndex; end != nil {
+		for i := 0; i <= int(*end) && i < n; i++ {
+			rejected[i] = errors.New("log event timestamp is too old")
+		}
+	}
+	if end := r.ExpiredLogEventEndIndex; end != nil {
+		for i := 0; i <= int(*end) && i < n; i++ {
+			rejected[i] = errors.New("log event timestamp is older than the retention period of the log group")
+		}
+	}
+	if start := r.TooNewLogEventStartIndex; start != nil {
+		for i := int(*start); i < n; i++ {
+			rejected[i] = errors.New("log event timestamp is too new")
+		}
+	}
+	return rejected
+}
+
+// putEvents sends events to a destination and returns the errors of any events
+// that were rejected, keyed by their index within events.
+func (c *cloudWatchLogsWriter) putEvents(ctx context.Context, dest cwlDestination, events []cwlEvent) (map[int]error, error) {
+	input := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  &dest.group,
+		LogStreamName: &dest.stream,
+		LogEvents:     make([]types.InputLogEvent, len(events)),
+	}
+	for i, e := range events {
+		input.LogEvents[i] = types.InputLogEvent{
+			Message:   aws.String(e.message),
+			Timestamp: aws.Int64(e.timestamp),
+		}
+	}
+
+	backOff := c.conf.backoffCtor()
+	created := false
+	for {
+		res, err := c.cwl.PutLogEvents(ctx, input)
+		if err == nil {
+			return cwlRejectedEvents(res.RejectedLogEventsInfo, len(events)), nil
+		}
+
+		var notFoundErr *types.ResourceNotFoundException
+		if c.conf.AutoCreate && !created && errors.As(err, &notFoundErr) {
+			if err = c.createDestination(ctx, dest); err == nil {
+				created = true
+				continue
+			}
+		}
+
+		wait := backOff.NextBackOff()
+		if wait == backoff.Stop {
+			return nil, err
+		}
+		c.log.Warnf("Failed to put log events to log group %v stream %v: %v\n", dest.group, dest.stream, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *cloudWatchLogsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	if c.cwl == nil {
+		return service.ErrNotConnected
+	}
+
+	dests, err := c.toEvents(batch)
+	if err != nil {
+		return err
+	}
+
+	var batchErr *service.BatchError
+	for dest, events := range dests {
+		for _, chunk := range cwlEventChunks(events) {
+			rejected, err := c.putEvents(ctx, dest, chunk)
+			if err != nil {
+				if batchErr == nil {
+					batchErr = service.NewBatchError(batch, err)
+				}
+				for _, e := range chunk {
+					batchErr.Failed(e.index, err)
+				}
+				continue
+			}
+			for i, rErr := range rejected {
+				if batchErr == nil {
+					batchErr = service.NewBatchError(batch, rErr)
+				}
+				batchErr.Failed(chunk[i].index, rErr)
+			}
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (c *cloudWatchLogsWriter) Close(context.Context) error {
+	return nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockCloudWatchLogs struct {
+	groups   map[string]int32
+	streams  map[string][]types.InputLogEvent
+	rejected *types.RejectedLogEventsInfo
+
+	retentionErr error
+}
+
+func (m *mockCloudWatchLogs) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	key := *params.LogGroupName + "/" + *params.LogStreamName
+	if _, exists := m.streams[key]; !exists {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("nope")}
+	}
+	m.streams[key] = append(m.streams[key], params.LogEvents...)
+	return &cloudwatchlogs.PutLogEventsOutput{RejectedLogEventsInfo: m.rejected}, nil
+}
+
+func (m *mockCloudWatchLogs) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	if _, exists := m.groups[*params.LogGroupName]; exists {
+		return nil, &types.ResourceAlreadyExistsException{Message: aws.String("exists")}
+	}
+	m.groups[*params.LogGroupName] = 0
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+func (m *mockCloudWatchLogs) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	key := *params.LogGroupName + "/" + *params.LogStreamName
+	if _, exists := m.streams[key]; exists {
+		return nil, &types.ResourceAlreadyExistsException{Message: aws.String("exists")}
+	}
+	m.streams[key] = nil
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (m *mockCloudWatchLogs) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	if err := m.retentionErr; err != nil {
+		m.retentionErr = nil
+		return nil, err
+	}
+	m.groups[*params.LogGroupName] = *params.RetentionInDays
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+
+func TestCloudWatchLogsEventChunks(t *testing.T) {
+	var events []cwlEvent
+	for i := 0; i < cwlMaxEventsCount+5; i++ {
+		events = append(events, cwlEvent{index: i, timestamp: 1000, message: "a"})
+	}
+	chunks := cwlEventChunks(events)
+	require.Len(t, chunks, 2)
+	assert.Len(t, chunks[0], cwlMaxEventsCount)
+	assert.Len(t, chunks[1], 5)
+
+	large := strings.Repeat("x", cwlMaxEventBytes)
+	chunks = cwlEventChunks([]cwlEvent{
+		{index: 0, timestamp: 1000, message: large},
+		{index: 1, timestamp: 1000, message: large},
+		{index: 2, timestamp: 1000, message: large},
+		{index: 3, timestamp: 1000, message: large},
+		{index: 4, timestamp: 1000, message: large},
+	})
+	require.Len(t, chunks, 2)
+	assert.Len(t, chunks[0], 4)
+	assert.Len(t, chunks[1], 1)
+
+	day := (24 * time.Hour).Milliseconds()
+	chunks = cwlEventChunks([]cwlEvent{
+		{index: 0, timestamp: 0, message: "a"},
+		{index: 1, timestamp: day - 1, message: "b"},
+		{index: 2, timestamp: day, message: "c"},
+		{index: 3, timestamp: day + 5, message: "d"},
+	})
+	require.Len(t, chunks, 2)
+	assert.Equal(t, []cwlEvent{{index: 0, timestamp: 0, message: "a"}, {index: 1, timestamp: day - 1, message: "b"}}, chunks[0])
+	assert.Equal(t, []cwlEvent{{index: 2, timestamp: day, message: "c"}, {index: 3, timestamp: day + 5, message: "d"}}, chunks[1])
+}
+
+func TestCloudWatchLogsParseTimestamp(t *testing.T) {
+	ts, err := cwlParseTimestamp("1700000000123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000123), ts)
+
+	ts, err = cwlParseTimestamp("2023-11-14T22:13:20.123Z")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000123), ts)
+
+	_, err = cwlParseTimestamp("nope")
+	require.Error(t, err)
+}
+
+func TestCloudWatchLogsWriteBatch(t *testing.T) {
+	conf, err := cwloConfigFromParsed(mustParseCWLConfig(t, `
+log_group: ${! meta("group") }
+log_stream: foo
+timestamp: ${! meta("ts") }
+retention_days: 7
+`))
+	require.NoError(t, err)
+	conf.backoffCtor = func() backoff.BackOff {
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1)
+	}
+
+	w, err := newCloudWatchLogsWriter(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	mock := &mockCloudWatchLogs{
+		groups:  map[string]int32{},
+		streams: map[string][]types.InputLogEvent{},
+	}
+	w.cwl = mock
+
+	newMsg := func(content, group, ts string) *service.Message {
+		m := service.NewMessage([]byte(content))
+		m.MetaSetMut("group", group)
+		m.MetaSetMut("ts", ts)
+		return m
+	}
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		newMsg("third", "a", "3000"),
+		newMsg("first", "a", "1000"),
+		newMsg("other", "b", "1500"),
+		newMsg("second", "a", "2000"),
+	}))
+
+	assert.Equal(t, map[string]int32{"a": 7, "b": 7}, mock.groups)
+
+	var messages []string
+	var timestamps []int64
+	for _, e := range mock.streams["a/foo"] {
+		messages = append(messages, *e.Message)
+		timestamps = append(timestamps, *e.Timestamp)
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, messages)
+	assert.Equal(t, []int64{1000, 2000, 3000}, timestamps)
+
+	require.Len(t, mock.streams["b/foo"], 1)
+	assert.Equal(t, "other", *mock.streams["b/foo"][0].Message)
+}
+
+func TestCloudWatchLogsRetentionRetry(t *testing.T) {
+	conf, err := cwloConfigFromParsed(mustParseCWLConfig(t, `
+log_group: foo
+log_stream: bar
+retention_days: 7
+`))
+	require.NoError(t, err)
+	conf.backoffCtor = func() backoff.BackOff {
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 0)
+	}
+
+	w, err := newCloudWatchLogsWriter(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	mock := &mockCloudWatchLogs{
+		groups:       map[string]int32{},
+		streams:      map[string][]types.InputLogEvent{},
+		retentionErr: errors.New("nope"),
+	}
+	w.cwl = mock
+
+	require.Error(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+	}))
+	assert.Equal(t, map[string]int32{"foo": 0}, mock.groups)
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+	}))
+	assert.Equal(t, map[string]int32{"foo": 7}, mock.groups)
+	require.Len(t, mock.streams["foo/bar"], 1)
+}
+
+func TestCloudWatchLogsNoAutoCreate(t *testing.T) {
+	conf, err := cwloConfigFromParsed(mustParseCWLConfig(t, `
+log_group: foo
+log_stream: bar
+auto_create: false
+`))
+	require.NoError(t, err)
+	conf.backoffCtor = func() backoff.BackOff {
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1)
+	}
+
+	w, err := newCloudWatchLogsWriter(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+	w.cwl = &mockCloudWatchLogs{
+		groups:  map[string]int32{},
+		streams: map[string][]types.InputLogEvent{},
+	}
+
+	err = w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+	})
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.IndexedErrors())
+}
+
+func TestCloudWatchLogsRejectedEvents(t *testing.T) {
+	conf, err := cwloConfigFromParsed(mustParseCWLConfig(t, `
+log_group: foo
+log_stream: bar
+timestamp: ${! meta("ts") }
+`))
+	require.NoError(t, err)
+
+	w, err := newCloudWatchLogsWriter(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+	w.cwl = &mockCloudWatchLogs{
+		groups:  map[string]int32{},
+		streams: map[string][]types.InputLogEvent{},
+		rejected: &types.RejectedLogEventsInfo{
+			ExpiredLogEventEndIndex:  aws.Int32(0),
+			TooOldLogEventEndIndex:   aws.Int32(1),
+			TooNewLogEventStartIndex: aws.Int32(4),
+		},
+	}
+
+	var batch service.MessageBatch
+	for _, ts := range []string{"5000", "3000", "1000", "4000", "2000"} {
+		m := service.NewMessage([]byte(ts))
+		m.MetaSetMut("ts", ts)
+		batch = append(batch, m)
+	}
+	index := batch.Index()
+
+	err = w.WriteBatch(context.Background(), batch)
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+
+	failed := map[string]string{}
+	batchErr.WalkMessagesIndexedBy(index, func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			ts, _ := batch[i].MetaGet("ts")
+			failed[ts] = err.Error()
+		}
+		return true
+	})
+	assert.Equal(t, map[string]string{
+		"1000": "log event timestamp is older than the retention period of the log group",
+		"2000": "log event timestamp is too old",
+		"5000": "log event timestamp is too new",
+	}, failed)
+}
+
+func TestCloudWatchLogsInvalidRetention(t *testing.T) {
+	_, err := cwloConfigFromParsed(mustParseCWLConfig(t, `
+log_group: foo
+log_stream: bar
+retention_days: 2
+`))
+	require.Error(t, err)
+}
+
+func mustParseCWLConfig(t testing.TB, yamlStr string) *service.ParsedConfig {
+	t.Helper()
+	pConf, err := cwloOutputSpec().ParseYAML(yamlStr, nil)
+	require.NoError(t, err)
+	return pConf
+}
@@ -28,7 +28,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/gofrs/uuid"
 
+	"github.com/redpanda-data/benthos/v4/public/bloblang"
 	"github.com/redpanda-data/benthos/v4/public/service"
 
 	"github.com/redpanda-data/connect/v4/internal/impl/aws/config"
@@ -44,14 +46,34 @@ const (
 	ddboFieldTTL            = "ttl"
 	ddboFieldTTLKey         = "ttl_key"
 	ddboFieldBatching       = "batching"
+	ddboFieldOperation      = "operation"
+	ddboFieldKeyMapping     = "key_mapping"
+	ddboFieldUpdateExpr     = "update_expression"
+	ddboFieldConditionExpr  = "condition_expression"
+	ddboFieldExprAttrNames  = "expression_attribute_names"
+	ddboFieldExprAttrValues = "expression_attribute_values"
+	ddboFieldTransaction    = "transaction"
+
+	ddboOperationPut    = "put"
+	ddboOperationUpdate = "update"
+	ddboOperationDelete = "delete"
+
+	ddbMaxTransactItems = 100
 )
 
 type ddboConfig struct {
-	Table          string
-	StringColumns  map[string]*service.InterpolatedString
-	JSONMapColumns map[string]string
-	TTL            string
-	TTLKey         string
+	Table               string
+	StringColumns       map[string]*service.InterpolatedString
+	JSONMapColumns      map[string]string
+	TTL                 string
+	TTLKey              string
+	Operation           *service.InterpolatedString
+	KeyMapping          *bloblang.Executor
+	UpdateExpression    *service.InterpolatedString
+	ConditionExpression *service.InterpolatedString
+	ExprAttrNames       map[string]*service.InterpolatedString
+	ExprAttrValues      *bloblang.Executor
+	Transaction         bool
 
 	aconf       aws.Config
 	backoffCtor func() backoff.BackOff
@@ -73,6 +95,35 @@ func ddboConfigFromParsed(pConf *service.ParsedConfig) (conf ddboConfig, err err
 	if conf.TTLKey, err = pConf.FieldString(ddboFieldTTLKey); err != nil {
 		return
 	}
+	if conf.Operation, err = pConf.FieldInterpolatedString(ddboFieldOperation); err != nil {
+		return
+	}
+	if pConf.Contains(ddboFieldKeyMapping) {
+		if conf.KeyMapping, err = pConf.FieldBloblang(ddboFieldKeyMapping); err != nil {
+			return
+		}
+	}
+	if pConf.Contains(ddboFieldUpdateExpr) {
+		if conf.UpdateExpression, err = pConf.FieldInterpolatedString(ddboFieldUpdateExpr); err != nil {
+			return
+		}
+	}
+	if pConf.Contains(ddboFieldConditionExpr) {
+		if conf.ConditionExpression, err = pConf.FieldInterpolatedString(ddboFieldConditionExpr); err != nil {
+			return
+		}
+	}
+	if conf.ExprAttrNames, err = pConf.FieldInterpolatedStringMap(ddboFieldExprAttrNames); err != nil {
+		return
+	}
+	if pConf.Contains(ddboFieldExprAttrValues) {
+		if conf.ExprAttrValues, err = pConf.FieldBloblang(ddboFieldExprAttrValues); err != nil {
+			return
+		}
+	}
+	if conf.Transaction, err = pConf.FieldBool(ddboFieldTransaction); err != nil {
+		return
+	}
 	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
 		return
 	}
@@ -87,7 +138,7 @@ func ddboOutputSpec() *service.ConfigSpec {
 		Stable().
 		Version("3.36.0").
 		Categories("Services", "AWS").
-		Summary(`Inserts items into a DynamoDB table.`).
+		Summary(`Inserts, updates or deletes items of a DynamoDB table.`).
 		Description(`
 The field `+"`string_columns`"+` is a map of column names to string values, where the values are xref:configuration:interpolation.adoc#bloblang-queries[function interpolated] per message of a batch. This allows you to populate string columns of an item by extracting fields within the document payload or metadata like follows:
 
@@ -116,6 +167,26 @@ json_map_columns:
 
 In which case the top level document fields will be written at the root of the item, potentially overwriting previously defined column values. If a path is not found within a document the column will not be populated.
 
+== Operations
+
+The field `+"`operation`"+` determines whether each message puts, updates or deletes an item, and can be set dynamically per message. Items to update or delete are identified by the primary key produced by the `+"`key_mapping`"+`, and updates are described by an `+"`update_expression`"+`. Any operation can be made conditional with a `+"`condition_expression`"+`, where the placeholders of all expressions are populated with `+"`expression_attribute_names`"+` and `+"`expression_attribute_values`"+`. This allows you to perform optimistic-concurrency upserts like follows:
+
+`+"```yml"+`
+operation: update
+key_mapping: 'root.id = this.id'
+update_expression: 'SET #content = :content, version = :version'
+condition_expression: 'attribute_not_exists(id) OR version < :version'
+expression_attribute_names:
+  "#content": content
+expression_attribute_values: |
+  root.":content" = this.content
+  root.":version" = this.version
+`+"```"+`
+
+Messages that fail a condition check are reported individually as errors, without being retried by this output, and can therefore be routed elsewhere with a `+"`fallback`"+` or `+"`switch`"+` output.
+
+Puts and deletes without conditions are written with `+"`BatchWriteItem`"+`, whereas all other operations are sent individually. When `+"`transaction`"+` is enabled each batch is instead written atomically with `+"`TransactWriteItems`"+`, in which case the `+"`count`"+` of the batching policy must be set to no more than 100, and a failed condition check of any message fails the entire batch.
+
 == Credentials
 
 By default Redpanda Connect will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more in xref:guides:cloud/aws.adoc[].
@@ -156,6 +227,45 @@ This output benefits from sending messages as a batch for improved performance.
 				Description("The column key to place the TTL value within.").
 				Default("").
 				Advanced(),
+			service.NewInterpolatedStringEnumField(ddboFieldOperation, ddboOperationPut, ddboOperationUpdate, ddboOperationDelete).
+				Description("The operation to perform for each message.").
+				Version("4.33.0").
+				Default(ddboOperationPut),
+			service.NewBloblangField(ddboFieldKeyMapping).
+				Description("A xref:guides:bloblang/about.adoc[Bloblang mapping] that produces an object of the primary key attributes of the item to update or delete. Required for the `update` and `delete` operations.").
+				Version("4.33.0").
+				Example(`root.id = this.id`).
+				Example(`root = { "pk": this.user_id, "sk": this.created_at }`).
+				Optional(),
+			service.NewInterpolatedStringField(ddboFieldUpdateExpr).
+				Description("An update expression describing the attributes to modify. Required for the `update` operation.").
+				Version("4.33.0").
+				Example(`SET content = :content ADD version :one`).
+				Optional(),
+			service.NewInterpolatedStringField(ddboFieldConditionExpr).
+				Description("An optional condition that must be satisfied in order for an operation to succeed. When the condition is empty for a message it is not applied.").
+				Version("4.33.0").
+				Example(`attribute_not_exists(id)`).
+				Example(`version < :version`).
+				Optional(),
+			service.NewInterpolatedStringMapField(ddboFieldExprAttrNames).
+				Description("A map of placeholders to attribute names for use within expressions.").
+				Version("4.33.0").
+				Default(map[string]any{}).
+				Example(map[string]any{
+					"#content": "content",
+				}),
+			service.NewBloblangField(ddboFieldExprAttrValues).
+				Description("A xref:guides:bloblang/about.adoc[Bloblang mapping] that produces an object of placeholders to values for use within expressions.").
+				Version("4.33.0").
+				Example(`root.":content" = this.content
+root.":version" = this.version`).
+				Optional(),
+			service.NewBoolField(ddboFieldTransaction).
+				Description("Whether to write each batch atomically with `TransactWriteItems`. When enabled the `count` of the batching policy must be set to between 1 and 100, and larger batches are rejected.").
+				Version("4.33.0").
+				Default(false).
+				Advanced(),
 			service.NewOutputMaxInFlightField(),
 			service.NewBatchPolicyField(ddboFieldBatching),
 		).
@@ -176,6 +286,10 @@ func init() {
 			if wConf, err = ddboConfigFromParsed(conf); err != nil {
 				return
 			}
+			if wConf.Transaction && (batchPolicy.Count <= 0 || batchPolicy.Count > ddbMaxTransactItems) {
+				err = fmt.Errorf("batching count must be between 1 and %v when transaction is enabled", ddbMaxTransactItems)
+				return
+			}
 			out, err = newDynamoDBWriter(wConf, mgr)
 			return
 		})
@@ -191,6 +305,8 @@ type dynamoDBAPI interface {
 	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
 	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
 	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 }
 
 type dynamoDBWriter struct {
@@ -210,8 +326,8 @@ func newDynamoDBWriter(conf ddboConfig, mgr *service.Resources) (*dynamoDBWriter
 		log:   mgr.Logger(),
 		table: aws.String(conf.Table),
 	}
-	if len(conf.StringColumns) == 0 && len(conf.JSONMapColumns) == 0 {
-		return nil, errors.New("you must provide at least one column")
+	if len(conf.StringColumns) == 0 && len(conf.JSONMapColumns) == 0 && conf.KeyMapping == nil {
+		return nil, errors.New("you must provide at least one column or a key_mapping")
 	}
 	for k, v := range conf.JSONMapColumns {
 		if v == "." {
@@ -312,111 +428,318 @@ func jsonToMap(path string, root any) (types.AttributeValue, error) {
 	return anyToAttributeValue(gObj.Data()), nil
 }
 
-func (d *dynamoDBWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
-	if d.client == nil {
-		return service.ErrNotConnected
+// ddboRequest describes the write operation of a single message.
+type ddboRequest struct {
+	operation string
+	item      map[string]types.AttributeValue
+	key       map[string]types.AttributeValue
+	update    *string
+	condition *string
+	names     map[string]string
+	values    map[string]types.AttributeValue
+}
+
+// batchable returns whether the request can be sent as part of a
+// BatchWriteItem call, which does not support updates or conditions.
+func (r *ddboRequest) batchable() bool {
+	return r.operation != ddboOperationUpdate && r.condition == nil
+}
+
+func (r *ddboRequest) writeRequest() types.WriteRequest {
+	if r.operation == ddboOperationDelete {
+		return types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: r.key},
+		}
+	}
+	return types.WriteRequest{
+		PutRequest: &types.PutRequest{Item: r.item},
 	}
+}
 
-	boff := d.boffPool.Get().(backoff.BackOff)
-	defer func() {
-		boff.Reset()
-		d.boffPool.Put(boff)
-	}()
+// expressionAttributes returns the expression placeholders of the request,
+// which DynamoDB rejects unless an update or condition expression is present.
+func (r *ddboRequest) expressionAttributes() (map[string]string, map[string]types.AttributeValue) {
+	if r.update == nil && r.condition == nil {
+		return nil, nil
+	}
+	return r.names, r.values
+}
 
-	writeReqs := []types.WriteRequest{}
-	if err := b.WalkWithBatchedErrors(func(i int, p *service.Message) error {
-		items := map[string]types.AttributeValue{}
-		if d.ttl != 0 && d.conf.TTLKey != "" {
-			items[d.conf.TTLKey] = &types.AttributeValueMemberN{
-				Value: strconv.FormatInt(time.Now().Add(d.ttl).Unix(), 10),
-			}
+func (r *ddboRequest) transactItem(table *string) types.TransactWriteItem {
+	names, values := r.expressionAttributes()
+	switch r.operation {
+	case ddboOperationUpdate:
+		return types.TransactWriteItem{Update: &types.Update{
+			TableName:                 table,
+			Key:                       r.key,
+			UpdateExpression:          r.update,
+			ConditionExpression:       r.condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}}
+	case ddboOperationDelete:
+		return types.TransactWriteItem{Delete: &types.Delete{
+			TableName:                 table,
+			Key:                       r.key,
+			ConditionExpression:       r.condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}}
+	}
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName:                 table,
+		Item:                      r.item,
+		ConditionExpression:       r.condition,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}}
+}
+
+func (r *ddboRequest) send(ctx context.Context, client dynamoDBAPI, table *string) (err error) {
+	names, values := r.expressionAttributes()
+	switch r.operation {
+	case ddboOperationUpdate:
+		_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 table,
+			Key:                       r.key,
+			UpdateExpression:          r.update,
+			ConditionExpression:       r.condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		})
+	case ddboOperationDelete:
+		_, err = client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:                 table,
+			Key:                       r.key,
+			ConditionExpression:       r.condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		})
+	default:
+		_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 table,
+			Item:                      r.item,
+			ConditionExpression:       r.condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		})
+	}
+	return
+}
+
+func isConditionalCheckFailed(err error) bool {
+	var cErr *types.ConditionalCheckFailedException
+	return errors.As(err, &cErr)
+}
+
+func mappingToAttributeValues(b service.MessageBatch, i int, exec *bloblang.Executor) (map[string]types.AttributeValue, error) {
+	m, err := b.BloblangQuery(i, exec)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, errors.New("mapping resulted in a deleted message")
+	}
+	root, err := m.AsStructured()
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected mapping to result in an object, got %T", root)
+	}
+	values := make(map[string]types.AttributeValue, len(obj))
+	for k, v := range obj {
+		values[k] = mappingValueToAttributeValue(v)
+	}
+	return values, nil
+}
+
+// mappingValueToAttributeValue converts the result of a mapping into an
+// attribute value, where unlike anyToAttributeValue numbers parsed from JSON
+// documents remain numbers so that they can be used within expressions.
+func mappingValueToAttributeValue(root any) types.AttributeValue {
+	switch v := root.(type) {
+	case map[string]any:
+		m := make(map[string]types.AttributeValue, len(v))
+		for k, v2 := range v {
+			m[k] = mappingValueToAttributeValue(v2)
 		}
-		for k, v := range d.conf.StringColumns {
-			s, err := b.TryInterpolatedString(i, v)
-			if err != nil {
-				return fmt.Errorf("string column %v interpolation error: %w", k, err)
-			}
-			items[k] = &types.AttributeValueMemberS{
-				Value: s,
-			}
+		return &types.AttributeValueMemberM{
+			Value: m,
 		}
-		if len(d.conf.JSONMapColumns) > 0 {
-			jRoot, err := p.AsStructured()
-			if err != nil {
-				d.log.Errorf("Failed to extract JSON maps from document: %v", err)
-				return err
-			}
-			for k, v := range d.conf.JSONMapColumns {
-				if attr, err := jsonToMap(v, jRoot); err == nil {
-					if k == "" {
-						if mv, ok := attr.(*types.AttributeValueMemberM); ok {
-							for ak, av := range mv.Value {
-								items[ak] = av
-							}
-						} else {
-							items[k] = attr
+	case []any:
+		l := make([]types.AttributeValue, len(v))
+		for i, v2 := range v {
+			l[i] = mappingValueToAttributeValue(v2)
+		}
+		return &types.AttributeValueMemberL{
+			Value: l,
+		}
+	case json.Number:
+		return &types.AttributeValueMemberN{
+			Value: v.String(),
+		}
+	}
+	return anyToAttributeValue(root)
+}
+
+func (d *dynamoDBWriter) itemFromMessage(b service.MessageBatch, i int, p *service.Message) (map[string]types.AttributeValue, error) {
+	items := map[string]types.AttributeValue{}
+	if d.ttl != 0 && d.conf.TTLKey != "" {
+		items[d.conf.TTLKey] = &types.AttributeValueMemberN{
+			Value: strconv.FormatInt(time.Now().Add(d.ttl).Unix(), 10),
+		}
+	}
+	for k, v := range d.conf.StringColumns {
+		s, err := b.TryInterpolatedString(i, v)
+		if err != nil {
+			return nil, fmt.Errorf("string column %v interpolation error: %w", k, err)
+		}
+		items[k] = &types.AttributeValueMemberS{
+			Value: s,
+		}
+	}
+	if len(d.conf.JSONMapColumns) > 0 {
+		jRoot, err := p.AsStructured()
+		if err != nil {
+			d.log.Errorf("Failed to extract JSON maps from document: %v", err)
+			return nil, err
+		}
+		for k, v := range d.conf.JSONMapColumns {
+			if attr, err := jsonToMap(v, jRoot); err == nil {
+				if k == "" {
+					if mv, ok := attr.(*types.AttributeValueMemberM); ok {
+						for ak, av := range mv.Value {
+							items[ak] = av
 						}
 					} else {
 						items[k] = attr
 					}
 				} else {
-					d.log.Warnf("Unable to extract JSON map path '%v' from document: %v", v, err)
-					return err
+					items[k] = attr
 				}
+			} else {
+				d.log.Warnf("Unable to extract JSON map path '%v' from document: %v", v, err)
+				return nil, err
 			}
 		}
-		writeReqs = append(writeReqs, types.WriteRequest{
-			PutRequest: &types.PutRequest{
-				Item: items,
-			},
-		})
+	}
+	return items, nil
+}
+
+func (d *dynamoDBWriter) requestFromMessage(b service.MessageBatch, i int, p *service.Message) (*ddboRequest, error) {
+	op, err := b.TryInterpolatedString(i, d.conf.Operation)
+	if err != nil {
+		return nil, fmt.Errorf("operation interpolation error: %w", err)
+	}
+
+	req := &ddboRequest{operation: op}
+	switch op {
+	case ddboOperationPut:
+		if len(d.conf.StringColumns) == 0 && len(d.conf.JSONMapColumns) == 0 {
+			return nil, errors.New("put operations require at least one column")
+		}
+		if req.item, err = d.itemFromMessage(b, i, p); err != nil {
+			return nil, err
+		}
+	case ddboOperationUpdate, ddboOperationDelete:
+		if d.conf.KeyMapping == nil {
+			return nil, fmt.Errorf("%v operations require a key_mapping", op)
+		}
+		if req.key, err = mappingToAttributeValues(b, i, d.conf.KeyMapping); err != nil {
+			return nil, fmt.Errorf("key mapping error: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognised operation: %v", op)
+	}
+
+	if op == ddboOperationUpdate {
+		if d.conf.UpdateExpression == nil {
+			return nil, errors.New("update operations require an update_expression")
+		}
+		s, err := b.TryInterpolatedString(i, d.conf.UpdateExpression)
+		if err != nil {
+			return nil, fmt.Errorf("update expression interpolation error: %w", err)
+		}
+		req.update = aws.String(s)
+	}
+
+	if d.conf.ConditionExpression != nil {
+		s, err := b.TryInterpolatedString(i, d.conf.ConditionExpression)
+		if err != nil {
+			return nil, fmt.Errorf("condition expression interpolation error: %w", err)
+		}
+		if s != "" {
+			req.condition = aws.String(s)
+		}
+	}
+
+	if len(d.conf.ExprAttrNames) > 0 {
+		req.names = make(map[string]string, len(d.conf.ExprAttrNames))
+		for k, v := range d.conf.ExprAttrNames {
+			s, err := b.TryInterpolatedString(i, v)
+			if err != nil {
+				return nil, fmt.Errorf("expression attribute name %v interpolation error: %w", k, err)
+			}
+			req.names[k] = s
+		}
+	}
+
+	if d.conf.ExprAttrValues != nil {
+		if req.values, err = mappingToAttributeValues(b, i, d.conf.ExprAttrValues); err != nil {
+			return nil, fmt.Errorf("expression attribute values mapping error: %w", err)
+		}
+		if len(req.values) == 0 {
+			req.values = nil
+		}
+	}
+	return req, nil
+}
+
+func (d *dynamoDBWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	if d.client == nil {
+		return service.ErrNotConnected
+	}
+
+	boff := d.boffPool.Get().(backoff.BackOff)
+	defer func() {
+		boff.Reset()
+		d.boffPool.Put(boff)
+	}()
+
+	reqs := make([]*ddboRequest, 0, len(b))
+	if err := b.WalkWithBatchedErrors(func(i int, p *service.Message) error {
+		req, err := d.requestFromMessage(b, i, p)
+		if err != nil {
+			return err
+		}
+		reqs = append(reqs, req)
 		return nil
 	}); err != nil {
 		return err
 	}
 
+	if d.conf.Transaction {
+		return d.writeTransaction(ctx, b, reqs, boff)
+	}
+
+	writeReqs := make([]types.WriteRequest, 0, len(reqs))
+	for _, req := range reqs {
+		if !req.batchable() {
+			return d.writeIndividually(ctx, b, reqs, boff, nil)
+		}
+		writeReqs = append(writeReqs, req.writeRequest())
+	}
+
 	batchResult, err := d.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
 		RequestItems: map[string][]types.WriteRequest{
 			*d.table: writeReqs,
 		},
 	})
 	if err != nil {
-		headlineErr := err
-
 		// None of the messages were successful, attempt to send individually
-	individualRequestsLoop:
-		for err != nil {
-			batchErr := service.NewBatchError(b, headlineErr)
-			for i, req := range writeReqs {
-				if req.PutRequest == nil {
-					continue
-				}
-				if _, iErr := d.client.PutItem(ctx, &dynamodb.PutItemInput{
-					TableName: d.table,
-					Item:      req.PutRequest.Item,
-				}); iErr != nil {
-					d.log.Errorf("Put error: %v\n", iErr)
-					wait := boff.NextBackOff()
-					if wait == backoff.Stop {
-						break individualRequestsLoop
-					}
-					select {
-					case <-time.After(wait):
-					case <-ctx.Done():
-						break individualRequestsLoop
-					}
-					batchErr.Failed(i, iErr)
-				} else {
-					writeReqs[i].PutRequest = nil
-				}
-			}
-			if batchErr.IndexedErrors() == 0 {
-				err = nil
-			} else {
-				err = batchErr
-			}
-		}
-		return err
+		return d.writeIndividually(ctx, b, reqs, boff, err)
 	}
 
 	unproc := batchResult.UnprocessedItems[*d.table]
@@ -453,6 +776,158 @@ unprocessedLoop:
 	return err
 }
 
+// writeIndividually sends each request of a batch in its own call, retrying
+// failed requests until the backoff is exhausted. Requests that fail a
+// condition check are reported without being retried.
+func (d *dynamoDBWriter) writeIndividually(ctx context.Context, b service.MessageBatch, reqs []*ddboRequest, boff backoff.BackOff, headlineErr error) error {
+	pending := make([]*ddboRequest, len(reqs))
+	copy(pending, reqs)
+
+	var failed map[int]error
+	conditionFailed := map[int]error{}
+
+individualRequestsLoop:
+	for {
+		failed = map[int]error{}
+		for i, req := range pending {
+			if req == nil {
+				continue
+			}
+			iErr := req.send(ctx, d.client, d.table)
+			if iErr == nil {
+				pending[i] = nil
+				continue
+			}
+			if isConditionalCheckFailed(iErr) {
+				conditionFailed[i] = iErr
+				pending[i] = nil
+				continue
+			}
+			d.log.Errorf("%v error: %v\n", req.operation, iErr)
+			failed[i] = iErr
+
+			wait := boff.NextBackOff()
+			if wait == backoff.Stop {
+				break individualRequestsLoop
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				break individualRequestsLoop
+			}
+		}
+		if len(failed) == 0 {
+			break
+		}
+	}
+
+	// Any requests that were never attempted before running out of retries
+	// are also failed.
+	for i, req := range pending {
+		if req == nil {
+			continue
+		}
+		if _, exists := failed[i]; !exists {
+			if failed[i] = ctx.Err(); failed[i] == nil {
+				failed[i] = errors.New("ran out of request retries")
+			}
+		}
+	}
+	for i, err := range conditionFailed {
+		failed[i] = err
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	if headlineErr == nil {
+		for i := range b {
+			if err, exists := failed[i]; exists {
+				headlineErr = err
+				break
+			}
+		}
+	}
+	batchErr := service.NewBatchError(b, headlineErr)
+	for i := range b {
+		if err, exists := failed[i]; exists {
+			batchErr.Failed(i, err)
+		}
+	}
+	return batchErr
+}
+
+// writeTransaction writes all requests of a batch atomically, retrying the
+// transaction until the backoff is exhausted unless it was cancelled due to a
+// failed condition check.
+func (d *dynamoDBWriter) writeTransaction(ctx context.Context, b service.MessageBatch, reqs []*ddboRequest, boff backoff.BackOff) error {
+	if len(reqs) > ddbMaxTransactItems {
+		// Retrying a batch that is too large would never succeed.
+		err := fmt.Errorf("batch of %v messages exceeds the transaction limit of %v items", len(reqs), ddbMaxTransactItems)
+		batchErr := service.NewBatchError(b, err)
+		for i := range b {
+			batchErr.Failed(i, err)
+		}
+		return batchErr
+	}
+
+	items := make([]types.TransactWriteItem, len(reqs))
+	for i, req := range reqs {
+		items[i] = req.transactItem(d.table)
+	}
+
+	// Retries of the transaction share a token so that an attempt that
+	// succeeded without us receiving the response isn't applied twice.
+	token, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	for {
+		_, err := d.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems:      items,
+			ClientRequestToken: aws.String(token.String()),
+		})
+		if err == nil {
+			return nil
+		}
+
+		var cancelErr *types.TransactionCanceledException
+		if errors.As(err, &cancelErr) {
+			batchErr := service.NewBatchError(b, err)
+			conditionFailed := false
+			for i, reason := range cancelErr.CancellationReasons {
+				if i >= len(b) || aws.ToString(reason.Code) != "ConditionalCheckFailed" {
+					continue
+				}
+				conditionFailed = true
+				batchErr.Failed(i, fmt.Errorf("conditional check failed: %v", aws.ToString(reason.Message)))
+			}
+			if conditionFailed {
+				// The remaining messages were only rejected due to being
+				// part of a failed transaction.
+				for i := range b {
+					if i >= len(cancelErr.CancellationReasons) || aws.ToString(cancelErr.CancellationReasons[i].Code) != "ConditionalCheckFailed" {
+						batchErr.Failed(i, err)
+					}
+				}
+				return batchErr
+			}
+		}
+
+		d.log.Errorf("Transaction error: %v\n", err)
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
 func (d *dynamoDBWriter) Close(context.Context) error {
 	return nil
 }
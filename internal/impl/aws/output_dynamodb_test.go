@@ -17,7 +17,9 @@ package aws
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -30,8 +32,11 @@ import (
 
 type mockDynamoDB struct {
 	dynamoDBAPI
-	fn      func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
-	batchFn func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	fn         func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	batchFn    func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	updateFn   func(*dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	deleteFn   func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	transactFn func(*dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
 }
 
 func (m *mockDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
@@ -42,6 +47,18 @@ func (m *mockDynamoDB) BatchWriteItem(ctx context.Context, params *dynamodb.Batc
 	return m.batchFn(params)
 }
 
+func (m *mockDynamoDB) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return m.updateFn(params)
+}
+
+func (m *mockDynamoDB) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return m.deleteFn(params)
+}
+
+func (m *mockDynamoDB) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return m.transactFn(params)
+}
+
 func testDDBOWriter(t *testing.T, conf string) *dynamoDBWriter {
 	t.Helper()
 
@@ -509,3 +526,289 @@ string_columns:
 
 	assert.Equal(t, expected, requests)
 }
+
+func TestDynamoDBConditionalUpdates(t *testing.T) {
+	t.Parallel()
+
+	db := testDDBOWriter(t, `
+table: FooTable
+operation: update
+key_mapping: 'root.id = this.id'
+update_expression: 'SET #content = :content, version = :version'
+condition_expression: 'attribute_not_exists(id) OR version < :version'
+expression_attribute_names:
+  "#content": content
+expression_attribute_values: |
+  root.":content" = this.content
+  root.":version" = this.version
+`)
+
+	var requests []*dynamodb.UpdateItemInput
+	db.client = &mockDynamoDB{
+		updateFn: func(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			requests = append(requests, input)
+			if input.Key["id"].(*types.AttributeValueMemberS).Value == "bar" {
+				return nil, &types.ConditionalCheckFailedException{Message: aws.String("nope")}
+			}
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+	}
+
+	msg := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo","content":"foo stuff","version":2}`)),
+		service.NewMessage([]byte(`{"id":"bar","content":"bar stuff","version":1}`)),
+	}
+	indexer := msg.Index()
+
+	err := db.WriteBatch(context.Background(), msg)
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.IndexedErrors())
+
+	var failed []int
+	batchErr.WalkMessagesIndexedBy(indexer, func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+			assert.True(t, isConditionalCheckFailed(err))
+		}
+		return true
+	})
+	assert.Equal(t, []int{1}, failed)
+
+	// Conditional check failures must not be retried
+	require.Len(t, requests, 2)
+	assert.Equal(t, &dynamodb.UpdateItemInput{
+		TableName:           aws.String("FooTable"),
+		Key:                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "foo"}},
+		UpdateExpression:    aws.String("SET #content = :content, version = :version"),
+		ConditionExpression: aws.String("attribute_not_exists(id) OR version < :version"),
+		ExpressionAttributeNames: map[string]string{
+			"#content": "content",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":content": &types.AttributeValueMemberS{Value: "foo stuff"},
+			":version": &types.AttributeValueMemberN{Value: "2"},
+		},
+	}, requests[0])
+}
+
+func TestDynamoDBMixedOperations(t *testing.T) {
+	t.Parallel()
+
+	db := testDDBOWriter(t, `
+table: FooTable
+operation: ${! meta("op") }
+key_mapping: 'root.id = this.id'
+string_columns:
+  id: ${!json("id")}
+  content: ${!json("content")}
+`)
+
+	var batchRequest []types.WriteRequest
+	db.client = &mockDynamoDB{
+		batchFn: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			batchRequest = input.RequestItems["FooTable"]
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+	}
+
+	putMsg := service.NewMessage([]byte(`{"id":"foo","content":"foo stuff"}`))
+	putMsg.MetaSetMut("op", "put")
+	delMsg := service.NewMessage([]byte(`{"id":"bar"}`))
+	delMsg.MetaSetMut("op", "delete")
+
+	require.NoError(t, db.WriteBatch(context.Background(), service.MessageBatch{putMsg, delMsg}))
+	assert.Equal(t, []types.WriteRequest{
+		{
+			PutRequest: &types.PutRequest{
+				Item: map[string]types.AttributeValue{
+					"id":      &types.AttributeValueMemberS{Value: "foo"},
+					"content": &types.AttributeValueMemberS{Value: "foo stuff"},
+				},
+			},
+		},
+		{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: "bar"},
+				},
+			},
+		},
+	}, batchRequest)
+
+	badMsg := service.NewMessage([]byte(`{"id":"baz"}`))
+	badMsg.MetaSetMut("op", "nope")
+	require.Error(t, db.WriteBatch(context.Background(), service.MessageBatch{badMsg}))
+}
+
+func TestDynamoDBTransaction(t *testing.T) {
+	t.Parallel()
+
+	db := testDDBOWriter(t, `
+table: FooTable
+transaction: true
+operation: delete
+key_mapping: 'root.id = this.id'
+condition_expression: 'attribute_exists(id)'
+`)
+
+	var transactions []*dynamodb.TransactWriteItemsInput
+	db.client = &mockDynamoDB{
+		transactFn: func(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			transactions = append(transactions, input)
+			if len(transactions) == 1 {
+				return nil, &types.TransactionCanceledException{
+					CancellationReasons: []types.CancellationReason{
+						{Code: aws.String("None")},
+						{Code: aws.String("TransactionConflict")},
+					},
+				}
+			}
+			return nil, &types.TransactionCanceledException{
+				CancellationReasons: []types.CancellationReason{
+					{Code: aws.String("None")},
+					{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("nope")},
+				},
+			}
+		},
+	}
+
+	msg := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo"}`)),
+		service.NewMessage([]byte(`{"id":"bar"}`)),
+	}
+
+	err := db.WriteBatch(context.Background(), msg)
+	require.Error(t, err)
+
+	// Conflicts are retried whereas condition failures are not
+	require.Len(t, transactions, 2)
+	assert.Equal(t, []types.TransactWriteItem{
+		{Delete: &types.Delete{
+			TableName:           aws.String("FooTable"),
+			Key:                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "foo"}},
+			ConditionExpression: aws.String("attribute_exists(id)"),
+		}},
+		{Delete: &types.Delete{
+			TableName:           aws.String("FooTable"),
+			Key:                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "bar"}},
+			ConditionExpression: aws.String("attribute_exists(id)"),
+		}},
+	}, transactions[0].TransactItems)
+
+	// Retries of a transaction share the same token
+	require.NotEmpty(t, aws.ToString(transactions[0].ClientRequestToken))
+	assert.Equal(t, transactions[0].ClientRequestToken, transactions[1].ClientRequestToken)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 2, batchErr.IndexedErrors())
+}
+
+func TestDynamoDBTransactionTooLarge(t *testing.T) {
+	t.Parallel()
+
+	db := testDDBOWriter(t, `
+table: FooTable
+transaction: true
+string_columns:
+  id: ${!json("id")}
+`)
+
+	db.client = &mockDynamoDB{
+		transactFn: func(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			t.Error("transaction should not be attempted")
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+	}
+
+	var batch service.MessageBatch
+	for i := 0; i < 101; i++ {
+		batch = append(batch, service.NewMessage([]byte(fmt.Sprintf(`{"id":"%v"}`, i))))
+	}
+
+	err := db.WriteBatch(context.Background(), batch)
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 101, batchErr.IndexedErrors())
+}
+
+func TestDynamoDBTransactionBatchCount(t *testing.T) {
+	t.Parallel()
+
+	for _, count := range []int{0, 101} {
+		sb := service.NewStreamBuilder()
+		require.NoError(t, sb.AddOutputYAML(fmt.Sprintf(`
+aws_dynamodb:
+  table: FooTable
+  transaction: true
+  string_columns:
+    id: ${!json("id")}
+  batching:
+    count: %v
+`, count)))
+		require.NoError(t, sb.AddInputYAML(`
+generate:
+  count: 1
+  mapping: 'root.id = "foo"'
+`))
+		strm, err := sb.Build()
+		require.NoError(t, err)
+
+		ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+		err = strm.Run(ctx)
+		done()
+		require.ErrorContains(t, err, "batching count must be between 1 and 100", count)
+	}
+}
+
+func TestDynamoDBTransactionWithoutExpressions(t *testing.T) {
+	t.Parallel()
+
+	db := testDDBOWriter(t, `
+table: FooTable
+transaction: true
+operation: ${! meta("op") }
+key_mapping: 'root.id = this.id'
+condition_expression: ${! meta("condition").or("") }
+expression_attribute_names:
+  '#v': version
+string_columns:
+  id: ${!json("id")}
+`)
+
+	var transaction *dynamodb.TransactWriteItemsInput
+	db.client = &mockDynamoDB{
+		transactFn: func(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			transaction = input
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+	}
+
+	putMsg := service.NewMessage([]byte(`{"id":"foo"}`))
+	putMsg.MetaSetMut("op", "put")
+	delMsg := service.NewMessage([]byte(`{"id":"bar"}`))
+	delMsg.MetaSetMut("op", "delete")
+	delMsg.MetaSetMut("condition", "attribute_exists(#v)")
+
+	require.NoError(t, db.WriteBatch(context.Background(), service.MessageBatch{putMsg, delMsg}))
+	require.NotNil(t, transaction)
+
+	// Placeholders are only sent along with an expression
+	assert.Equal(t, []types.TransactWriteItem{
+		{Put: &types.Put{
+			TableName: aws.String("FooTable"),
+			Item:      map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "foo"}},
+		}},
+		{Delete: &types.Delete{
+			TableName:                aws.String("FooTable"),
+			Key:                      map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "bar"}},
+			ConditionExpression:      aws.String("attribute_exists(#v)"),
+			ExpressionAttributeNames: map[string]string{"#v": "version"},
+		}},
+	}, transaction.TransactItems)
+}
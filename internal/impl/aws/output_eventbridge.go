@@ -0,0 +1,346 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+
+	"github.com/redpanda-data/connect/v4/internal/impl/aws/config"
+	"github.com/redpanda-data/connect/v4/internal/retries"
+)
+
+const (
+	// EventBridge Output Fields
+	eboFieldEventBus   = "event_bus"
+	eboFieldSource     = "source"
+	eboFieldDetailType = "detail_type"
+	eboFieldResources  = "resources"
+	eboFieldBatching   = "batching"
+
+	// The maximum number of entries and the maximum size of a single
+	// PutEvents request.
+	ebMaxEntriesCount = 10
+	ebMaxRequestBytes = 256 * 1024
+)
+
+type eboConfig struct {
+	EventBus   *service.InterpolatedString
+	Source     *service.InterpolatedString
+	DetailType *service.InterpolatedString
+	Resources  []*service.InterpolatedString
+
+	aconf       aws.Config
+	backoffCtor func() backoff.BackOff
+}
+
+func eboConfigFromParsed(pConf *service.ParsedConfig) (conf eboConfig, err error) {
+	if conf.EventBus, err = pConf.FieldInterpolatedString(eboFieldEventBus); err != nil {
+		return
+	}
+	if conf.Source, err = pConf.FieldInterpolatedString(eboFieldSource); err != nil {
+		return
+	}
+	if conf.DetailType, err = pConf.FieldInterpolatedString(eboFieldDetailType); err != nil {
+		return
+	}
+	if conf.Resources, err = pConf.FieldInterpolatedStringList(eboFieldResources); err != nil {
+		return
+	}
+	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
+		return
+	}
+	if conf.backoffCtor, err = retries.CommonRetryBackOffCtorFromParsed(pConf); err != nil {
+		return
+	}
+	return
+}
+
+func eboOutputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.33.0").
+		Categories("Services", "AWS").
+		Summary(`Sends messages as events to an AWS EventBridge event bus.`).
+		Description(`
+Each message is sent as the detail of an event, and must therefore be a valid JSON object. Batches of messages are sent with `+"`PutEvents`"+` requests of up to 10 events and 256KB in size, where events that are rejected individually are retried until the retry attempts are exhausted. Messages that exceed the size limit of an event by themselves are rejected without being sent.
+
+The fields `+"`event_bus`, `source`, `detail_type` and `resources`"+` can be set dynamically using xref:configuration:interpolation.adoc#bloblang-queries[function interpolations], which are resolved individually for each message of a batch.
+
+== Credentials
+
+By default Redpanda Connect will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more in xref:guides:cloud/aws.adoc[].`+service.OutputPerformanceDocs(true, true)).
+		Fields(
+			service.NewInterpolatedStringField(eboFieldEventBus).
+				Description("The name or ARN of the event bus to send events to.").
+				Default("default"),
+			service.NewInterpolatedStringField(eboFieldSource).
+				Description("The source of each event.").
+				Example("com.example.orders"),
+			service.NewInterpolatedStringField(eboFieldDetailType).
+				Description("The detail type of each event.").
+				Example("OrderCreated").
+				Example(`${! meta("event_type") }`),
+			service.NewInterpolatedStringListField(eboFieldResources).
+				Description("A list of ARNs of resources that each event primarily concerns. Resources that resolve to an empty string are omitted.").
+				Default([]any{}).
+				Advanced(),
+			service.NewOutputMaxInFlightField().
+				Description("The maximum number of parallel message batches to have in flight at any given time."),
+			service.NewBatchPolicyField(eboFieldBatching),
+		).
+		Fields(config.SessionFields()...).
+		Fields(retries.CommonRetryBackOffFields(3, "1s", "5s", "30s")...).
+		Example("Order events", "Send order events to a custom event bus with a detail type obtained from the metadata of each message.", `
+output:
+  aws_eventbridge:
+    event_bus: orders
+    source: com.example.orders
+    detail_type: ${! meta("order_status") }
+    batching:
+      count: 10
+      period: 1s
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("aws_eventbridge", eboOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(eboFieldBatching); err != nil {
+				return
+			}
+			var wConf eboConfig
+			if wConf, err = eboConfigFromParsed(conf); err != nil {
+				return
+			}
+			out, err = newEventBridgeWriter(wConf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type eventBridgeAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+type eventBridgeWriter struct {
+	conf eboConfig
+	eb   eventBridgeAPI
+	log  *service.Logger
+}
+
+func newEventBridgeWriter(conf eboConfig, log *service.Logger) (*eventBridgeWriter, error) {
+	return &eventBridgeWriter{
+		conf: conf,
+		log:  log,
+	}, nil
+}
+
+func (e *eventBridgeWriter) Connect(ctx context.Context) error {
+	if e.eb != nil {
+		return nil
+	}
+	e.eb = eventbridge.NewFromConfig(e.conf.aconf)
+	return nil
+}
+
+type ebEntry struct {
+	index int
+	entry types.PutEventsRequestEntry
+}
+
+// ebEntrySize returns the size of an entry as counted towards the size limit of
+// a PutEvents request.
+func ebEntrySize(e types.PutEventsRequestEntry) int {
+	size := len(aws.ToString(e.Source)) + len(aws.ToString(e.DetailType)) + len(aws.ToString(e.Detail))
+	if e.Time != nil {
+		size += 14
+	}
+	for _, r := range e.Resources {
+		size += len(r)
+	}
+	return size
+}
+
+// ebEntryChunks splits entries into chunks that each fit within the limits of
+// a single PutEvents request.
+func ebEntryChunks(entries []ebEntry) (chunks [][]ebEntry) {
+	var chunk []ebEntry
+	var chunkBytes int
+	for _, e := range entries {
+		size := ebEntrySize(e.entry)
+		if len(chunk) > 0 && (len(chunk) == ebMaxEntriesCount || chunkBytes+size > ebMaxRequestBytes) {
+			chunks = append(chunks, chunk)
+			chunk, chunkBytes = nil, 0
+		}
+		chunk = append(chunk, e)
+		chunkBytes += size
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return
+}
+
+func (e *eventBridgeWriter) entryFromMessage(batch service.MessageBatch, i int) (entry types.PutEventsRequestEntry, err error) {
+	var bus, source, detailType string
+	if bus, err = batch.TryInterpolatedString(i, e.conf.EventBus); err != nil {
+		return entry, fmt.Errorf("event bus interpolation error: %w", err)
+	}
+	if source, err = batch.TryInterpolatedString(i, e.conf.Source); err != nil {
+		return entry, fmt.Errorf("source interpolation error: %w", err)
+	}
+	if detailType, err = batch.TryInterpolatedString(i, e.conf.DetailType); err != nil {
+		return entry, fmt.Errorf("detail type interpolation error: %w", err)
+	}
+
+	var resources []string
+	for _, r := range e.conf.Resources {
+		var resource string
+		if resource, err = batch.TryInterpolatedString(i, r); err != nil {
+			return entry, fmt.Errorf("resource interpolation error: %w", err)
+		}
+		if resource != "" {
+			resources = append(resources, resource)
+		}
+	}
+
+	var mBytes []byte
+	if mBytes, err = batch[i].AsBytes(); err != nil {
+		return
+	}
+
+	entry = types.PutEventsRequestEntry{
+		EventBusName: aws.String(bus),
+		Source:       aws.String(source),
+		DetailType:   aws.String(detailType),
+		Detail:       aws.String(string(mBytes)),
+		Resources:    resources,
+	}
+	if size := ebEntrySize(entry); size > ebMaxRequestBytes {
+		err = fmt.Errorf("event size of %v bytes exceeds the limit of %v bytes", size, ebMaxRequestBytes)
+	}
+	return
+}
+
+// putEvents sends a chunk of entries and returns the entries that failed along
+// with their errors.
+func (e *eventBridgeWriter) putEvents(ctx context.Context, chunk []ebEntry) (failed []ebEntry, errs map[int]error) {
+	errs = map[int]error{}
+
+	input := &eventbridge.PutEventsInput{
+		Entries: make([]types.PutEventsRequestEntry, len(chunk)),
+	}
+	for i, c := range chunk {
+		input.Entries[i] = c.entry
+	}
+
+	out, err := e.eb.PutEvents(ctx, input)
+	if err != nil {
+		e.log.Warnf("EventBridge error: %v\n", err)
+		for _, c := range chunk {
+			errs[c.index] = err
+		}
+		return chunk, errs
+	}
+
+	for i, res := range out.Entries {
+		if i >= len(chunk) || res.ErrorCode == nil {
+			continue
+		}
+		err := fmt.Errorf("event failed with code: %v, message: %v", aws.ToString(res.ErrorCode), aws.ToString(res.ErrorMessage))
+		e.log.Debugf("EventBridge entry error: %v\n", err)
+		failed = append(failed, chunk[i])
+		errs[chunk[i].index] = err
+	}
+	return
+}
+
+func (e *eventBridgeWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	if e.eb == nil {
+		return service.ErrNotConnected
+	}
+
+	backOff := e.conf.backoffCtor()
+
+	failedErrs := map[int]error{}
+	var pending []ebEntry
+	for i := range batch {
+		entry, err := e.entryFromMessage(batch, i)
+		if err != nil {
+			failedErrs[i] = err
+			continue
+		}
+		pending = append(pending, ebEntry{index: i, entry: entry})
+	}
+
+	for len(pending) > 0 {
+		var failed []ebEntry
+		for _, chunk := range ebEntryChunks(pending) {
+			chunkFailed, chunkErrs := e.putEvents(ctx, chunk)
+			failed = append(failed, chunkFailed...)
+			for k, v := range chunkErrs {
+				failedErrs[k] = v
+			}
+		}
+		if pending = failed; len(pending) == 0 {
+			break
+		}
+
+		wait := backOff.NextBackOff()
+		if wait == backoff.Stop {
+			break
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		// Only the errors of entries yet to be retried are relevant.
+		for _, p := range pending {
+			delete(failedErrs, p.index)
+		}
+	}
+
+	if len(failedErrs) == 0 {
+		return nil
+	}
+
+	batchErr := service.NewBatchError(batch, fmt.Errorf("failed to send %v events", len(failedErrs)))
+	for i := range batch {
+		if err, exists := failedErrs[i]; exists {
+			batchErr.Failed(i, err)
+		}
+	}
+	return batchErr
+}
+
+func (e *eventBridgeWriter) Close(context.Context) error {
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockEventBridge struct {
+	fn func(*eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error)
+}
+
+func (m *mockEventBridge) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	return m.fn(params)
+}
+
+func testEventBridgeWriter(t testing.TB, yamlStr string) *eventBridgeWriter {
+	t.Helper()
+
+	pConf, err := eboOutputSpec().ParseYAML(yamlStr, nil)
+	require.NoError(t, err)
+
+	conf, err := eboConfigFromParsed(pConf)
+	require.NoError(t, err)
+	conf.backoffCtor = func() backoff.BackOff {
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+	}
+
+	w, err := newEventBridgeWriter(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+	return w
+}
+
+func TestEventBridgeEntryChunks(t *testing.T) {
+	var entries []ebEntry
+	for i := 0; i < 25; i++ {
+		entries = append(entries, ebEntry{index: i, entry: types.PutEventsRequestEntry{Detail: aws.String("{}")}})
+	}
+	chunks := ebEntryChunks(entries)
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], 10)
+	assert.Len(t, chunks[1], 10)
+	assert.Len(t, chunks[2], 5)
+
+	large := aws.String(strings.Repeat("x", 100*1024))
+	chunks = ebEntryChunks([]ebEntry{
+		{index: 0, entry: types.PutEventsRequestEntry{Detail: large}},
+		{index: 1, entry: types.PutEventsRequestEntry{Detail: large}},
+		{index: 2, entry: types.PutEventsRequestEntry{Detail: large}},
+	})
+	require.Len(t, chunks, 2)
+	assert.Len(t, chunks[0], 2)
+	assert.Len(t, chunks[1], 1)
+}
+
+func TestEventBridgeWriteBatch(t *testing.T) {
+	w := testEventBridgeWriter(t, `
+event_bus: ${! meta("bus") }
+source: com.example
+detail_type: ${! meta("type") }
+resources:
+  - arn:aws:foo
+  - ${! @resource.or("") }
+`)
+
+	var sent []types.PutEventsRequestEntry
+	var attempts int
+	w.eb = &mockEventBridge{
+		fn: func(input *eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error) {
+			attempts++
+			out := &eventbridge.PutEventsOutput{}
+			for _, e := range input.Entries {
+				// Reject the second event on its first attempt only
+				if attempts == 1 && *e.Detail == `{"id":2}` {
+					out.FailedEntryCount++
+					out.Entries = append(out.Entries, types.PutEventsResultEntry{
+						ErrorCode:    aws.String("ThrottlingException"),
+						ErrorMessage: aws.String("slow down"),
+					})
+					continue
+				}
+				sent = append(sent, e)
+				out.Entries = append(out.Entries, types.PutEventsResultEntry{EventId: aws.String("foo")})
+			}
+			return out, nil
+		},
+	}
+
+	var batch service.MessageBatch
+	for i := 1; i <= 3; i++ {
+		m := service.NewMessage([]byte(fmt.Sprintf(`{"id":%v}`, i)))
+		m.MetaSetMut("bus", "orders")
+		m.MetaSetMut("type", fmt.Sprintf("type%v", i))
+		if i == 1 {
+			m.MetaSetMut("resource", "arn:aws:bar")
+		}
+		batch = append(batch, m)
+	}
+
+	require.NoError(t, w.WriteBatch(context.Background(), batch))
+	assert.Equal(t, 2, attempts)
+	require.Len(t, sent, 3)
+
+	assert.Equal(t, types.PutEventsRequestEntry{
+		EventBusName: aws.String("orders"),
+		Source:       aws.String("com.example"),
+		DetailType:   aws.String("type1"),
+		Detail:       aws.String(`{"id":1}`),
+		Resources:    []string{"arn:aws:foo", "arn:aws:bar"},
+	}, sent[0])
+	assert.Equal(t, []string{"arn:aws:foo"}, sent[1].Resources)
+	assert.Equal(t, `{"id":2}`, *sent[2].Detail)
+}
+
+func TestEventBridgeWriteBatchFailures(t *testing.T) {
+	w := testEventBridgeWriter(t, `
+source: com.example
+detail_type: foo
+`)
+
+	var attempts int
+	w.eb = &mockEventBridge{
+		fn: func(input *eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error) {
+			attempts++
+			out := &eventbridge.PutEventsOutput{}
+			for _, e := range input.Entries {
+				if *e.Detail == "nope" {
+					out.Entries = append(out.Entries, types.PutEventsResultEntry{
+						ErrorCode:    aws.String("MalformedDetail"),
+						ErrorMessage: aws.String("detail is not valid JSON"),
+					})
+					continue
+				}
+				out.Entries = append(out.Entries, types.PutEventsResultEntry{EventId: aws.String("foo")})
+			}
+			return out, nil
+		},
+	}
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{}`)),
+		service.NewMessage([]byte(`nope`)),
+		service.NewMessage([]byte(strings.Repeat("x", ebMaxRequestBytes))),
+	}
+
+	indexer := batch.Index()
+
+	err := w.WriteBatch(context.Background(), batch)
+	require.Error(t, err)
+	assert.Equal(t, 3, attempts)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 2, batchErr.IndexedErrors())
+
+	var failed []int
+	batchErr.WalkMessagesIndexedBy(indexer, func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.ElementsMatch(t, []int{1, 2}, failed)
+}
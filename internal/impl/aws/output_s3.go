@@ -17,6 +17,7 @@ package aws
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"sort"
@@ -53,8 +54,99 @@ const (
 	s3oFieldKMSKeyID                = "kms_key_id"
 	s3oFieldServerSideEncryption    = "server_side_encryption"
 	s3oFieldBatching                = "batching"
+	s3oFieldRolling                 = "rolling"
+
+	// S3 Output Rolling Fields
+	s3oRollingFieldEnabled   = "enabled"
+	s3oRollingFieldPartition = "partition"
+	s3oRollingFieldCount     = "count"
+	s3oRollingFieldByteSize  = "byte_size"
+	s3oRollingFieldPeriod    = "period"
+	s3oRollingFieldPartSize  = "part_size"
+	s3oRollingFieldSeparator = "separator"
+
+	// The minimum size of all parts but the last of a multipart upload.
+	s3oMinPartSize = 5 * 1024 * 1024
 )
 
+type s3oRollingConfig struct {
+	Enabled   bool
+	Partition *service.InterpolatedString
+	Count     int
+	ByteSize  int64
+	Period    time.Duration
+	PartSize  int64
+	Separator string
+}
+
+func s3oRollingConfigFromParsed(pConf *service.ParsedConfig) (conf s3oRollingConfig, err error) {
+	if conf.Enabled, err = pConf.FieldBool(s3oRollingFieldEnabled); err != nil {
+		return
+	}
+	if conf.Partition, err = pConf.FieldInterpolatedString(s3oRollingFieldPartition); err != nil {
+		return
+	}
+	if conf.Count, err = pConf.FieldInt(s3oRollingFieldCount); err != nil {
+		return
+	}
+	if conf.ByteSize, err = int64Field(pConf, s3oRollingFieldByteSize); err != nil {
+		return
+	}
+	if conf.Period, err = pConf.FieldDuration(s3oRollingFieldPeriod); err != nil {
+		return
+	}
+	if conf.PartSize, err = int64Field(pConf, s3oRollingFieldPartSize); err != nil {
+		return
+	}
+	if conf.Separator, err = pConf.FieldString(s3oRollingFieldSeparator); err != nil {
+		return
+	}
+	if !conf.Enabled {
+		return
+	}
+	if conf.PartSize < s3oMinPartSize {
+		err = fmt.Errorf("rolling part_size must be at least %v bytes", s3oMinPartSize)
+		return
+	}
+	if conf.Count <= 0 && conf.ByteSize <= 0 && conf.Period <= 0 {
+		err = errors.New("at least one of rolling count, byte_size or period must be set")
+		return
+	}
+	return
+}
+
+// checkFinalizable returns an error if objects might never be finalized. Since
+// messages are only acknowledged once their object is finalized, an object
+// without a period must be finalized by a count that can be reached by the
+// messages in flight, as the size of messages isn't known up front. Messages in
+// flight can be spread across any number of partitions, and so a period is
+// required unless the partition is static.
+func (c s3oRollingConfig) checkFinalizable(maxInFlight int, batchPolicy service.BatchPolicy) error {
+	if !c.Enabled || c.Period > 0 {
+		return nil
+	}
+	if c.Count <= 0 {
+		return errors.New("rolling count must be set when rolling period is zero, as otherwise objects might never be finalized")
+	}
+	if c.Partition != nil {
+		if _, static := c.Partition.Static(); !static {
+			return errors.New("rolling period must be set when rolling partition is interpolated, as otherwise objects might never be finalized")
+		}
+	}
+	capacity := maxInFlight
+	if !batchPolicy.IsNoop() {
+		if batchPolicy.Count <= 0 {
+			// The number of messages of each batch isn't bounded by a count.
+			return nil
+		}
+		capacity *= batchPolicy.Count
+	}
+	if c.Count > capacity {
+		return fmt.Errorf("rolling count %v exceeds the %v messages that can be in flight and would never be reached with a rolling period of zero", c.Count, capacity)
+	}
+	return nil
+}
+
 type s3TagPair struct {
 	key   string
 	value *service.InterpolatedString
@@ -78,6 +170,7 @@ type s3oConfig struct {
 	KMSKeyID                string
 	ServerSideEncryption    string
 	UsePathStyle            bool
+	Rolling                 s3oRollingConfig
 
 	aconf aws.Config
 }
@@ -144,6 +237,9 @@ func s3oConfigFromParsed(pConf *service.ParsedConfig) (conf s3oConfig, err error
 	if conf.ServerSideEncryption, err = pConf.FieldString(s3oFieldServerSideEncryption); err != nil {
 		return
 	}
+	if conf.Rolling, err = s3oRollingConfigFromParsed(pConf.Namespace(s3oFieldRolling)); err != nil {
+		return
+	}
 	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
 		return
 	}
@@ -214,7 +310,30 @@ output:
       processors:
         - archive:
             format: json_array
-`+"```"+``+service.OutputPerformanceDocs(true, false)).
+`+"```"+`
+
+== Rolling objects
+
+Batching requires an entire object to be held in memory before it's uploaded, which makes it impractical for producing large objects. Instead, by enabling `+"`rolling`"+` messages are appended to an open object that is uploaded in parts with a https://docs.aws.amazon.com/AmazonS3/latest/userguide/mpuoverview.html[multipart upload^], and which is finalized once it reaches any of the thresholds `+"`rolling.count`"+`, `+"`rolling.byte_size`"+` or `+"`rolling.period`"+`.
+
+Messages are grouped into objects by `+"`rolling.partition`"+`, where an object is open at any given time for each distinct partition, and the key and other attributes of an object are resolved from the first message written to it. Messages are only acknowledged once the object they were written to has been finalized, and therefore `+"`max_in_flight`"+` should be large enough to allow objects to reach their thresholds, or a `+"`rolling.period`"+` should be set to ensure objects are finalized regularly. When `+"`rolling.period`"+` is zero a `+"`rolling.count`"+` no larger than the number of messages that can be in flight must be set and `+"`rolling.partition`"+` must be static, as otherwise objects might never be finalized.
+
+For example, in order to write newline delimited JSON documents into objects partitioned by topic and date, where each object is finalized at 128MB or every ten minutes, we could use the following config:
+
+`+"```yaml"+`
+output:
+  aws_s3:
+    bucket: TODO
+    path: ${! meta("kafka_topic") }/${! now().ts_format("2006-01-02") }/${! uuid_v4() }.jsonl
+    max_in_flight: 256
+    rolling:
+      enabled: true
+      partition: ${! meta("kafka_topic") }/${! now().ts_format("2006-01-02") }
+      byte_size: 134217728
+      period: 10m
+`+"```"+`
+
+The field `+"`content_md5`"+` is ignored when rolling objects.`+service.OutputPerformanceDocs(true, false)).
 		Fields(
 			service.NewStringField(s3oFieldBucket).
 				Description("The bucket to upload messages to."),
@@ -285,6 +404,35 @@ output:
 				Advanced().
 				Default("5s"),
 			service.NewBatchPolicyField(s3oFieldBatching),
+			service.NewObjectField(s3oFieldRolling,
+				service.NewBoolField(s3oRollingFieldEnabled).
+					Description("Whether to append messages to rolling objects rather than uploading each message as an object.").
+					Default(false),
+				service.NewInterpolatedStringField(s3oRollingFieldPartition).
+					Description("An interpolated string resolved for each message that determines which open object it is appended to.").
+					Default("").
+					Example(`${! meta("kafka_topic") }`).
+					Example(`${! meta("kafka_topic") }/${! now().ts_format("2006-01-02") }`),
+				service.NewIntField(s3oRollingFieldCount).
+					Description("The number of messages at which an object is finalized, set to zero to disable.").
+					Default(0),
+				service.NewIntField(s3oRollingFieldByteSize).
+					Description("The size in bytes at which an object is finalized, set to zero to disable.").
+					Default(128*1024*1024),
+				service.NewDurationField(s3oRollingFieldPeriod).
+					Description("The period after an object is opened at which it is finalized, set to zero to disable, in which case `count` must be set and `partition` must be static.").
+					Default("5m"),
+				service.NewIntField(s3oRollingFieldPartSize).
+					Description("The size in bytes of each part uploaded, which is also the amount of data held in memory for each open object. Must be at least 5MiB.").
+					Default(s3oMinPartSize).
+					Advanced(),
+				service.NewStringField(s3oRollingFieldSeparator).
+					Description("A string appended to each message written to an object.").
+					Default("\n").
+					Advanced(),
+			).
+				Description("Append messages to objects that are uploaded in parts and finalized once they reach a size, count or age.").
+				Version("4.33.0"),
 		).
 		Fields(config.SessionFields()...)
 }
@@ -302,6 +450,13 @@ func init() {
 			if wConf, err = s3oConfigFromParsed(conf); err != nil {
 				return
 			}
+			if err = wConf.Rolling.checkFinalizable(maxInFlight, batchPolicy); err != nil {
+				return
+			}
+			if wConf.Rolling.Enabled {
+				out, err = newS3RollingWriter(wConf, mgr)
+				return
+			}
 			out, err = newAmazonS3Writer(wConf, mgr)
 			return
 		})
@@ -310,6 +465,115 @@ func init() {
 	}
 }
 
+// putObjectInput creates the input of an upload of a message from a batch,
+// without the body.
+func (c *s3oConfig) putObjectInput(msg service.MessageBatch, i int) (*s3.PutObjectInput, error) {
+	metadata := map[string]string{}
+	_ = c.Metadata.WalkMut(msg[i], func(k string, v any) error {
+		metadata[k] = bloblang.ValueToString(v)
+		return nil
+	})
+
+	var contentEncoding *string
+	ce, err := msg.TryInterpolatedString(i, c.ContentEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("content encoding interpolation: %w", err)
+	}
+	if ce != "" {
+		contentEncoding = aws.String(ce)
+	}
+	var cacheControl *string
+	if ce, err = msg.TryInterpolatedString(i, c.CacheControl); err != nil {
+		return nil, fmt.Errorf("cache control interpolation: %w", err)
+	}
+	if ce != "" {
+		cacheControl = aws.String(ce)
+	}
+	var contentDisposition *string
+	if ce, err = msg.TryInterpolatedString(i, c.ContentDisposition); err != nil {
+		return nil, fmt.Errorf("content disposition interpolation: %w", err)
+	}
+	if ce != "" {
+		contentDisposition = aws.String(ce)
+	}
+	var contentLanguage *string
+	if ce, err = msg.TryInterpolatedString(i, c.ContentLanguage); err != nil {
+		return nil, fmt.Errorf("content language interpolation: %w", err)
+	}
+	if ce != "" {
+		contentLanguage = aws.String(ce)
+	}
+	var contentMD5 *string
+	if ce, err = msg.TryInterpolatedString(i, c.ContentMD5); err != nil {
+		return nil, fmt.Errorf("content MD5 interpolation: %w", err)
+	}
+	if ce != "" {
+		contentMD5 = aws.String(ce)
+	}
+	var websiteRedirectLocation *string
+	if ce, err = msg.TryInterpolatedString(i, c.WebsiteRedirectLocation); err != nil {
+		return nil, fmt.Errorf("website redirect location interpolation: %w", err)
+	}
+	if ce != "" {
+		websiteRedirectLocation = aws.String(ce)
+	}
+
+	key, err := msg.TryInterpolatedString(i, c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("key interpolation: %w", err)
+	}
+
+	contentType, err := msg.TryInterpolatedString(i, c.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("content type interpolation: %w", err)
+	}
+
+	storageClass, err := msg.TryInterpolatedString(i, c.StorageClass)
+	if err != nil {
+		return nil, fmt.Errorf("storage class interpolation: %w", err)
+	}
+
+	uploadInput := &s3.PutObjectInput{
+		Bucket:                  &c.Bucket,
+		Key:                     aws.String(key),
+		ContentType:             aws.String(contentType),
+		ContentEncoding:         contentEncoding,
+		CacheControl:            cacheControl,
+		ContentDisposition:      contentDisposition,
+		ContentLanguage:         contentLanguage,
+		ContentMD5:              contentMD5,
+		WebsiteRedirectLocation: websiteRedirectLocation,
+		StorageClass:            types.StorageClass(storageClass),
+		Metadata:                metadata,
+	}
+
+	// Prepare tags, escaping keys and values to ensure they're valid query string parameters.
+	if len(c.Tags) > 0 {
+		tags := make([]string, len(c.Tags))
+		for j, pair := range c.Tags {
+			tagStr, err := msg.TryInterpolatedString(i, pair.value)
+			if err != nil {
+				return nil, fmt.Errorf("tag %v interpolation: %w", pair.key, err)
+			}
+			tags[j] = url.QueryEscape(pair.key) + "=" + url.QueryEscape(tagStr)
+		}
+		uploadInput.Tagging = aws.String(strings.Join(tags, "&"))
+	}
+
+	if c.KMSKeyID != "" {
+		uploadInput.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		uploadInput.SSEKMSKeyId = &c.KMSKeyID
+	}
+
+	// NOTE: This overrides the ServerSideEncryption set above. We need this to preserve
+	// backwards compatibility, where it is allowed to only set kms_key_id in the config and
+	// the ServerSideEncryption value of "aws:kms" is implied.
+	if c.ServerSideEncryption != "" {
+		uploadInput.ServerSideEncryption = types.ServerSideEncryption(c.ServerSideEncryption)
+	}
+	return uploadInput, nil
+}
+
 type amazonS3Writer struct {
 	conf     s3oConfig
 	uploader *manager.Uploader
@@ -345,115 +609,16 @@ func (a *amazonS3Writer) WriteBatch(wctx context.Context, msg service.MessageBat
 	defer cancel()
 
 	return msg.WalkWithBatchedErrors(func(i int, m *service.Message) error {
-		metadata := map[string]string{}
-		_ = a.conf.Metadata.WalkMut(m, func(k string, v any) error {
-			metadata[k] = bloblang.ValueToString(v)
-			return nil
-		})
-
-		var contentEncoding *string
-		ce, err := msg.TryInterpolatedString(i, a.conf.ContentEncoding)
-		if err != nil {
-			return fmt.Errorf("content encoding interpolation: %w", err)
-		}
-		if ce != "" {
-			contentEncoding = aws.String(ce)
-		}
-		var cacheControl *string
-		if ce, err = msg.TryInterpolatedString(i, a.conf.CacheControl); err != nil {
-			return fmt.Errorf("cache control interpolation: %w", err)
-		}
-		if ce != "" {
-			cacheControl = aws.String(ce)
-		}
-		var contentDisposition *string
-		if ce, err = msg.TryInterpolatedString(i, a.conf.ContentDisposition); err != nil {
-			return fmt.Errorf("content disposition interpolation: %w", err)
-		}
-		if ce != "" {
-			contentDisposition = aws.String(ce)
-		}
-		var contentLanguage *string
-		if ce, err = msg.TryInterpolatedString(i, a.conf.ContentLanguage); err != nil {
-			return fmt.Errorf("content language interpolation: %w", err)
-		}
-		if ce != "" {
-			contentLanguage = aws.String(ce)
-		}
-		var contentMD5 *string
-		if ce, err = msg.TryInterpolatedString(i, a.conf.ContentMD5); err != nil {
-			return fmt.Errorf("content MD5 interpolation: %w", err)
-		}
-		if ce != "" {
-			contentMD5 = aws.String(ce)
-		}
-		var websiteRedirectLocation *string
-		if ce, err = msg.TryInterpolatedString(i, a.conf.WebsiteRedirectLocation); err != nil {
-			return fmt.Errorf("website redirect location interpolation: %w", err)
-		}
-		if ce != "" {
-			websiteRedirectLocation = aws.String(ce)
-		}
-
-		key, err := msg.TryInterpolatedString(i, a.conf.Path)
+		uploadInput, err := a.conf.putObjectInput(msg, i)
 		if err != nil {
-			return fmt.Errorf("key interpolation: %w", err)
-		}
-
-		contentType, err := msg.TryInterpolatedString(i, a.conf.ContentType)
-		if err != nil {
-			return fmt.Errorf("content type interpolation: %w", err)
-		}
-
-		storageClass, err := msg.TryInterpolatedString(i, a.conf.StorageClass)
-		if err != nil {
-			return fmt.Errorf("storage class interpolation: %w", err)
+			return err
 		}
 
 		mBytes, err := m.AsBytes()
 		if err != nil {
 			return err
 		}
-
-		uploadInput := &s3.PutObjectInput{
-			Bucket:                  &a.conf.Bucket,
-			Key:                     aws.String(key),
-			Body:                    bytes.NewReader(mBytes),
-			ContentType:             aws.String(contentType),
-			ContentEncoding:         contentEncoding,
-			CacheControl:            cacheControl,
-			ContentDisposition:      contentDisposition,
-			ContentLanguage:         contentLanguage,
-			ContentMD5:              contentMD5,
-			WebsiteRedirectLocation: websiteRedirectLocation,
-			StorageClass:            types.StorageClass(storageClass),
-			Metadata:                metadata,
-		}
-
-		// Prepare tags, escaping keys and values to ensure they're valid query string parameters.
-		if len(a.conf.Tags) > 0 {
-			tags := make([]string, len(a.conf.Tags))
-			for j, pair := range a.conf.Tags {
-				tagStr, err := msg.TryInterpolatedString(i, pair.value)
-				if err != nil {
-					return fmt.Errorf("tag %v interpolation: %w", pair.key, err)
-				}
-				tags[j] = url.QueryEscape(pair.key) + "=" + url.QueryEscape(tagStr)
-			}
-			uploadInput.Tagging = aws.String(strings.Join(tags, "&"))
-		}
-
-		if a.conf.KMSKeyID != "" {
-			uploadInput.ServerSideEncryption = types.ServerSideEncryptionAwsKms
-			uploadInput.SSEKMSKeyId = &a.conf.KMSKeyID
-		}
-
-		// NOTE: This overrides the ServerSideEncryption set above. We need this to preserve
-		// backwards compatibility, where it is allowed to only set kms_key_id in the config and
-		// the ServerSideEncryption value of "aws:kms" is implied.
-		if a.conf.ServerSideEncryption != "" {
-			uploadInput.ServerSideEncryption = types.ServerSideEncryption(a.conf.ServerSideEncryption)
-		}
+		uploadInput.Body = bytes.NewReader(mBytes)
 
 		if _, err := a.uploader.Upload(ctx, uploadInput); err != nil {
 			return err
@@ -0,0 +1,374 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+// The maximum number of parts of a multipart upload.
+const s3oMaxParts = 10000
+
+type s3RollingAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// s3RollingObject is an open object that messages are appended to, where the
+// data is buffered until it can be uploaded as a part.
+type s3RollingObject struct {
+	mut       sync.Mutex
+	partition string
+	input     *s3.PutObjectInput
+	uploadID  *string
+	parts     []types.CompletedPart
+	buf       bytes.Buffer
+	size      int64
+	count     int
+	timer     *time.Timer
+	closed    bool
+
+	// Closed once the object has been finalized, at which point err is set if
+	// the object failed to upload.
+	done chan struct{}
+	err  error
+}
+
+type s3RollingWriter struct {
+	conf   s3oConfig
+	client s3RollingAPI
+	log    *service.Logger
+
+	objectsMut sync.Mutex
+	objects    map[string]*s3RollingObject
+}
+
+func newS3RollingWriter(conf s3oConfig, mgr *service.Resources) (*s3RollingWriter, error) {
+	return &s3RollingWriter{
+		conf:    conf,
+		log:     mgr.Logger(),
+		objects: map[string]*s3RollingObject{},
+	}, nil
+}
+
+func (r *s3RollingWriter) Connect(ctx context.Context) error {
+	if r.client != nil {
+		return nil
+	}
+	r.client = s3.NewFromConfig(r.conf.aconf, func(o *s3.Options) {
+		o.UsePathStyle = r.conf.UsePathStyle
+	})
+	return nil
+}
+
+// openObject returns the open object of a partition, opening a new object with
+// the attributes of the message when there isn't one.
+func (r *s3RollingWriter) openObject(partition string, msg service.MessageBatch, i int) (*s3RollingObject, error) {
+	r.objectsMut.Lock()
+	defer r.objectsMut.Unlock()
+
+	if obj, exists := r.objects[partition]; exists {
+		return obj, nil
+	}
+
+	input, err := r.conf.putObjectInput(msg, i)
+	if err != nil {
+		return nil, err
+	}
+	input.ContentMD5 = nil
+
+	obj := &s3RollingObject{
+		partition: partition,
+		input:     input,
+		done:      make(chan struct{}),
+	}
+	if r.conf.Rolling.Period > 0 {
+		obj.timer = time.AfterFunc(r.conf.Rolling.Period, func() {
+			obj.mut.Lock()
+			defer obj.mut.Unlock()
+			if !obj.closed {
+				r.finalize(obj)
+			}
+		})
+	}
+	r.objects[partition] = obj
+	return obj, nil
+}
+
+// appendMessage writes a message to the open object of its partition and
+// returns the object it was written to.
+func (r *s3RollingWriter) appendMessage(ctx context.Context, msg service.MessageBatch, i int) (*s3RollingObject, error) {
+	partition, err := msg.TryInterpolatedString(i, r.conf.Rolling.Partition)
+	if err != nil {
+		return nil, fmt.Errorf("partition interpolation: %w", err)
+	}
+
+	data, err := msg[i].AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		obj, err := r.openObject(partition, msg, i)
+		if err != nil {
+			return nil, err
+		}
+
+		obj.mut.Lock()
+		if obj.closed {
+			// The object was finalized since we obtained it, and therefore a
+			// new object needs to be opened.
+			obj.mut.Unlock()
+			continue
+		}
+
+		obj.buf.Write(data)
+		obj.buf.WriteString(r.conf.Rolling.Separator)
+		obj.size += int64(len(data) + len(r.conf.Rolling.Separator))
+		obj.count++
+
+		if int64(obj.buf.Len()) >= r.conf.Rolling.PartSize {
+			if err := r.uploadPart(ctx, obj); err != nil {
+				r.fail(obj, err)
+			}
+		}
+		if !obj.closed && r.reachedThreshold(obj) {
+			r.finalize(obj)
+		}
+		obj.mut.Unlock()
+		return obj, nil
+	}
+}
+
+func (r *s3RollingWriter) reachedThreshold(obj *s3RollingObject) bool {
+	if r.conf.Rolling.Count > 0 && obj.count >= r.conf.Rolling.Count {
+		return true
+	}
+	if r.conf.Rolling.ByteSize > 0 && obj.size >= r.conf.Rolling.ByteSize {
+		return true
+	}
+	return len(obj.parts) >= s3oMaxParts-1
+}
+
+// uploadPart uploads the buffered data of an object as a part, starting a
+// multipart upload if the object doesn't have one yet. The object lock must be
+// held by the caller.
+func (r *s3RollingWriter) uploadPart(ctx context.Context, obj *s3RollingObject) error {
+	ctx, done := context.WithTimeout(ctx, r.conf.Timeout)
+	defer done()
+
+	if obj.uploadID == nil {
+		out, err := r.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:                  obj.input.Bucket,
+			Key:                     obj.input.Key,
+			ContentType:             obj.input.ContentType,
+			ContentEncoding:         obj.input.ContentEncoding,
+			CacheControl:            obj.input.CacheControl,
+			ContentDisposition:      obj.input.ContentDisposition,
+			ContentLanguage:         obj.input.ContentLanguage,
+			WebsiteRedirectLocation: obj.input.WebsiteRedirectLocation,
+			StorageClass:            obj.input.StorageClass,
+			Metadata:                obj.input.Metadata,
+			Tagging:                 obj.input.Tagging,
+			ServerSideEncryption:    obj.input.ServerSideEncryption,
+			SSEKMSKeyId:             obj.input.SSEKMSKeyId,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create multipart upload: %w", err)
+		}
+		obj.uploadID = out.UploadId
+	}
+
+	partNumber := int32(len(obj.parts) + 1)
+	out, err := r.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     obj.input.Bucket,
+		Key:        obj.input.Key,
+		UploadId:   obj.uploadID,
+		PartNumber: &partNumber,
+		Body:       bytes.NewReader(obj.buf.Bytes()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %v: %w", partNumber, err)
+	}
+	obj.parts = append(obj.parts, types.CompletedPart{
+		ETag:       out.ETag,
+		PartNumber: &partNumber,
+	})
+	obj.buf.Reset()
+	return nil
+}
+
+// complete uploads the remaining data of an object and completes it. Objects
+// that never exceeded the size of a single part are uploaded directly.
+func (r *s3RollingWriter) complete(ctx context.Context, obj *s3RollingObject) error {
+	if obj.uploadID == nil {
+		ctx, done := context.WithTimeout(ctx, r.conf.Timeout)
+		defer done()
+
+		input := *obj.input
+		input.Body = bytes.NewReader(obj.buf.Bytes())
+		_, err := r.client.PutObject(ctx, &input)
+		return err
+	}
+
+	if obj.buf.Len() > 0 {
+		if err := r.uploadPart(ctx, obj); err != nil {
+			return err
+		}
+	}
+
+	ctx, done := context.WithTimeout(ctx, r.conf.Timeout)
+	defer done()
+
+	if _, err := r.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   obj.input.Bucket,
+		Key:      obj.input.Key,
+		UploadId: obj.uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{
+			Parts: obj.parts,
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+// close removes an object from the open objects and resolves all messages
+// written to it. The object lock must be held by the caller.
+func (r *s3RollingWriter) close(obj *s3RollingObject, err error) {
+	obj.closed = true
+	if obj.timer != nil {
+		obj.timer.Stop()
+	}
+
+	r.objectsMut.Lock()
+	if r.objects[obj.partition] == obj {
+		delete(r.objects, obj.partition)
+	}
+	r.objectsMut.Unlock()
+
+	obj.err = err
+	obj.buf = bytes.Buffer{}
+	close(obj.done)
+}
+
+// finalize completes an object. The object lock must be held by the caller.
+func (r *s3RollingWriter) finalize(obj *s3RollingObject) {
+	if err := r.complete(context.Background(), obj); err != nil {
+		r.fail(obj, err)
+		return
+	}
+	r.close(obj, nil)
+}
+
+// fail abandons an object along with any multipart upload it started. The
+// object lock must be held by the caller.
+func (r *s3RollingWriter) fail(obj *s3RollingObject, err error) {
+	r.log.Errorf("Failed to upload object %v: %v", aws.ToString(obj.input.Key), err)
+	if obj.uploadID != nil {
+		ctx, done := context.WithTimeout(context.Background(), r.conf.Timeout)
+		defer done()
+
+		if _, aerr := r.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   obj.input.Bucket,
+			Key:      obj.input.Key,
+			UploadId: obj.uploadID,
+		}); aerr != nil {
+			r.log.Warnf("Failed to abort multipart upload of object %v: %v", aws.ToString(obj.input.Key), aerr)
+		}
+	}
+	r.close(obj, err)
+}
+
+func (r *s3RollingWriter) WriteBatch(ctx context.Context, msg service.MessageBatch) error {
+	if r.client == nil {
+		return service.ErrNotConnected
+	}
+
+	var batchErr *service.BatchError
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = service.NewBatchError(msg, err)
+		}
+		batchErr.Failed(i, err)
+	}
+
+	objects := make([]*s3RollingObject, len(msg))
+	for i := range msg {
+		obj, err := r.appendMessage(ctx, msg, i)
+		if err != nil {
+			failed(i, err)
+			continue
+		}
+		objects[i] = obj
+	}
+
+	// Messages are only acknowledged once the objects they were written to have
+	// been finalized.
+	for i, obj := range objects {
+		if obj == nil {
+			continue
+		}
+		select {
+		case <-obj.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if obj.err != nil {
+			failed(i, obj.err)
+		}
+	}
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (r *s3RollingWriter) Close(ctx context.Context) error {
+	r.objectsMut.Lock()
+	objects := make([]*s3RollingObject, 0, len(r.objects))
+	for _, obj := range r.objects {
+		objects = append(objects, obj)
+	}
+	r.objectsMut.Unlock()
+
+	var err error
+	for _, obj := range objects {
+		obj.mut.Lock()
+		if !obj.closed {
+			r.finalize(obj)
+			if obj.err != nil {
+				err = errors.Join(err, obj.err)
+			}
+		}
+		obj.mut.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,358 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockS3Rolling struct {
+	mut       sync.Mutex
+	objects   map[string]string
+	uploads   map[string][]string
+	aborted   []string
+	partErrFn func(key string, part int32) error
+}
+
+func newMockS3Rolling() *mockS3Rolling {
+	return &mockS3Rolling{
+		objects: map[string]string{},
+		uploads: map[string][]string{},
+	}
+}
+
+func (m *mockS3Rolling) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	b, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[*params.Key] = string(b)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3Rolling) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	id := "upload-" + *params.Key
+	m.uploads[id] = nil
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(id)}, nil
+}
+
+func (m *mockS3Rolling) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.partErrFn != nil {
+		if err := m.partErrFn(*params.Key, *params.PartNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	parts := m.uploads[*params.UploadId]
+	if int(*params.PartNumber) != len(parts)+1 {
+		return nil, fmt.Errorf("unexpected part number %v", *params.PartNumber)
+	}
+	m.uploads[*params.UploadId] = append(parts, string(b))
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("etag-%v", *params.PartNumber))}, nil
+}
+
+func (m *mockS3Rolling) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	parts := m.uploads[*params.UploadId]
+	if len(parts) != len(params.MultipartUpload.Parts) {
+		return nil, errors.New("parts mismatch")
+	}
+	var content string
+	for i, p := range params.MultipartUpload.Parts {
+		if *p.ETag != fmt.Sprintf("etag-%v", i+1) {
+			return nil, errors.New("etag mismatch")
+		}
+		content += parts[i]
+	}
+	delete(m.uploads, *params.UploadId)
+	m.objects[*params.Key] = content
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (m *mockS3Rolling) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	delete(m.uploads, *params.UploadId)
+	m.aborted = append(m.aborted, *params.Key)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func testS3RollingWriter(t testing.TB, client s3RollingAPI, yamlStr string, partSize int64) *s3RollingWriter {
+	t.Helper()
+
+	pConf, err := s3oOutputSpec().ParseYAML(yamlStr, nil)
+	require.NoError(t, err)
+
+	conf, err := s3oConfigFromParsed(pConf)
+	require.NoError(t, err)
+	require.True(t, conf.Rolling.Enabled)
+	if partSize > 0 {
+		conf.Rolling.PartSize = partSize
+	}
+
+	w, err := newS3RollingWriter(conf, service.MockResources())
+	require.NoError(t, err)
+	w.client = client
+	t.Cleanup(func() {
+		_ = w.Close(context.Background())
+	})
+	return w
+}
+
+func testS3RollingBatch(partitions ...string) (batch service.MessageBatch) {
+	for i, p := range partitions {
+		msg := service.NewMessage([]byte(fmt.Sprintf("%v%v", p, i)))
+		msg.MetaSetMut("partition", p)
+		msg.MetaSetMut("index", i)
+		batch = append(batch, msg)
+	}
+	return
+}
+
+func TestS3RollingCountAndPeriod(t *testing.T) {
+	client := newMockS3Rolling()
+	w := testS3RollingWriter(t, client, `
+bucket: foo
+path: ${! @partition }/${! @index }.txt
+rolling:
+  enabled: true
+  partition: ${! @partition }
+  count: 3
+  byte_size: 0
+  period: 100ms
+`, 0)
+
+	start := time.Now()
+	require.NoError(t, w.WriteBatch(context.Background(), testS3RollingBatch("a", "a", "b", "a", "b")))
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*100)
+
+	assert.Equal(t, map[string]string{
+		"a/0.txt": "a0\na1\na3\n",
+		"b/2.txt": "b2\nb4\n",
+	}, client.objects)
+	assert.Empty(t, client.uploads)
+}
+
+func TestS3RollingMultipart(t *testing.T) {
+	client := newMockS3Rolling()
+	w := testS3RollingWriter(t, client, `
+bucket: foo
+path: ${! @partition }.txt
+rolling:
+  enabled: true
+  byte_size: 36
+  period: 0s
+  separator: ","
+`, 10)
+
+	var wg sync.WaitGroup
+	var writeErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		writeErr = w.WriteBatch(context.Background(), testS3RollingBatch("aaaa", "bbbb", "cccc"))
+	}()
+	require.NoError(t, w.WriteBatch(context.Background(), testS3RollingBatch("dddd", "eeee", "ffff")))
+	wg.Wait()
+	require.NoError(t, writeErr)
+
+	require.Len(t, client.objects, 1)
+	for _, content := range client.objects {
+		assert.Len(t, content, 6*6)
+	}
+	assert.Empty(t, client.uploads)
+
+	// Remaining data is uploaded on close
+	go func() {
+		_ = w.WriteBatch(context.Background(), testS3RollingBatch("gggg"))
+	}()
+	require.Eventually(t, func() bool {
+		w.objectsMut.Lock()
+		defer w.objectsMut.Unlock()
+		return len(w.objects) == 1
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, "gggg0,", client.objects["gggg.txt"])
+}
+
+func TestS3RollingPartFailure(t *testing.T) {
+	client := newMockS3Rolling()
+	client.partErrFn = func(key string, part int32) error {
+		if part == 2 {
+			return errors.New("nope")
+		}
+		return nil
+	}
+
+	w := testS3RollingWriter(t, client, `
+bucket: foo
+path: ${! @index }.txt
+rolling:
+  enabled: true
+  count: 10
+  period: 100ms
+`, 10)
+
+	batch := testS3RollingBatch("aaaaaaaaa", "bbbbbbbbb", "c")
+	indexer := batch.Index()
+
+	err := w.WriteBatch(context.Background(), batch)
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 2, batchErr.IndexedErrors())
+
+	var failed []int
+	batchErr.WalkMessagesIndexedBy(indexer, func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{0, 1}, failed)
+	assert.Equal(t, []string{"0.txt"}, client.aborted)
+	assert.Empty(t, client.uploads)
+
+	// The final message was written to a new object
+	assert.Equal(t, map[string]string{"2.txt": "c2\n"}, client.objects)
+}
+
+func TestS3RollingConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`
+bucket: foo
+rolling:
+  enabled: true
+  part_size: 1024
+`,
+		`
+bucket: foo
+rolling:
+  enabled: true
+  byte_size: 0
+  period: 0s
+`,
+	} {
+		pConf, err := s3oOutputSpec().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = s3oConfigFromParsed(pConf)
+		require.Error(t, err, conf)
+	}
+}
+
+func TestS3RollingFinalizable(t *testing.T) {
+	staticPartition, err := service.NewInterpolatedString("foo")
+	require.NoError(t, err)
+
+	dynamicPartition, err := service.NewInterpolatedString(`${! meta("foo") }`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		conf        s3oRollingConfig
+		maxInFlight int
+		batchPolicy service.BatchPolicy
+		errContains string
+	}{
+		{
+			name:        "period",
+			conf:        s3oRollingConfig{Enabled: true, Count: 1000, Period: time.Minute},
+			maxInFlight: 10,
+		},
+		{
+			name:        "reachable count",
+			conf:        s3oRollingConfig{Enabled: true, Count: 10},
+			maxInFlight: 10,
+		},
+		{
+			name:        "reachable count with batching",
+			conf:        s3oRollingConfig{Enabled: true, Count: 100},
+			maxInFlight: 10,
+			batchPolicy: service.BatchPolicy{Count: 10},
+		},
+		{
+			name:        "unreachable count",
+			conf:        s3oRollingConfig{Enabled: true, Count: 11},
+			maxInFlight: 10,
+			errContains: "exceeds the 10 messages",
+		},
+		{
+			name:        "static partition",
+			conf:        s3oRollingConfig{Enabled: true, Count: 10, Partition: staticPartition},
+			maxInFlight: 10,
+		},
+		{
+			name:        "interpolated partition",
+			conf:        s3oRollingConfig{Enabled: true, Count: 10, Partition: dynamicPartition},
+			maxInFlight: 10,
+			errContains: "rolling period must be set",
+		},
+		{
+			name:        "interpolated partition with period",
+			conf:        s3oRollingConfig{Enabled: true, Count: 10, Partition: dynamicPartition, Period: time.Minute},
+			maxInFlight: 10,
+		},
+		{
+			name:        "byte size only",
+			conf:        s3oRollingConfig{Enabled: true, ByteSize: 1024},
+			maxInFlight: 10,
+			errContains: "rolling count must be set",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := test.conf.checkFinalizable(test.maxInFlight, test.batchPolicy)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -47,6 +48,7 @@ type snsoConfig struct {
 	MessageDeduplicationID *service.InterpolatedString
 	Timeout                time.Duration
 	Metadata               *service.MetadataExcludeFilter
+	ExtendedPayload        extendedPayloadConfig
 
 	aconf aws.Config
 }
@@ -71,6 +73,9 @@ func snsoConfigFromParsed(pConf *service.ParsedConfig) (conf snsoConfig, err err
 	if conf.Timeout, err = pConf.FieldDuration(snsoFieldTimeout); err != nil {
 		return
 	}
+	if conf.ExtendedPayload, err = extendedPayloadOutputConfigFromParsed(pConf); err != nil {
+		return
+	}
 	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
 		return
 	}
@@ -84,6 +89,10 @@ func snsoOutputSpec() *service.ConfigSpec {
 		Categories("Services", "AWS").
 		Summary(`Sends messages to an AWS SNS topic.`).
 		Description(`
+== Large payloads
+
+SNS limits the size of messages to 256KB. Larger payloads can be sent by enabling `+"`extended_payload`"+`, in which case payloads that exceed the configured threshold are written to an S3 bucket and a pointer to the object is sent in their place. The format of the pointer is compatible with the https://github.com/awslabs/amazon-sns-java-extended-client-lib[Amazon SNS Extended Client Library^], and pointers delivered to SQS queues with raw message delivery are resolved by the `+"`aws_sqs`"+` input when its own `+"`extended_payload`"+` field is enabled.
+
 == Credentials
 
 By default Redpanda Connect will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more in xref:guides:cloud/aws.adoc[].`+service.OutputPerformanceDocs(true, false)).
@@ -106,6 +115,7 @@ By default Redpanda Connect will use a shared credentials file when connecting t
 				Description("The maximum period to wait on an upload before abandoning it and reattempting.").
 				Advanced().
 				Default("5s"),
+			extendedPayloadOutputField(),
 		).
 		Fields(config.SessionFields()...)
 }
@@ -129,9 +139,10 @@ func init() {
 }
 
 type snsWriter struct {
-	conf snsoConfig
-	sns  *sns.Client
-	log  *service.Logger
+	conf     snsoConfig
+	sns      *sns.Client
+	payloads *s3PayloadStore
+	log      *service.Logger
 }
 
 func newSNSWriter(conf snsoConfig, mgr *service.Resources) (*snsWriter, error) {
@@ -147,6 +158,7 @@ func (a *snsWriter) Connect(ctx context.Context) error {
 		return nil
 	}
 	a.sns = sns.NewFromConfig(a.conf.aconf)
+	a.payloads = newS3PayloadStore(a.conf.ExtendedPayload, a.conf.aconf)
 	return nil
 }
 
@@ -162,6 +174,15 @@ func isValidSNSAttribute(k, v string) bool {
 	return len(snsAttributeKeyInvalidCharRegexp.FindStringIndex(strings.ToLower(k))) == 0
 }
 
+// snsAttributesSize returns the size of message attributes as counted towards
+// the size limit of a message.
+func snsAttributesSize(attrs map[string]types.MessageAttributeValue) (size int) {
+	for k, v := range attrs {
+		size += len(k) + len(aws.ToString(v.DataType)) + len(aws.ToString(v.StringValue)) + len(v.BinaryValue)
+	}
+	return
+}
+
 func (a *snsWriter) getSNSAttributes(msg *service.Message) (snsAttributes, error) {
 	keys := []string{}
 	_ = a.conf.Metadata.WalkMut(msg, func(k string, v any) error {
@@ -226,14 +247,37 @@ func (a *snsWriter) Write(wctx context.Context, msg *service.Message) error {
 	if err != nil {
 		return err
 	}
+
+	content := string(mBytes)
+	var payload *s3PayloadPointer
+	if a.payloads.shouldOffload(mBytes, snsAttributesSize(attrs.attrMap)) {
+		var p s3PayloadPointer
+		if p, content, err = a.payloads.offload(ctx, mBytes); err != nil {
+			return err
+		}
+		payload = &p
+		if attrs.attrMap == nil {
+			attrs.attrMap = map[string]types.MessageAttributeValue{}
+		}
+		attrs.attrMap[epSizeAttribute] = types.MessageAttributeValue{
+			DataType:    aws.String(epSizeAttributeDataType),
+			StringValue: aws.String(strconv.Itoa(len(mBytes))),
+		}
+	}
+
 	message := &sns.PublishInput{
 		TopicArn:               aws.String(a.conf.TopicArn),
-		Message:                aws.String(string(mBytes)),
+		Message:                &content,
 		MessageAttributes:      attrs.attrMap,
 		MessageGroupId:         attrs.groupID,
 		MessageDeduplicationId: attrs.dedupeID,
 	}
-	_, err = a.sns.Publish(ctx, message)
+	if _, err = a.sns.Publish(ctx, message); err != nil && payload != nil {
+		// The payload is offloaded again when the message is retried.
+		if rerr := a.payloads.remove(context.WithoutCancel(wctx), *payload); rerr != nil {
+			a.log.Errorf("Failed to delete offloaded payload of unsent SNS message: %v\n", rerr)
+		}
+	}
 	return err
 }
 
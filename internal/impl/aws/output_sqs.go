@@ -55,9 +55,10 @@ type sqsoConfig struct {
 	MessageDeduplicationID *service.InterpolatedString
 	DelaySeconds           *service.InterpolatedString
 
-	Metadata    *service.MetadataExcludeFilter
-	aconf       aws.Config
-	backoffCtor func() backoff.BackOff
+	Metadata        *service.MetadataExcludeFilter
+	ExtendedPayload extendedPayloadConfig
+	aconf           aws.Config
+	backoffCtor     func() backoff.BackOff
 }
 
 func sqsoConfigFromParsed(pConf *service.ParsedConfig) (conf sqsoConfig, err error) {
@@ -82,6 +83,9 @@ func sqsoConfigFromParsed(pConf *service.ParsedConfig) (conf sqsoConfig, err err
 	if conf.Metadata, err = pConf.FieldMetadataExcludeFilter(sqsoFieldMetadata); err != nil {
 		return
 	}
+	if conf.ExtendedPayload, err = extendedPayloadOutputConfigFromParsed(pConf); err != nil {
+		return
+	}
 	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
 		return
 	}
@@ -102,6 +106,10 @@ Metadata values are sent along with the payload as attributes with the data type
 
 The fields `+"`message_group_id`, `message_deduplication_id` and `delay_seconds`"+` can be set dynamically using xref:configuration:interpolation.adoc#bloblang-queries[function interpolations], which are resolved individually for each message of a batch.
 
+== Large payloads
+
+SQS limits the size of messages to 256KB. Larger payloads can be sent by enabling `+"`extended_payload`"+`, in which case payloads that exceed the configured threshold are written to an S3 bucket and a pointer to the object is sent in their place. The format of the pointer is compatible with the https://github.com/awslabs/amazon-sqs-java-extended-client-lib[Amazon SQS Extended Client Library^], and pointers are resolved by the `+"`aws_sqs`"+` input when its own `+"`extended_payload`"+` field is enabled. Since offloading a payload adds an attribute to the message only nine metadata values are sent as attributes when `+"`extended_payload`"+` is enabled.
+
 == Credentials
 
 By default Redpanda Connect will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more in xref:guides:cloud/aws.adoc[].`+service.OutputPerformanceDocs(true, true)).
@@ -121,6 +129,7 @@ By default Redpanda Connect will use a shared credentials file when connecting t
 			service.NewMetadataExcludeFilterField(snsoFieldMetadata).
 				Description("Specify criteria for which metadata values are sent as headers."),
 			service.NewBatchPolicyField(koFieldBatching),
+			extendedPayloadOutputField(),
 		).
 		Fields(config.SessionFields()...).
 		Fields(retries.CommonRetryBackOffFields(0, "1s", "5s", "30s")...)
@@ -148,8 +157,9 @@ func init() {
 }
 
 type sqsWriter struct {
-	conf sqsoConfig
-	sqs  sqsAPI
+	conf     sqsoConfig
+	sqs      sqsAPI
+	payloads *s3PayloadStore
 
 	closer    sync.Once
 	closeChan chan struct{}
@@ -172,6 +182,7 @@ func (a *sqsWriter) Connect(ctx context.Context) error {
 	}
 
 	a.sqs = sqs.NewFromConfig(a.conf.aconf)
+	a.payloads = newS3PayloadStore(a.conf.ExtendedPayload, a.conf.aconf)
 	return nil
 }
 
@@ -181,6 +192,9 @@ type sqsAttributes struct {
 	dedupeID     *string
 	delaySeconds int32
 	content      *string
+
+	// The payload offloaded to S3 in place of the content, if any.
+	payload *s3PayloadPointer
 }
 
 var sqsAttributeKeyInvalidCharRegexp = regexp.MustCompile(`(^\.)|(\.\.)|(^aws\.)|(^amazon\.)|(\.$)|([^a-z0-9_\-.]+)`)
@@ -189,7 +203,16 @@ func isValidSQSAttribute(k, v string) bool {
 	return len(sqsAttributeKeyInvalidCharRegexp.FindStringIndex(strings.ToLower(k))) == 0
 }
 
-func (a *sqsWriter) getSQSAttributes(batch service.MessageBatch, i int) (sqsAttributes, error) {
+// sqsAttributesSize returns the size of message attributes as counted towards
+// the size limit of a message.
+func sqsAttributesSize(attrs map[string]types.MessageAttributeValue) (size int) {
+	for k, v := range attrs {
+		size += len(k) + len(aws.ToString(v.DataType)) + len(aws.ToString(v.StringValue)) + len(v.BinaryValue)
+	}
+	return
+}
+
+func (a *sqsWriter) getSQSAttributes(ctx context.Context, batch service.MessageBatch, i int) (sqsAttributes, error) {
 	msg := batch[i]
 	keys := []string{}
 	_ = a.conf.Metadata.WalkMut(msg, func(k string, v any) error {
@@ -200,6 +223,12 @@ func (a *sqsWriter) getSQSAttributes(batch service.MessageBatch, i int) (sqsAttr
 		}
 		return nil
 	})
+	// An attribute is reserved for the size of offloaded payloads.
+	maxAttributes := 10
+	if a.payloads != nil {
+		maxAttributes = 9
+	}
+
 	var values map[string]types.MessageAttributeValue
 	if len(keys) > 0 {
 		sort.Strings(keys)
@@ -212,7 +241,7 @@ func (a *sqsWriter) getSQSAttributes(batch service.MessageBatch, i int) (sqsAttr
 				DataType:    &dataType,
 				StringValue: &v,
 			}
-			if i == 9 {
+			if i == maxAttributes-1 {
 				break
 			}
 		}
@@ -254,28 +283,70 @@ func (a *sqsWriter) getSQSAttributes(batch service.MessageBatch, i int) (sqsAttr
 		return sqsAttributes{}, err
 	}
 
+	content := string(msgBytes)
+	var payload *s3PayloadPointer
+	if a.payloads.shouldOffload(msgBytes, sqsAttributesSize(values)) {
+		var p s3PayloadPointer
+		if p, content, err = a.payloads.offload(ctx, msgBytes); err != nil {
+			return sqsAttributes{}, err
+		}
+		payload = &p
+		if values == nil {
+			values = map[string]types.MessageAttributeValue{}
+		}
+		values[epSizeAttribute] = types.MessageAttributeValue{
+			DataType:    aws.String(epSizeAttributeDataType),
+			StringValue: aws.String(strconv.Itoa(len(msgBytes))),
+		}
+	}
+
 	return sqsAttributes{
 		attrMap:      values,
 		groupID:      groupID,
 		dedupeID:     dedupeID,
 		delaySeconds: delaySeconds,
-		content:      aws.String(string(msgBytes)),
+		content:      &content,
+		payload:      payload,
 	}, nil
 }
 
-func (a *sqsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+// removePayloads deletes the offloaded payloads of entries that were not sent,
+// as they are offloaded again when the batch is retried.
+func (a *sqsWriter) removePayloads(ctx context.Context, attrMap map[string]sqsAttributes, entries []types.SendMessageBatchRequestEntry) {
+	ctx = context.WithoutCancel(ctx)
+	for _, e := range entries {
+		p := attrMap[*e.Id].payload
+		if p == nil {
+			continue
+		}
+		if err := a.payloads.remove(ctx, *p); err != nil {
+			a.log.Errorf("Failed to delete offloaded payload of unsent SQS message: %v\n", err)
+		}
+	}
+}
+
+func (a *sqsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) (err error) {
 	if a.sqs == nil {
 		return service.ErrNotConnected
 	}
 
 	backOff := a.conf.backoffCtor()
 
+	input := &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(a.conf.URL),
+	}
 	entries := []types.SendMessageBatchRequestEntry{}
 	attrMap := map[string]sqsAttributes{}
 
+	defer func() {
+		if err != nil {
+			a.removePayloads(ctx, attrMap, append(input.Entries, entries...))
+		}
+	}()
+
 	for i := 0; i < len(batch); i++ {
 		id := strconv.Itoa(i)
-		attrs, err := a.getSQSAttributes(batch, i)
+		attrs, err := a.getSQSAttributes(ctx, batch, i)
 		if err != nil {
 			return err
 		}
@@ -292,19 +363,13 @@ func (a *sqsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch)
 		})
 	}
 
-	input := &sqs.SendMessageBatchInput{
-		QueueUrl: aws.String(a.conf.URL),
-		Entries:  entries,
-	}
-
 	// trim input length to max sqs batch size
 	if len(entries) > sqsMaxRecordsCount {
 		input.Entries, entries = entries[:sqsMaxRecordsCount], entries[sqsMaxRecordsCount:]
 	} else {
-		entries = nil
+		input.Entries, entries = entries, nil
 	}
 
-	var err error
 	for len(input.Entries) > 0 {
 		wait := backOff.NextBackOff()
 
@@ -327,11 +392,11 @@ func (a *sqsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch)
 
 		if unproc := batchResult.Failed; len(unproc) > 0 {
 			input.Entries = []types.SendMessageBatchRequestEntry{}
+			var senderErr error
 			for _, v := range unproc {
-				if v.SenderFault {
-					err = fmt.Errorf("record failed with code: %v, message: %v", *v.Code, *v.Message)
-					a.log.Errorf("SQS record error: %v\n", err)
-					return err
+				if v.SenderFault && senderErr == nil {
+					senderErr = fmt.Errorf("record failed with code: %v, message: %v", *v.Code, *v.Message)
+					a.log.Errorf("SQS record error: %v\n", senderErr)
 				}
 				aMap := attrMap[*v.Id]
 				input.Entries = append(input.Entries, types.SendMessageBatchRequestEntry{
@@ -342,6 +407,9 @@ func (a *sqsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch)
 					MessageDeduplicationId: aMap.dedupeID,
 				})
 			}
+			if senderErr != nil {
+				return senderErr
+			}
 			err = fmt.Errorf("failed to send %v messages", len(unproc))
 		} else {
 			input.Entries = nil
@@ -0,0 +1,258 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/shutdown"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/gofrs/uuid"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+
+	"github.com/redpanda-data/connect/v4/internal/impl/aws/config"
+)
+
+const (
+	// Glue Schema Registry Decode Processor Fields
+	gsrdFieldAvroRawJSON = "avro_raw_json"
+)
+
+type gsrdConfig struct {
+	CodecOptions gsrCodecOptions
+
+	aconf aws.Config
+}
+
+func gsrdConfigFromParsed(pConf *service.ParsedConfig) (conf gsrdConfig, err error) {
+	if conf.CodecOptions.avroRawJSON, err = pConf.FieldBool(gsrdFieldAvroRawJSON); err != nil {
+		return
+	}
+	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
+		return
+	}
+	return
+}
+
+func gsrdProcessorSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.33.0").
+		Categories("Parsing", "Integration").
+		Summary("Automatically decodes and validates messages with schemas from an AWS Glue Schema Registry.").
+		Description(`
+Decodes messages in the https://docs.aws.amazon.com/glue/latest/dg/schema-registry.html[AWS Glue Schema Registry^] wire format, as produced by the serializers of the AWS Glue Schema Registry libraries, by extracting the schema version from the header of each message and obtaining the associated schema from the registry. Schema versions are cached for as long as they're in use. If a message fails to match against the schema then it will remain unchanged and the error can be caught using xref:configuration:error_handling.adoc[error handling methods].
+
+Avro, JSON and Protobuf schemas are supported, as well as messages compressed with zlib. Avro messages are decoded into https://avro.apache.org/docs/current/specification/_print/#json-encoding[Avro JSON^] unless `+"`avro_raw_json`"+` is enabled, and Protobuf messages are decoded into their https://developers.google.com/protocol-buffers/docs/proto3#json[JSON mapping^].
+
+== Credentials
+
+By default Redpanda Connect will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more in xref:guides:cloud/aws.adoc[].`).
+		Fields(
+			service.NewBoolField(gsrdFieldAvroRawJSON).
+				Description("Whether Avro messages should be decoded into normal JSON rather than https://avro.apache.org/docs/current/specification/_print/#json-encoding[Avro JSON^].").
+				Default(false).
+				Advanced(),
+		).
+		Fields(config.SessionFields()...).
+		Example("MSK consumer", "Consume messages from an Amazon MSK cluster with IAM authentication and decode them with their schemas from the Glue Schema Registry.", `
+input:
+  kafka_franz:
+    seed_brokers: [ TODO ]
+    topics: [ orders ]
+    consumer_group: example
+    tls:
+      enabled: true
+    sasl:
+      - mechanism: AWS_MSK_IAM
+        aws:
+          region: us-east-1
+  processors:
+    - aws_glue_schema_registry_decode:
+        region: us-east-1
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"aws_glue_schema_registry_decode", gsrdProcessorSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			pConf, err := gsrdConfigFromParsed(conf)
+			if err != nil {
+				return nil, err
+			}
+			return newGlueSchemaRegistryDecoder(pConf, glue.NewFromConfig(pConf.aconf), mgr.Logger()), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type gsrCachedDecoder struct {
+	lastUsedUnixSeconds int64
+	codec               *gsrCodec
+}
+
+type glueSchemaRegistryDecoder struct {
+	conf   gsrdConfig
+	client glueSchemaRegistryAPI
+
+	schemas    map[uuid.UUID]*gsrCachedDecoder
+	cacheMut   sync.RWMutex
+	requestMut sync.Mutex
+	shutSig    *shutdown.Signaller
+
+	logger *service.Logger
+}
+
+func newGlueSchemaRegistryDecoder(conf gsrdConfig, client glueSchemaRegistryAPI, logger *service.Logger) *glueSchemaRegistryDecoder {
+	s := &glueSchemaRegistryDecoder{
+		conf:    conf,
+		client:  client,
+		schemas: map[uuid.UUID]*gsrCachedDecoder{},
+		shutSig: shutdown.NewSignaller(),
+		logger:  logger,
+	}
+
+	go func() {
+		for {
+			select {
+			case <-time.After(gsrSchemaCachePurgePeriod):
+				s.clearExpired()
+			case <-s.shutSig.SoftStopChan():
+				return
+			}
+		}
+	}()
+	return s
+}
+
+func (s *glueSchemaRegistryDecoder) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	b, err := msg.AsBytes()
+	if err != nil {
+		return nil, errors.New("unable to reference message as bytes")
+	}
+
+	versionID, data, err := gsrDecodeWireFormat(b)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := s.getCodec(versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err = codec.decode(data); err != nil {
+		return nil, err
+	}
+
+	msg.SetBytes(data)
+	return service.MessageBatch{msg}, nil
+}
+
+func (s *glueSchemaRegistryDecoder) Close(ctx context.Context) error {
+	s.shutSig.TriggerHardStop()
+	s.cacheMut.Lock()
+	defer s.cacheMut.Unlock()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for k := range s.schemas {
+		delete(s.schemas, k)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *glueSchemaRegistryDecoder) clearExpired() {
+	// First pass in read only mode to gather candidates
+	s.cacheMut.RLock()
+	targetTime := time.Now().Add(-gsrSchemaStaleAfter).Unix()
+	var targets []uuid.UUID
+	for k, v := range s.schemas {
+		if atomic.LoadInt64(&v.lastUsedUnixSeconds) < targetTime {
+			targets = append(targets, k)
+		}
+	}
+	s.cacheMut.RUnlock()
+
+	// Second pass fully locks schemas and removes stale decoders
+	if len(targets) > 0 {
+		s.cacheMut.Lock()
+		for _, k := range targets {
+			if s.schemas[k].lastUsedUnixSeconds < targetTime {
+				delete(s.schemas, k)
+			}
+		}
+		s.cacheMut.Unlock()
+	}
+}
+
+func (s *glueSchemaRegistryDecoder) getCodec(versionID uuid.UUID) (*gsrCodec, error) {
+	s.cacheMut.RLock()
+	c, ok := s.schemas[versionID]
+	s.cacheMut.RUnlock()
+	if ok {
+		atomic.StoreInt64(&c.lastUsedUnixSeconds, time.Now().Unix())
+		return c.codec, nil
+	}
+
+	s.requestMut.Lock()
+	defer s.requestMut.Unlock()
+
+	// We might've been beaten to making the request, so check once more whilst
+	// within the request lock.
+	s.cacheMut.RLock()
+	c, ok = s.schemas[versionID]
+	s.cacheMut.RUnlock()
+	if ok {
+		atomic.StoreInt64(&c.lastUsedUnixSeconds, time.Now().Unix())
+		return c.codec, nil
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), gsrRequestTimeout)
+	defer done()
+
+	out, err := s.client.GetSchemaVersion(ctx, &glue.GetSchemaVersionInput{
+		SchemaVersionId: aws.String(versionID.String()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema version %v: %w", versionID, err)
+	}
+
+	codec, err := newGSRCodec(out.DataFormat, aws.ToString(out.SchemaDefinition), s.conf.CodecOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheMut.Lock()
+	s.schemas[versionID] = &gsrCachedDecoder{
+		lastUsedUnixSeconds: time.Now().Unix(),
+		codec:               codec,
+	}
+	s.cacheMut.Unlock()
+	return codec, nil
+}
@@ -0,0 +1,464 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/shutdown"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/gofrs/uuid"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+
+	"github.com/redpanda-data/connect/v4/internal/impl/aws/config"
+)
+
+const (
+	// Glue Schema Registry Encode Processor Fields
+	gsreFieldRegistryName     = "registry_name"
+	gsreFieldSchemaName       = "schema_name"
+	gsreFieldSchemaDefinition = "schema_definition"
+	gsreFieldDataFormat       = "data_format"
+	gsreFieldAutoRegister     = "auto_register"
+	gsreFieldCompression      = "compression"
+	gsreFieldRefreshPeriod    = "refresh_period"
+	gsreFieldAvroRawJSON      = "avro_raw_json"
+	gsreFieldProtobufMessage  = "protobuf_message_name"
+)
+
+type gsreConfig struct {
+	RegistryName     string
+	SchemaName       *service.InterpolatedString
+	SchemaDefinition string
+	DataFormat       types.DataFormat
+	AutoRegister     bool
+	Compression      byte
+	RefreshPeriod    time.Duration
+	CodecOptions     gsrCodecOptions
+
+	aconf aws.Config
+}
+
+func gsreConfigFromParsed(pConf *service.ParsedConfig) (conf gsreConfig, err error) {
+	if conf.RegistryName, err = pConf.FieldString(gsreFieldRegistryName); err != nil {
+		return
+	}
+	if conf.SchemaName, err = pConf.FieldInterpolatedString(gsreFieldSchemaName); err != nil {
+		return
+	}
+	if pConf.Contains(gsreFieldSchemaDefinition) {
+		if conf.SchemaDefinition, err = pConf.FieldString(gsreFieldSchemaDefinition); err != nil {
+			return
+		}
+	}
+	var dataFormat string
+	if dataFormat, err = pConf.FieldString(gsreFieldDataFormat); err != nil {
+		return
+	}
+	conf.DataFormat = types.DataFormat(dataFormat)
+	if conf.AutoRegister, err = pConf.FieldBool(gsreFieldAutoRegister); err != nil {
+		return
+	}
+	if conf.AutoRegister && conf.SchemaDefinition == "" {
+		err = fmt.Errorf("field %v must be set when %v is enabled", gsreFieldSchemaDefinition, gsreFieldAutoRegister)
+		return
+	}
+	var compression string
+	if compression, err = pConf.FieldString(gsreFieldCompression); err != nil {
+		return
+	}
+	switch compression {
+	case "none":
+		conf.Compression = gsrCompressionNone
+	case "zlib":
+		conf.Compression = gsrCompressionZlib
+	default:
+		err = fmt.Errorf("compression type %v not supported", compression)
+		return
+	}
+	if conf.RefreshPeriod, err = pConf.FieldDuration(gsreFieldRefreshPeriod); err != nil {
+		return
+	}
+	if conf.CodecOptions.avroRawJSON, err = pConf.FieldBool(gsreFieldAvroRawJSON); err != nil {
+		return
+	}
+	if pConf.Contains(gsreFieldProtobufMessage) {
+		if conf.CodecOptions.protobufMessageName, err = pConf.FieldString(gsreFieldProtobufMessage); err != nil {
+			return
+		}
+	}
+	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
+		return
+	}
+	return
+}
+
+func gsreProcessorSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.33.0").
+		Categories("Parsing", "Integration").
+		Summary("Automatically encodes and validates messages with schemas from an AWS Glue Schema Registry.").
+		Description(`
+Encodes messages in the https://docs.aws.amazon.com/glue/latest/dg/schema-registry.html[AWS Glue Schema Registry^] wire format, which is compatible with the serializers of the AWS Glue Schema Registry libraries and therefore allows producing data to Amazon MSK and Kinesis Data Streams consumers that use them. Each encoded message consists of a header that identifies the schema version used, followed by the serialized data which can optionally be compressed.
+
+By default messages are encoded with the latest version of the target schema, which is polled from the registry periodically. Alternatively, a `+"`schema_definition`"+` can be provided in which case messages are encoded with the version of the schema that matches the definition, and when `+"`auto_register`"+` is enabled the definition is registered as a new version, or a new schema, when it does not exist yet.
+
+If a message fails to encode under the schema then it will remain unchanged and the error can be caught using xref:configuration:error_handling.adoc[error handling methods].
+
+Avro, JSON and Protobuf schemas are supported. Documents are expected as https://avro.apache.org/docs/current/specification/_print/#json-encoding[Avro JSON^] when encoding with Avro schemas unless `+"`avro_raw_json`"+` is enabled, and as JSON documents that conform to the https://developers.google.com/protocol-buffers/docs/proto3#json[JSON mapping^] of the target message type when encoding with Protobuf schemas.
+
+== Credentials
+
+By default Redpanda Connect will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more in xref:guides:cloud/aws.adoc[].`).
+		Fields(
+			service.NewStringField(gsreFieldRegistryName).
+				Description("The name of the registry containing the schema.").
+				Default("default-registry"),
+			service.NewInterpolatedStringField(gsreFieldSchemaName).
+				Description("The name of the schema to encode messages with.").
+				Example("foo").
+				Example(`${! meta("kafka_topic") }`),
+			service.NewStringField(gsreFieldSchemaDefinition).
+				Description("An optional schema definition to encode messages with, in which case the version of the schema matching this definition is used rather than the latest version.").
+				Optional(),
+			service.NewStringEnumField(gsreFieldDataFormat, string(types.DataFormatAvro), string(types.DataFormatJson), string(types.DataFormatProtobuf)).
+				Description("The data format of the `schema_definition`, used when registering it.").
+				Default(string(types.DataFormatAvro)),
+			service.NewBoolField(gsreFieldAutoRegister).
+				Description("Whether to register the `schema_definition` when it does not exist within the registry. If the schema itself does not exist it is created with the default compatibility mode of the registry.").
+				Default(false),
+			service.NewStringEnumField(gsreFieldCompression, "none", "zlib").
+				Description("The compression to apply to serialized data.").
+				Default("none"),
+			service.NewDurationField(gsreFieldRefreshPeriod).
+				Description("The period after which the latest version of a schema is refreshed by polling the registry.").
+				Default("10m").
+				Advanced(),
+			service.NewBoolField(gsreFieldAvroRawJSON).
+				Description("Whether messages encoded in Avro format should be parsed as normal JSON rather than https://avro.apache.org/docs/current/specification/_print/#json-encoding[Avro JSON^].").
+				Default(false).
+				Advanced(),
+			service.NewStringField(gsreFieldProtobufMessage).
+				Description("The fully qualified name of the message type to encode messages as when using Protobuf schemas. By default the first message type defined by a schema is used.").
+				Example("example.v1.Order").
+				Optional().
+				Advanced(),
+		).
+		Fields(config.SessionFields()...)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"aws_glue_schema_registry_encode", gsreProcessorSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			pConf, err := gsreConfigFromParsed(conf)
+			if err != nil {
+				return nil, err
+			}
+			return newGlueSchemaRegistryEncoder(pConf, glue.NewFromConfig(pConf.aconf), mgr.Logger()), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type gsrCachedEncoder struct {
+	lastUsedUnixSeconds    int64
+	lastUpdatedUnixSeconds int64
+	versionID              uuid.UUID
+	codec                  *gsrCodec
+}
+
+type glueSchemaRegistryEncoder struct {
+	conf   gsreConfig
+	client glueSchemaRegistryAPI
+
+	schemas    map[string]*gsrCachedEncoder
+	cacheMut   sync.RWMutex
+	requestMut sync.Mutex
+	shutSig    *shutdown.Signaller
+
+	logger *service.Logger
+	nowFn  func() time.Time
+}
+
+func newGlueSchemaRegistryEncoder(conf gsreConfig, client glueSchemaRegistryAPI, logger *service.Logger) *glueSchemaRegistryEncoder {
+	s := &glueSchemaRegistryEncoder{
+		conf:    conf,
+		client:  client,
+		schemas: map[string]*gsrCachedEncoder{},
+		shutSig: shutdown.NewSignaller(),
+		logger:  logger,
+		nowFn:   time.Now,
+	}
+
+	refreshTicker := conf.RefreshPeriod / 10
+	if refreshTicker < time.Second {
+		refreshTicker = time.Second
+	}
+	go func() {
+		for {
+			select {
+			case <-time.After(refreshTicker):
+				s.refreshEncoders()
+			case <-s.shutSig.SoftStopChan():
+				return
+			}
+		}
+	}()
+	return s
+}
+
+func (s *glueSchemaRegistryEncoder) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	batch = batch.Copy()
+	for i, msg := range batch {
+		schemaName, err := batch.TryInterpolatedString(i, s.conf.SchemaName)
+		if err != nil {
+			s.logger.Errorf("Schema name interpolation error: %v", err)
+			msg.SetError(fmt.Errorf("schema name interpolation error: %w", err))
+			continue
+		}
+
+		encoder, err := s.getEncoder(schemaName)
+		if err != nil {
+			msg.SetError(err)
+			continue
+		}
+
+		b, err := msg.AsBytes()
+		if err != nil {
+			msg.SetError(errors.New("unable to reference message as bytes"))
+			continue
+		}
+
+		if b, err = encoder.codec.encode(b); err != nil {
+			msg.SetError(err)
+			continue
+		}
+
+		if b, err = gsrEncodeWireFormat(encoder.versionID, s.conf.Compression, b); err != nil {
+			msg.SetError(err)
+			continue
+		}
+		msg.SetBytes(b)
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (s *glueSchemaRegistryEncoder) Close(ctx context.Context) error {
+	s.shutSig.TriggerHardStop()
+	s.cacheMut.Lock()
+	defer s.cacheMut.Unlock()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for k := range s.schemas {
+		delete(s.schemas, k)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *glueSchemaRegistryEncoder) refreshEncoders() {
+	// First pass in read only mode to gather purge candidates and refresh
+	// candidates
+	s.cacheMut.RLock()
+	purgeTargetTime := s.nowFn().Add(-gsrSchemaStaleAfter).Unix()
+	updateTargetTime := s.nowFn().Add(-s.conf.RefreshPeriod).Unix()
+	var purgeTargets, refreshTargets []string
+	for k, v := range s.schemas {
+		if atomic.LoadInt64(&v.lastUsedUnixSeconds) < purgeTargetTime {
+			purgeTargets = append(purgeTargets, k)
+		} else if s.conf.SchemaDefinition == "" && atomic.LoadInt64(&v.lastUpdatedUnixSeconds) < updateTargetTime {
+			// Versions that match a definition never change and therefore
+			// only latest versions are refreshed.
+			refreshTargets = append(refreshTargets, k)
+		}
+	}
+	s.cacheMut.RUnlock()
+
+	// Second pass fully locks schemas and removes stale encoders
+	if len(purgeTargets) > 0 {
+		s.cacheMut.Lock()
+		for _, k := range purgeTargets {
+			if s.schemas[k].lastUsedUnixSeconds < purgeTargetTime {
+				delete(s.schemas, k)
+			}
+		}
+		s.cacheMut.Unlock()
+	}
+
+	// Each refresh target gets updated passively
+	if len(refreshTargets) > 0 {
+		s.requestMut.Lock()
+		for _, k := range refreshTargets {
+			encoder, err := s.fetchEncoder(k)
+			if err != nil {
+				s.logger.Errorf("Failed to refresh schema '%v': %v", k, err)
+				continue
+			}
+			s.cacheMut.Lock()
+			if existing, exists := s.schemas[k]; exists {
+				encoder.lastUsedUnixSeconds = atomic.LoadInt64(&existing.lastUsedUnixSeconds)
+			}
+			s.schemas[k] = encoder
+			s.cacheMut.Unlock()
+		}
+		s.requestMut.Unlock()
+	}
+}
+
+func (s *glueSchemaRegistryEncoder) schemaID(schemaName string) *types.SchemaId {
+	return &types.SchemaId{
+		RegistryName: aws.String(s.conf.RegistryName),
+		SchemaName:   aws.String(schemaName),
+	}
+}
+
+// registerSchemaVersion registers the configured schema definition as a new
+// version of a schema, creating the schema when it does not exist.
+func (s *glueSchemaRegistryEncoder) registerSchemaVersion(ctx context.Context, schemaName string) (string, error) {
+	var versionID string
+
+	out, err := s.client.RegisterSchemaVersion(ctx, &glue.RegisterSchemaVersionInput{
+		SchemaId:         s.schemaID(schemaName),
+		SchemaDefinition: aws.String(s.conf.SchemaDefinition),
+	})
+	if err == nil {
+		versionID = aws.ToString(out.SchemaVersionId)
+	} else {
+		var nfErr *types.EntityNotFoundException
+		if !errors.As(err, &nfErr) {
+			return "", fmt.Errorf("failed to register schema version: %w", err)
+		}
+
+		s.logger.Infof("Creating schema '%v' within registry '%v'", schemaName, s.conf.RegistryName)
+		cOut, err := s.client.CreateSchema(ctx, &glue.CreateSchemaInput{
+			RegistryId:       &types.RegistryId{RegistryName: aws.String(s.conf.RegistryName)},
+			SchemaName:       aws.String(schemaName),
+			DataFormat:       s.conf.DataFormat,
+			SchemaDefinition: aws.String(s.conf.SchemaDefinition),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create schema: %w", err)
+		}
+		versionID = aws.ToString(cOut.SchemaVersionId)
+	}
+
+	if err := gsrWaitForSchemaVersion(ctx, s.client, versionID); err != nil {
+		return "", err
+	}
+	return versionID, nil
+}
+
+func (s *glueSchemaRegistryEncoder) fetchEncoder(schemaName string) (*gsrCachedEncoder, error) {
+	ctx, done := context.WithTimeout(context.Background(), gsrRequestTimeout)
+	defer done()
+
+	var versionIDStr, definition string
+	var dataFormat types.DataFormat
+	if s.conf.SchemaDefinition == "" {
+		out, err := s.client.GetSchemaVersion(ctx, &glue.GetSchemaVersionInput{
+			SchemaId:            s.schemaID(schemaName),
+			SchemaVersionNumber: &types.SchemaVersionNumber{LatestVersion: true},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest schema version: %w", err)
+		}
+		versionIDStr, definition, dataFormat = aws.ToString(out.SchemaVersionId), aws.ToString(out.SchemaDefinition), out.DataFormat
+	} else {
+		definition, dataFormat = s.conf.SchemaDefinition, s.conf.DataFormat
+
+		out, err := s.client.GetSchemaByDefinition(ctx, &glue.GetSchemaByDefinitionInput{
+			SchemaId:         s.schemaID(schemaName),
+			SchemaDefinition: aws.String(s.conf.SchemaDefinition),
+		})
+		if err == nil {
+			versionIDStr, dataFormat = aws.ToString(out.SchemaVersionId), out.DataFormat
+		} else {
+			var nfErr *types.EntityNotFoundException
+			if !s.conf.AutoRegister || !errors.As(err, &nfErr) {
+				return nil, fmt.Errorf("failed to get schema version by definition: %w", err)
+			}
+			if versionIDStr, err = s.registerSchemaVersion(ctx, schemaName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	versionID, err := uuid.FromString(versionIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema version id: %w", err)
+	}
+
+	codec, err := newGSRCodec(dataFormat, definition, s.conf.CodecOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Tracef("Loaded new codec for schema %v: %v", schemaName, versionIDStr)
+	now := s.nowFn().Unix()
+	return &gsrCachedEncoder{
+		lastUsedUnixSeconds:    now,
+		lastUpdatedUnixSeconds: now,
+		versionID:              versionID,
+		codec:                  codec,
+	}, nil
+}
+
+func (s *glueSchemaRegistryEncoder) getEncoder(schemaName string) (*gsrCachedEncoder, error) {
+	s.cacheMut.RLock()
+	c, ok := s.schemas[schemaName]
+	s.cacheMut.RUnlock()
+	if ok {
+		atomic.StoreInt64(&c.lastUsedUnixSeconds, s.nowFn().Unix())
+		return c, nil
+	}
+
+	s.requestMut.Lock()
+	defer s.requestMut.Unlock()
+
+	// We might've been beaten to making the request, so check once more whilst
+	// within the request lock.
+	s.cacheMut.RLock()
+	c, ok = s.schemas[schemaName]
+	s.cacheMut.RUnlock()
+	if ok {
+		atomic.StoreInt64(&c.lastUsedUnixSeconds, s.nowFn().Unix())
+		return c, nil
+	}
+
+	c, err := s.fetchEncoder(schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheMut.Lock()
+	s.schemas[schemaName] = c
+	s.cacheMut.Unlock()
+	return c, nil
+}
@@ -37,6 +37,7 @@ type bigQuerySelectInputConfig struct {
 	queryPriority   bigquery.QueryPriority
 	jobLabels       map[string]string
 	credentialsJSON string
+	storageRead     bqStorageReadConfig
 }
 
 func bigQuerySelectInputConfigFromParsed(inConf *service.ParsedConfig) (conf bigQuerySelectInputConfig, err error) {
@@ -93,6 +94,14 @@ func bigQuerySelectInputConfigFromParsed(inConf *service.ParsedConfig) (conf big
 		return
 	}
 
+	if conf.storageRead, err = bqStorageReadConfigFromParsed(inConf.Namespace("storage_read_api")); err != nil {
+		return
+	}
+	if conf.storageRead.enabled && (conf.argsMapping != nil || queryParts.prefix != "" || queryParts.suffix != "") {
+		err = errors.New("the fields args_mapping, prefix and suffix cannot be used with the storage read api")
+		return
+	}
+
 	return
 }
 
@@ -102,7 +111,13 @@ func newBigQuerySelectInputConfig() *service.ConfigSpec {
 		Version("3.63.0").
 		Categories("Services", "GCP").
 		Summary("Executes a `SELECT` query against BigQuery and creates a message for each row received.").
-		Description(`Once the rows from the query are exhausted, this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a xref:components:inputs/sequence.adoc[sequence] to execute).`).
+		Description(`Once the rows from the query are exhausted, this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a xref:components:inputs/sequence.adoc[sequence] to execute).
+
+== Storage Read API
+
+By default rows are obtained by running a query job and paging through its results. Alternatively, when `+"`storage_read_api.enabled`"+` is set to `+"`true`"+` the table is read directly with the https://cloud.google.com/bigquery/docs/reference/storage[BigQuery Storage Read API^], which is much faster and cheaper for exporting large tables. A read session is created that reads the `+"`columns`"+` of the table, where each column must be a plain column name, and only rows matching the `+"`where`"+` field are read, which must be a row restriction without placeholders such as `+"`"+`state = "WA" AND year > 2000`+"`"+`.
+
+The session is split into a number of streams up to `+"`storage_read_api.max_streams`"+`, which are read in parallel, and therefore rows are not emitted in any particular order. Reads of a stream that fail are resumed from the last row received. The fields `+"`args_mapping`"+`, `+"`prefix`"+` and `+"`suffix`"+` cannot be used with the Storage Read API, and the fields `+"`job_labels`"+` and `+"`priority`"+` only apply to query jobs.`).
 		Field(service.NewStringField("project").Description("GCP project where the query job will execute.")).
 		Field(service.NewStringField("credentials_json").
 			Description("An optional field to set Google Service Account Credentials json.").
@@ -129,6 +144,19 @@ func newBigQuerySelectInputConfig() *service.ConfigSpec {
 		Field(service.NewStringField("suffix").
 			Description("An optional suffix to append to the select query.").
 			Optional()).
+		Field(service.NewObjectField("storage_read_api",
+			service.NewBoolField("enabled").
+				Description("Whether to read the table with the Storage Read API rather than a query job.").
+				Default(false),
+			service.NewStringEnumField("format", bqReadFormatAvro, bqReadFormatArrow).
+				Description("The format in which rows are transferred from BigQuery.").
+				Default(bqReadFormatAvro),
+			service.NewIntField("max_streams").
+				Description("The maximum number of streams to read in parallel. BigQuery might create fewer streams than requested depending on the size of the table.").
+				Default(4),
+		).
+			Description("Read the table with the BigQuery Storage Read API.").
+			Version("4.33.0")).
 		Example("Word counts",
 			`
 Here we query the public corpus of Shakespeare's works to generate a stream of the top 10 words that are 3 or more characters long:`,
@@ -163,6 +191,11 @@ type bigQuerySelectInput struct {
 	// The indirection provided by the `bigqueryIterator` interface allows test
 	// code to conveniently create mock iterators
 	iterator bigqueryIterator
+
+	// Used instead of client and iterator when reading with the Storage Read
+	// API.
+	storageClient bqStorageReadClient
+	storage       *bigQueryStorageReader
 }
 
 func newBigQuerySelectInput(inConf *service.ParsedConfig, logger *service.Logger) (*bigQuerySelectInput, error) {
@@ -181,6 +214,10 @@ func newBigQuerySelectInput(inConf *service.ParsedConfig, logger *service.Logger
 func (inp *bigQuerySelectInput) Connect(ctx context.Context) error {
 	jobctx, _ := inp.shutdownSig.SoftStopCtx(context.Background())
 
+	if inp.config.storageRead.enabled {
+		return inp.connectStorage(jobctx)
+	}
+
 	if inp.client == nil {
 		var err error
 		var opt []option.ClientOption
@@ -228,7 +265,43 @@ func (inp *bigQuerySelectInput) Connect(ctx context.Context) error {
 	return nil
 }
 
+func (inp *bigQuerySelectInput) connectStorage(ctx context.Context) error {
+	if inp.storageClient == nil {
+		opt, err := getClientOptionWithCredential(inp.config.credentialsJSON, nil)
+		if err != nil {
+			return err
+		}
+
+		if inp.storageClient, err = newBQStorageReadClient(ctx, opt...); err != nil {
+			return fmt.Errorf("failed to create bigquery storage read client: %w", err)
+		}
+	}
+
+	storage, err := newBigQueryStorageReader(ctx, inp.storageClient, inp.config, inp.logger)
+	if err != nil {
+		return err
+	}
+
+	inp.storage = storage
+	return nil
+}
+
 func (inp *bigQuerySelectInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	if inp.config.storageRead.enabled {
+		if inp.storage == nil {
+			return nil, nil, fmt.Errorf("read session is not set: %w", service.ErrNotConnected)
+		}
+
+		msg, err := inp.storage.Read(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return msg, func(ctx context.Context, err error) error {
+			return nil
+		}, nil
+	}
+
 	if inp.iterator == nil {
 		return nil, nil, fmt.Errorf("query result iterator is not set: %w", service.ErrNotConnected)
 	}
@@ -259,6 +332,15 @@ func (inp *bigQuerySelectInput) Read(ctx context.Context) (*service.Message, ser
 func (inp *bigQuerySelectInput) Close(ctx context.Context) error {
 	inp.shutdownSig.TriggerHardStop()
 
+	// Streams must be stopped before the client they read from is closed.
+	if inp.storage != nil {
+		inp.storage.Close()
+	}
+
+	if inp.storageClient != nil {
+		return inp.storageClient.Close()
+	}
+
 	if inp.client != nil {
 		return inp.client.Close()
 	}
@@ -0,0 +1,405 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	bqstorage "cloud.google.com/go/bigquery/storage/apiv1"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/api/option"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	bqReadFormatAvro  = "AVRO"
+	bqReadFormatArrow = "ARROW"
+)
+
+type bqStorageReadConfig struct {
+	enabled    bool
+	format     string
+	maxStreams int
+}
+
+func bqStorageReadConfigFromParsed(conf *service.ParsedConfig) (sconf bqStorageReadConfig, err error) {
+	if sconf.enabled, err = conf.FieldBool("enabled"); err != nil {
+		return
+	}
+	if sconf.format, err = conf.FieldString("format"); err != nil {
+		return
+	}
+	if sconf.maxStreams, err = conf.FieldInt("max_streams"); err != nil {
+		return
+	}
+	if sconf.maxStreams < 1 {
+		err = fmt.Errorf("max_streams must be at least 1, got %v", sconf.maxStreams)
+	}
+	return
+}
+
+// bqReadSessionTable converts a table name of the form `project.dataset.table`
+// or `dataset.table` into the resource name expected by the Storage Read API.
+func bqReadSessionTable(project, table string) (string, error) {
+	parts := strings.Split(strings.Trim(table, "`"), ".")
+	switch len(parts) {
+	case 2:
+		parts = append([]string{project}, parts...)
+	case 3:
+	default:
+		return "", fmt.Errorf("table %q must be of the form project.dataset.table or dataset.table", table)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return "", fmt.Errorf("table %q must be of the form project.dataset.table or dataset.table", table)
+		}
+	}
+	return fmt.Sprintf("projects/%v/datasets/%v/tables/%v", parts[0], parts[1], parts[2]), nil
+}
+
+//------------------------------------------------------------------------------
+
+type bqReadRowsStream interface {
+	Recv() (*storagepb.ReadRowsResponse, error)
+}
+
+// bqStorageReadClient is the subset of the Storage Read API used by the
+// input, which allows tests to provide fake streams.
+type bqStorageReadClient interface {
+	CreateReadSession(ctx context.Context, req *storagepb.CreateReadSessionRequest) (*storagepb.ReadSession, error)
+	ReadRows(ctx context.Context, req *storagepb.ReadRowsRequest) (bqReadRowsStream, error)
+	Close() error
+}
+
+type wrappedBQStorageReadClient struct {
+	wrapped *bqstorage.BigQueryReadClient
+}
+
+func newBQStorageReadClient(ctx context.Context, opts ...option.ClientOption) (bqStorageReadClient, error) {
+	client, err := bqstorage.NewBigQueryReadClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedBQStorageReadClient{wrapped: client}, nil
+}
+
+func (c *wrappedBQStorageReadClient) CreateReadSession(ctx context.Context, req *storagepb.CreateReadSessionRequest) (*storagepb.ReadSession, error) {
+	return c.wrapped.CreateReadSession(ctx, req)
+}
+
+func (c *wrappedBQStorageReadClient) ReadRows(ctx context.Context, req *storagepb.ReadRowsRequest) (bqReadRowsStream, error) {
+	return c.wrapped.ReadRows(ctx, req)
+}
+
+func (c *wrappedBQStorageReadClient) Close() error {
+	return c.wrapped.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// bqRowDecoder decodes the rows of a read response into JSON documents.
+type bqRowDecoder func(resp *storagepb.ReadRowsResponse) ([][]byte, error)
+
+func newBQRowDecoder(session *storagepb.ReadSession) (bqRowDecoder, error) {
+	switch session.GetDataFormat() {
+	case storagepb.DataFormat_AVRO:
+		return newBQAvroRowDecoder(session.GetAvroSchema().GetSchema())
+	case storagepb.DataFormat_ARROW:
+		return newBQArrowRowDecoder(session.GetArrowSchema().GetSerializedSchema())
+	}
+	return nil, fmt.Errorf("unsupported data format: %v", session.GetDataFormat())
+}
+
+func newBQAvroRowDecoder(schema string) (bqRowDecoder, error) {
+	codec, err := goavro.NewCodecForStandardJSONFull(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %w", err)
+	}
+	return func(resp *storagepb.ReadRowsResponse) ([][]byte, error) {
+		rows := make([][]byte, 0, resp.GetRowCount())
+		remaining := resp.GetAvroRows().GetSerializedBinaryRows()
+		for len(remaining) > 0 {
+			native, rest, err := codec.NativeFromBinary(remaining)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode avro row: %w", err)
+			}
+			remaining = rest
+
+			row, err := codec.TextualFromNative(nil, native)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode avro row as json: %w", err)
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}, nil
+}
+
+func newBQArrowRowDecoder(serializedSchema []byte) (bqRowDecoder, error) {
+	r, err := ipc.NewReader(bytes.NewReader(serializedSchema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arrow schema: %w", err)
+	}
+	schema := r.Schema()
+	r.Release()
+
+	return func(resp *storagepb.ReadRowsResponse) ([][]byte, error) {
+		// Record batches are serialized without the schema message, which the
+		// IPC reader expects first.
+		r, err := ipc.NewReader(
+			io.MultiReader(
+				bytes.NewReader(serializedSchema),
+				bytes.NewReader(resp.GetArrowRecordBatch().GetSerializedRecordBatch()),
+			),
+			ipc.WithSchema(schema),
+			ipc.WithAllocator(memory.DefaultAllocator),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read arrow record batch: %w", err)
+		}
+		defer r.Release()
+
+		rows := make([][]byte, 0, resp.GetRowCount())
+		for r.Next() {
+			rec := r.Record()
+			for i := 0; i < int(rec.NumRows()); i++ {
+				row, err := bqArrowRowToJSON(rec, i)
+				if err != nil {
+					return nil, err
+				}
+				rows = append(rows, row)
+			}
+		}
+		if err := r.Err(); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read arrow record batch: %w", err)
+		}
+		return rows, nil
+	}, nil
+}
+
+func bqArrowRowToJSON(rec arrow.Record, i int) ([]byte, error) {
+	row := make(map[string]any, rec.NumCols())
+	for c, field := range rec.Schema().Fields() {
+		row[field.Name] = rec.Column(c).GetOneForMarshal(i)
+	}
+	b, err := json.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arrow row to json: %w", err)
+	}
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+// bigQueryStorageReader reads all streams of a read session in parallel and
+// feeds the decoded rows to the input.
+type bigQueryStorageReader struct {
+	log    *service.Logger
+	client bqStorageReadClient
+	decode bqRowDecoder
+
+	rowsChan chan [][]byte
+	pending  [][]byte
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	errMut sync.Mutex
+	err    error
+}
+
+func newBigQueryStorageReader(ctx context.Context, client bqStorageReadClient, conf *bigQuerySelectInputConfig, logger *service.Logger) (*bigQueryStorageReader, error) {
+	table, err := bqReadSessionTable(conf.project, conf.queryParts.table)
+	if err != nil {
+		return nil, err
+	}
+
+	format := storagepb.DataFormat_AVRO
+	if conf.storageRead.format == bqReadFormatArrow {
+		format = storagepb.DataFormat_ARROW
+	}
+
+	session, err := client.CreateReadSession(ctx, &storagepb.CreateReadSessionRequest{
+		Parent: "projects/" + conf.project,
+		ReadSession: &storagepb.ReadSession{
+			Table:      table,
+			DataFormat: format,
+			ReadOptions: &storagepb.ReadSession_TableReadOptions{
+				SelectedFields: conf.queryParts.columns,
+				RowRestriction: conf.queryParts.where,
+			},
+		},
+		MaxStreamCount: int32(conf.storageRead.maxStreams),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create read session: %w", err)
+	}
+
+	r := &bigQueryStorageReader{
+		log:      logger,
+		client:   client,
+		rowsChan: make(chan [][]byte, len(session.GetStreams())),
+	}
+	if len(session.GetStreams()) > 0 {
+		if r.decode, err = newBQRowDecoder(session); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.With("session", session.GetName(), "streams", len(session.GetStreams())).Debug("created bigquery read session")
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	for _, stream := range session.GetStreams() {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.readStream(ctx, stream.GetName())
+		}()
+	}
+	go func() {
+		r.wg.Wait()
+		close(r.rowsChan)
+	}()
+	return r, nil
+}
+
+// readStream reads the rows of a stream until it's exhausted. Reads that fail
+// are resumed from the offset of the last row received.
+func (r *bigQueryStorageReader) readStream(ctx context.Context, name string) {
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Millisecond * 100
+	boff.MaxInterval = time.Second * 10
+	boff.MaxElapsedTime = 0
+
+	var offset int64
+	for {
+		err := r.readStreamFrom(ctx, name, &offset, boff)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		var decodeErr *bqDecodeError
+		if errors.As(err, &decodeErr) {
+			r.errMut.Lock()
+			r.err = errors.Join(r.err, err)
+			r.errMut.Unlock()
+			return
+		}
+
+		wait := boff.NextBackOff()
+		r.log.Warnf("Failed to read stream %v at offset %v, retrying in %v: %v", name, offset, wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type bqDecodeError struct {
+	stream string
+	err    error
+}
+
+func (e *bqDecodeError) Error() string {
+	return fmt.Sprintf("stream %v: %v", e.stream, e.err)
+}
+
+func (e *bqDecodeError) Unwrap() error {
+	return e.err
+}
+
+func (r *bigQueryStorageReader) readStreamFrom(ctx context.Context, name string, offset *int64, boff backoff.BackOff) error {
+	stream, err := r.client.ReadRows(ctx, &storagepb.ReadRowsRequest{
+		ReadStream: name,
+		Offset:     *offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		boff.Reset()
+
+		rows, err := r.decode(resp)
+		if err != nil {
+			return &bqDecodeError{stream: name, err: err}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		select {
+		case r.rowsChan <- rows:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		*offset += int64(len(rows))
+	}
+}
+
+func (r *bigQueryStorageReader) Read(ctx context.Context) (*service.Message, error) {
+	for len(r.pending) == 0 {
+		select {
+		case rows, open := <-r.rowsChan:
+			if !open {
+				r.errMut.Lock()
+				err := r.err
+				r.err = nil
+				r.errMut.Unlock()
+				if err != nil {
+					// The remaining rows of the failed streams are reported
+					// once before the input ends.
+					return nil, err
+				}
+				return nil, service.ErrEndOfInput
+			}
+			r.pending = rows
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	msg := service.NewMessage(r.pending[0])
+	r.pending = r.pending[1:]
+	return msg, nil
+}
+
+// Close stops reading the streams of the session and waits for them to finish.
+func (r *bigQueryStorageReader) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,397 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const testBQAvroSchema = `{
+  "type": "record",
+  "name": "__root__",
+  "fields": [
+    {"name": "word", "type": ["null", "string"]},
+    {"name": "word_count", "type": ["null", "long"]}
+  ]
+}`
+
+type fakeBQStorageReadClient struct {
+	mut      sync.Mutex
+	session  *storagepb.ReadSession
+	req      *storagepb.CreateReadSessionRequest
+	streams  map[string][]*storagepb.ReadRowsResponse
+	failOnce map[string]int
+	offsets  map[string][]int64
+	closed   bool
+}
+
+func (f *fakeBQStorageReadClient) CreateReadSession(ctx context.Context, req *storagepb.CreateReadSessionRequest) (*storagepb.ReadSession, error) {
+	f.req = req
+	return f.session, nil
+}
+
+func (f *fakeBQStorageReadClient) ReadRows(ctx context.Context, req *storagepb.ReadRowsRequest) (bqReadRowsStream, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.offsets[req.ReadStream] = append(f.offsets[req.ReadStream], req.Offset)
+
+	var skipped int64
+	var resps []*storagepb.ReadRowsResponse
+	for _, resp := range f.streams[req.ReadStream] {
+		if skipped < req.Offset {
+			skipped += resp.RowCount
+			continue
+		}
+		resps = append(resps, resp)
+	}
+
+	// Fail the stream after a number of responses the first time it's read
+	var failAfter = -1
+	if n, exists := f.failOnce[req.ReadStream]; exists {
+		failAfter = n
+		delete(f.failOnce, req.ReadStream)
+	}
+	return &fakeBQReadRowsStream{resps: resps, failAfter: failAfter}, nil
+}
+
+func (f *fakeBQStorageReadClient) Close() error {
+	f.closed = true
+	return nil
+}
+
+type fakeBQReadRowsStream struct {
+	resps     []*storagepb.ReadRowsResponse
+	failAfter int
+}
+
+func (f *fakeBQReadRowsStream) Recv() (*storagepb.ReadRowsResponse, error) {
+	if f.failAfter == 0 {
+		return nil, errors.New("stream broken")
+	}
+	f.failAfter--
+	if len(f.resps) == 0 {
+		return nil, io.EOF
+	}
+	resp := f.resps[0]
+	f.resps = f.resps[1:]
+	return resp, nil
+}
+
+func testBQAvroResponse(t testing.TB, rows ...map[string]any) *storagepb.ReadRowsResponse {
+	t.Helper()
+
+	codec, err := goavro.NewCodec(testBQAvroSchema)
+	require.NoError(t, err)
+
+	var buf []byte
+	for _, row := range rows {
+		buf, err = codec.BinaryFromNative(buf, row)
+		require.NoError(t, err)
+	}
+	return &storagepb.ReadRowsResponse{
+		RowCount: int64(len(rows)),
+		Rows: &storagepb.ReadRowsResponse_AvroRows{
+			AvroRows: &storagepb.AvroRows{SerializedBinaryRows: buf},
+		},
+	}
+}
+
+func testBQAvroRow(word string, count int64) map[string]any {
+	return map[string]any{
+		"word":       goavro.Union("string", word),
+		"word_count": goavro.Union("long", count),
+	}
+}
+
+func TestGCPBigQuerySelectInputStorageRead(t *testing.T) {
+	spec := newBigQuerySelectInputConfig()
+
+	parsed, err := spec.ParseYAML(`
+project: job-project
+table: samples.shakespeare
+columns: [ word, word_count ]
+where: word_count > 10
+storage_read_api:
+  enabled: true
+  max_streams: 2
+`, nil)
+	require.NoError(t, err)
+
+	inp, err := newBigQuerySelectInput(parsed, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	client := &fakeBQStorageReadClient{
+		session: &storagepb.ReadSession{
+			Name:       "session",
+			DataFormat: storagepb.DataFormat_AVRO,
+			Schema: &storagepb.ReadSession_AvroSchema{
+				AvroSchema: &storagepb.AvroSchema{Schema: testBQAvroSchema},
+			},
+			Streams: []*storagepb.ReadStream{{Name: "a"}, {Name: "b"}},
+		},
+		streams: map[string][]*storagepb.ReadRowsResponse{
+			"a": {
+				testBQAvroResponse(t, testBQAvroRow("a0", 11), testBQAvroRow("a1", 12)),
+				testBQAvroResponse(t, testBQAvroRow("a2", 13)),
+			},
+			"b": {
+				testBQAvroResponse(t, testBQAvroRow("b0", 21)),
+				testBQAvroResponse(t, map[string]any{"word": goavro.Union("string", "b1"), "word_count": nil}),
+			},
+		},
+		failOnce: map[string]int{"a": 1},
+		offsets:  map[string][]int64{},
+	}
+	inp.storageClient = client
+
+	require.NoError(t, inp.Connect(context.Background()))
+
+	assert.Equal(t, "projects/job-project", client.req.Parent)
+	assert.Equal(t, "projects/job-project/datasets/samples/tables/shakespeare", client.req.ReadSession.Table)
+	assert.Equal(t, []string{"word", "word_count"}, client.req.ReadSession.ReadOptions.SelectedFields)
+	assert.Equal(t, "word_count > 10", client.req.ReadSession.ReadOptions.RowRestriction)
+	assert.Equal(t, int32(2), client.req.MaxStreamCount)
+
+	var rows []string
+	for {
+		msg, ackFn, err := inp.Read(context.Background())
+		if errors.Is(err, service.ErrEndOfInput) {
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, ackFn(context.Background(), nil))
+
+		// Normalise the order of keys
+		v, err := msg.AsStructured()
+		require.NoError(t, err)
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		rows = append(rows, string(b))
+	}
+	sort.Strings(rows)
+
+	assert.Equal(t, []string{
+		`{"word":"a0","word_count":11}`,
+		`{"word":"a1","word_count":12}`,
+		`{"word":"a2","word_count":13}`,
+		`{"word":"b0","word_count":21}`,
+		`{"word":"b1","word_count":null}`,
+	}, rows)
+
+	// The broken stream is resumed after the rows received
+	assert.Equal(t, []int64{0, 2}, client.offsets["a"])
+	assert.Equal(t, []int64{0}, client.offsets["b"])
+
+	require.NoError(t, inp.Close(context.Background()))
+	assert.True(t, client.closed)
+}
+
+func TestGCPBigQuerySelectInputStorageReadDecodeError(t *testing.T) {
+	client := &fakeBQStorageReadClient{
+		session: &storagepb.ReadSession{
+			DataFormat: storagepb.DataFormat_AVRO,
+			Schema: &storagepb.ReadSession_AvroSchema{
+				AvroSchema: &storagepb.AvroSchema{Schema: testBQAvroSchema},
+			},
+			Streams: []*storagepb.ReadStream{{Name: "a"}},
+		},
+		streams: map[string][]*storagepb.ReadRowsResponse{
+			"a": {{
+				RowCount: 1,
+				Rows: &storagepb.ReadRowsResponse_AvroRows{
+					AvroRows: &storagepb.AvroRows{SerializedBinaryRows: []byte{0xff}},
+				},
+			}},
+		},
+		offsets: map[string][]int64{},
+	}
+
+	r, err := newBigQueryStorageReader(context.Background(), client, &bigQuerySelectInputConfig{
+		project:     "foo",
+		queryParts:  &bqQueryParts{table: "foo.bar.baz"},
+		storageRead: bqStorageReadConfig{enabled: true, format: bqReadFormatAvro, maxStreams: 1},
+	}, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	// The decode error is reported once before the input ends
+	_, err = r.Read(context.Background())
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, service.ErrEndOfInput)
+
+	_, err = r.Read(context.Background())
+	require.ErrorIs(t, err, service.ErrEndOfInput)
+}
+
+func TestGCPBigQuerySelectInputStorageReadClose(t *testing.T) {
+	client := &fakeBQStorageReadClient{
+		session: &storagepb.ReadSession{
+			DataFormat: storagepb.DataFormat_AVRO,
+			Schema: &storagepb.ReadSession_AvroSchema{
+				AvroSchema: &storagepb.AvroSchema{Schema: testBQAvroSchema},
+			},
+			Streams: []*storagepb.ReadStream{{Name: "a"}},
+		},
+		streams: map[string][]*storagepb.ReadRowsResponse{
+			"a": {
+				testBQAvroResponse(t, testBQAvroRow("foo", 1)),
+				testBQAvroResponse(t, testBQAvroRow("bar", 2)),
+				testBQAvroResponse(t, testBQAvroRow("baz", 3)),
+			},
+		},
+		offsets: map[string][]int64{},
+	}
+
+	r, err := newBigQueryStorageReader(context.Background(), client, &bigQuerySelectInputConfig{
+		project:     "foo",
+		queryParts:  &bqQueryParts{table: "foo.bar.baz"},
+		storageRead: bqStorageReadConfig{enabled: true, format: bqReadFormatAvro, maxStreams: 1},
+	}, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	// The stream is blocked on rows that are never read
+	closed := make(chan struct{})
+	go func() {
+		r.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second * 10):
+		t.Fatal("timed out waiting for streams to stop")
+	}
+}
+
+func TestGCPBigQueryArrowRowDecoder(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "word", Type: arrow.BinaryTypes.String},
+		{Name: "word_count", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	}, nil)
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	builder.Field(0).(*array.StringBuilder).AppendValues([]string{"foo", "bar"}, nil)
+	builder.Field(1).(*array.Int64Builder).AppendValues([]int64{5, 0}, []bool{true, false})
+	rec := builder.NewRecord()
+	defer rec.Release()
+
+	// The Storage Read API serializes the schema and record batches as
+	// separate IPC messages.
+	var schemaBuf bytes.Buffer
+	w := ipc.NewWriter(&schemaBuf, ipc.WithSchema(schema))
+	require.NoError(t, w.Close())
+
+	var streamBuf bytes.Buffer
+	w = ipc.NewWriter(&streamBuf, ipc.WithSchema(schema))
+	require.NoError(t, w.Write(rec))
+	require.NoError(t, w.Close())
+
+	eosLen := 8
+	serializedSchema := schemaBuf.Bytes()[:schemaBuf.Len()-eosLen]
+	serializedBatch := streamBuf.Bytes()[len(serializedSchema) : streamBuf.Len()-eosLen]
+
+	decode, err := newBQRowDecoder(&storagepb.ReadSession{
+		DataFormat: storagepb.DataFormat_ARROW,
+		Schema: &storagepb.ReadSession_ArrowSchema{
+			ArrowSchema: &storagepb.ArrowSchema{SerializedSchema: serializedSchema},
+		},
+	})
+	require.NoError(t, err)
+
+	rows, err := decode(&storagepb.ReadRowsResponse{
+		RowCount: 2,
+		Rows: &storagepb.ReadRowsResponse_ArrowRecordBatch{
+			ArrowRecordBatch: &storagepb.ArrowRecordBatch{SerializedRecordBatch: serializedBatch},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, `{"word":"foo","word_count":5}`, string(rows[0]))
+	assert.Equal(t, `{"word":"bar","word_count":null}`, string(rows[1]))
+}
+
+func TestGCPBigQueryReadSessionTable(t *testing.T) {
+	for _, test := range []struct {
+		table    string
+		expected string
+	}{
+		{table: "foo.bar.baz", expected: "projects/foo/datasets/bar/tables/baz"},
+		{table: "`foo.bar.baz`", expected: "projects/foo/datasets/bar/tables/baz"},
+		{table: "bar.baz", expected: "projects/job/datasets/bar/tables/baz"},
+		{table: "baz"},
+		{table: "foo..baz"},
+	} {
+		actual, err := bqReadSessionTable("job", test.table)
+		if test.expected == "" {
+			assert.Error(t, err, test.table)
+			continue
+		}
+		require.NoError(t, err, test.table)
+		assert.Equal(t, test.expected, actual, test.table)
+	}
+}
+
+func TestGCPBigQuerySelectInputStorageReadConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`
+storage_read_api:
+  enabled: true
+  max_streams: 0
+`,
+		`
+where: foo = ?
+args_mapping: root = [ 1 ]
+storage_read_api:
+  enabled: true
+`,
+		`
+suffix: LIMIT 10
+storage_read_api:
+  enabled: true
+`,
+	} {
+		parsed, err := newBigQuerySelectInputConfig().ParseYAML(fmt.Sprintf(`
+project: foo
+table: foo.bar.baz
+columns: [ foo ]
+%v`, conf), nil)
+		require.NoError(t, err)
+
+		_, err = newBigQuerySelectInput(parsed, nil)
+		require.Error(t, err, conf)
+	}
+}
@@ -19,9 +19,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"time"
 
+	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/storage"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
@@ -36,14 +38,40 @@ const (
 	csiFieldPrefix          = "prefix"
 	csiFieldCredentialsJSON = "credentials_json"
 	csiFieldDeleteObjects   = "delete_objects"
+	csiFieldPubSub          = "pubsub"
+
+	// Cloud Storage Input Pub/Sub Fields
+	csiPubSubFieldProject                = "project"
+	csiPubSubFieldSubscription           = "subscription"
+	csiPubSubFieldMaxOutstandingMessages = "max_outstanding_messages"
 )
 
+type csiPubSubConfig struct {
+	Project                string
+	Subscription           string
+	MaxOutstandingMessages int
+}
+
+func csiPubSubConfigFromParsed(pConf *service.ParsedConfig) (conf csiPubSubConfig, err error) {
+	if conf.Project, err = pConf.FieldString(csiPubSubFieldProject); err != nil {
+		return
+	}
+	if conf.Subscription, err = pConf.FieldString(csiPubSubFieldSubscription); err != nil {
+		return
+	}
+	if conf.MaxOutstandingMessages, err = pConf.FieldInt(csiPubSubFieldMaxOutstandingMessages); err != nil {
+		return
+	}
+	return
+}
+
 type csiConfig struct {
 	Bucket          string
 	Prefix          string
 	CredentialsJSON string
 	DeleteObjects   bool
 	Codec           codec.DeprecatedFallbackCodec
+	PubSub          csiPubSubConfig
 }
 
 func csiConfigFromParsed(pConf *service.ParsedConfig) (conf csiConfig, err error) {
@@ -62,6 +90,9 @@ func csiConfigFromParsed(pConf *service.ParsedConfig) (conf csiConfig, err error
 	if conf.DeleteObjects, err = pConf.FieldBool(csiFieldDeleteObjects); err != nil {
 		return
 	}
+	if conf.PubSub, err = csiPubSubConfigFromParsed(pConf.Namespace(csiFieldPubSub)); err != nil {
+		return
+	}
 	return
 }
 
@@ -88,6 +119,14 @@ This input adds the following metadata fields to each message:
 
 You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
 
+== Streaming objects on upload with Pub/Sub
+
+By default the objects of the bucket are listed once, and the input shuts down once they have all been consumed. A common pattern for continuously consuming objects as they are uploaded is to https://cloud.google.com/storage/docs/pubsub-notifications[configure Pub/Sub notifications^] for the bucket, which publish an event to a topic for each change to an object.
+
+Redpanda Connect is able to follow this pattern when you configure a `+"`pubsub.subscription`"+` subscribed to the notification topic, where it consumes events from the subscription and only downloads the objects that were created, as indicated by events of the type `+"`OBJECT_FINALIZE`"+`. All other events, as well as events for objects of other buckets or without the configured `+"`prefix`"+`, are acknowledged and ignored.
+
+An event is only acknowledged once all messages of its object have been sent onwards, and events of objects that fail to be processed are negatively acknowledged in order to be redelivered. This ensures at-least-once crash resiliency, but also means that objects might be processed multiple times, and therefore it's recommended to set the acknowledgement deadline of the subscription to a value that comfortably exceeds the time it takes to process an object.
+
 === Credentials
 
 By default Redpanda Connect will use a shared credentials file when connecting to GCP services. You can find out more in xref:guides:cloud/gcp.adoc[].`).
@@ -108,6 +147,20 @@ By default Redpanda Connect will use a shared credentials file when connecting t
 				Description("Whether to delete downloaded objects from the bucket once they are processed.").
 				Advanced().
 				Default(false),
+			service.NewObjectField(csiFieldPubSub,
+				service.NewStringField(csiPubSubFieldProject).
+					Description("The project ID of the subscription. If not set, it will be inferred from the credentials or read from the GOOGLE_CLOUD_PROJECT environment variable.").
+					Default(""),
+				service.NewStringField(csiPubSubFieldSubscription).
+					Description("The ID of a subscription that receives notifications of the bucket. When set objects are downloaded as they are uploaded instead of listing the bucket.").
+					Default(""),
+				service.NewIntField(csiPubSubFieldMaxOutstandingMessages).
+					Description("The maximum number of notifications to have pending at a given time.").
+					Advanced().
+					Default(100),
+			).
+				Description("Consume objects as they are uploaded by subscribing to Pub/Sub notifications of the bucket.").
+				Version("4.33.0"),
 		)
 }
 
@@ -139,6 +192,11 @@ type gcpCloudStorageObjectTarget struct {
 	ackFn func(context.Context, error) error
 }
 
+type gcpCloudStorageObjectTargetReader interface {
+	Pop(ctx context.Context) (*gcpCloudStorageObjectTarget, error)
+	Close(ctx context.Context) error
+}
+
 func newGCPCloudStorageObjectTarget(key string, ackFn service.AckFunc) *gcpCloudStorageObjectTarget {
 	if ackFn == nil {
 		ackFn = func(context.Context, error) error {
@@ -247,18 +305,127 @@ func (r gcpCloudStorageTargetReader) Close(context.Context) error {
 
 //------------------------------------------------------------------------------
 
+const gcpCloudStorageEventObjectFinalize = "OBJECT_FINALIZE"
+
+// gcpCloudStoragePubSubTargetReader obtains objects to download from Pub/Sub
+// notifications of a bucket, where notifications are only acknowledged once
+// their object has been processed.
+type gcpCloudStoragePubSubTargetReader struct {
+	conf   csiConfig
+	log    *service.Logger
+	bucket *storage.BucketHandle
+
+	msgsChan  chan *pubsub.Message
+	closeFunc context.CancelFunc
+}
+
+func newGCPCloudStoragePubSubTargetReader(
+	conf csiConfig,
+	log *service.Logger,
+	bucket *storage.BucketHandle,
+	sub *pubsub.Subscription,
+) *gcpCloudStoragePubSubTargetReader {
+	sub.ReceiveSettings.MaxOutstandingMessages = conf.PubSub.MaxOutstandingMessages
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	msgsChan := make(chan *pubsub.Message)
+
+	go func() {
+		rerr := sub.Receive(subCtx, func(ctx context.Context, m *pubsub.Message) {
+			select {
+			case msgsChan <- m:
+			case <-ctx.Done():
+				m.Nack()
+			}
+		})
+		if rerr != nil && !errors.Is(rerr, context.Canceled) {
+			log.Errorf("Subscription error: %v\n", rerr)
+		}
+		close(msgsChan)
+	}()
+
+	return &gcpCloudStoragePubSubTargetReader{
+		conf:      conf,
+		log:       log,
+		bucket:    bucket,
+		msgsChan:  msgsChan,
+		closeFunc: cancel,
+	}
+}
+
+// targetFromNotification returns the object created according to a
+// notification, or nil if the notification should be ignored.
+func (r *gcpCloudStoragePubSubTargetReader) targetFromNotification(m *pubsub.Message) *gcpCloudStorageObjectTarget {
+	eventType, bucket, key := m.Attributes["eventType"], m.Attributes["bucketId"], m.Attributes["objectId"]
+	if eventType != gcpCloudStorageEventObjectFinalize {
+		r.log.Tracef("Ignoring notification of event type %v for object %v", eventType, key)
+		return nil
+	}
+	if bucket != r.conf.Bucket {
+		r.log.Debugf("Ignoring notification of object %v in foreign bucket %v", key, bucket)
+		return nil
+	}
+	if key == "" || !strings.HasPrefix(key, r.conf.Prefix) {
+		return nil
+	}
+
+	return newGCPCloudStorageObjectTarget(key, deleteGCPCloudStorageObjectAckFn(
+		r.bucket, key, r.conf.DeleteObjects,
+		func(ctx context.Context, err error) error {
+			if err != nil && errors.Is(err, storage.ErrObjectNotExist) {
+				// The object was deleted since the notification was sent and
+				// there's nothing left to process.
+				r.log.Warnf("Object %v of notification no longer exists", key)
+				err = nil
+			}
+			if err != nil {
+				r.log.Debugf("Pushing notification of object %v back into the subscription due to error: %v\n", key, err)
+				m.Nack()
+			} else {
+				m.Ack()
+			}
+			return nil
+		},
+	))
+}
+
+func (r *gcpCloudStoragePubSubTargetReader) Pop(ctx context.Context) (*gcpCloudStorageObjectTarget, error) {
+	for {
+		select {
+		case m, open := <-r.msgsChan:
+			if !open {
+				return nil, service.ErrNotConnected
+			}
+			if target := r.targetFromNotification(m); target != nil {
+				return target, nil
+			}
+			m.Ack()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (r *gcpCloudStoragePubSubTargetReader) Close(context.Context) error {
+	r.closeFunc()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
 // gcpCloudStorage is a benthos reader.Type implementation that reads messages
 // from a Google Cloud Storage bucket.
 type gcpCloudStorageInput struct {
 	conf csiConfig
 
 	objectScannerCtor codec.DeprecatedFallbackCodec
-	keyReader         *gcpCloudStorageTargetReader
+	keyReader         gcpCloudStorageObjectTargetReader
 
 	objectMut sync.Mutex
 	object    *gcpCloudStoragePendingObject
 
-	client *storage.Client
+	client       *storage.Client
+	pubsubClient *pubsub.Client
 
 	log *service.Logger
 }
@@ -289,6 +456,27 @@ func (g *gcpCloudStorageInput) Connect(ctx context.Context) error {
 		return err
 	}
 
+	if g.keyReader != nil {
+		_ = g.keyReader.Close(ctx)
+	}
+
+	if g.conf.PubSub.Subscription != "" {
+		if g.pubsubClient == nil {
+			project := g.conf.PubSub.Project
+			if project == "" {
+				project = pubsub.DetectProjectID
+			}
+			if g.pubsubClient, err = pubsub.NewClient(context.Background(), project, opt...); err != nil {
+				return err
+			}
+		}
+		g.keyReader = newGCPCloudStoragePubSubTargetReader(
+			g.conf, g.log, g.client.Bucket(g.conf.Bucket),
+			g.pubsubClient.Subscription(g.conf.PubSub.Subscription),
+		)
+		return nil
+	}
+
 	g.keyReader, err = newGCPCloudStorageTargetReader(ctx, g.conf, g.log, g.client.Bucket(g.conf.Bucket))
 	return err
 }
@@ -404,6 +592,18 @@ func (g *gcpCloudStorageInput) Close(ctx context.Context) (err error) {
 		g.object = nil
 	}
 
+	if g.keyReader != nil {
+		if kerr := g.keyReader.Close(ctx); err == nil {
+			err = kerr
+		}
+		g.keyReader = nil
+	}
+
+	if err == nil && g.pubsubClient != nil {
+		err = g.pubsubClient.Close()
+		g.pubsubClient = nil
+	}
+
 	if err == nil && g.client != nil {
 		err = g.client.Close()
 		g.client = nil
@@ -0,0 +1,167 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func testPubSubServerClient(t testing.TB) (*pstest.Server, *pubsub.Client) {
+	t.Helper()
+
+	srv := pstest.NewServer()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	client, err := pubsub.NewClient(context.Background(), "test-project",
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return srv, client
+}
+
+func TestCloudStoragePubSubTargetReader(t *testing.T) {
+	srv, client := testPubSubServerClient(t)
+	ctx := context.Background()
+
+	topic, err := client.CreateTopic(ctx, "notifications")
+	require.NoError(t, err)
+
+	sub, err := client.CreateSubscription(ctx, "notifications-sub", pubsub.SubscriptionConfig{
+		Topic:       topic,
+		AckDeadline: time.Minute,
+	})
+	require.NoError(t, err)
+
+	pConf, err := csiSpec().ParseYAML(`
+bucket: foo
+prefix: in/
+pubsub:
+  subscription: notifications-sub
+  max_outstanding_messages: 10
+`, nil)
+	require.NoError(t, err)
+
+	conf, err := csiConfigFromParsed(pConf)
+	require.NoError(t, err)
+
+	publish := func(eventType, bucket, key string) string {
+		return srv.Publish("projects/test-project/topics/notifications", nil, map[string]string{
+			"eventType": eventType,
+			"bucketId":  bucket,
+			"objectId":  key,
+		})
+	}
+
+	ignored := []string{
+		publish("OBJECT_DELETE", "foo", "in/a"),
+		publish("OBJECT_FINALIZE", "bar", "in/b"),
+		publish("OBJECT_FINALIZE", "foo", "out/c"),
+	}
+	ids := map[string]string{
+		"in/d": publish("OBJECT_FINALIZE", "foo", "in/d"),
+		"in/e": publish("OBJECT_FINALIZE", "foo", "in/e"),
+	}
+
+	r := newGCPCloudStoragePubSubTargetReader(conf, service.MockResources().Logger(), nil, sub)
+	t.Cleanup(func() {
+		_ = r.Close(ctx)
+	})
+
+	// Notifications are received concurrently and therefore targets are popped
+	// in the background.
+	popCtx, done := context.WithCancel(ctx)
+	defer done()
+
+	targetsChan := make(chan *gcpCloudStorageObjectTarget)
+	go func() {
+		for {
+			target, err := r.Pop(popCtx)
+			if err != nil {
+				return
+			}
+			select {
+			case targetsChan <- target:
+			case <-popCtx.Done():
+				return
+			}
+		}
+	}()
+
+	nextTarget := func() *gcpCloudStorageObjectTarget {
+		select {
+		case target := <-targetsChan:
+			return target
+		case <-time.After(time.Second * 10):
+			t.Fatal("timed out waiting for target")
+		}
+		return nil
+	}
+
+	targets := map[string]*gcpCloudStorageObjectTarget{}
+	for len(targets) < 2 {
+		target := nextTarget()
+		targets[target.key] = target
+	}
+	require.Contains(t, targets, "in/d")
+	require.Contains(t, targets, "in/e")
+
+	acks := func(id string) func() bool {
+		return func() bool {
+			return srv.Message(id).Acks > 0
+		}
+	}
+
+	for _, id := range ignored {
+		require.Eventually(t, acks(id), time.Second*5, time.Millisecond*10)
+	}
+
+	// Notifications are only acknowledged once the object is processed
+	assert.Zero(t, srv.Message(ids["in/d"]).Acks)
+	require.NoError(t, targets["in/d"].ackFn(ctx, nil))
+	require.Eventually(t, acks(ids["in/d"]), time.Second*5, time.Millisecond*10)
+
+	// Failed objects are redelivered
+	require.NoError(t, targets["in/e"].ackFn(ctx, errors.New("nope")))
+	target := nextTarget()
+	assert.Equal(t, "in/e", target.key)
+	assert.Zero(t, srv.Message(ids["in/e"]).Acks)
+	assert.GreaterOrEqual(t, srv.Message(ids["in/e"]).Deliveries, 2)
+
+	// Objects that no longer exist are skipped
+	require.NoError(t, target.ackFn(ctx, fmt.Errorf("failed to get attributes: %w", storage.ErrObjectNotExist)))
+	require.Eventually(t, acks(ids["in/e"]), time.Second*5, time.Millisecond*10)
+}
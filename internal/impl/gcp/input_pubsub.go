@@ -17,8 +17,10 @@ package gcp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"cloud.google.com/go/pubsub"
 	"google.golang.org/api/option"
@@ -38,6 +40,20 @@ const (
 	pbiFieldCreateSub              = "create_subscription"
 	pbiFieldCreateSubEnabled       = "enabled"
 	pbiFieldCreateSubTopicID       = "topic"
+	pbiFieldCreateSubExactlyOnce   = "enable_exactly_once_delivery"
+	pbiFieldCreateSubOrdering      = "enable_message_ordering"
+	pbiFieldCreateSubFilter        = "filter"
+	pbiFieldCreateSubDeadLetter    = "dead_letter_policy"
+	pbiFieldCreateSubRetry         = "retry_policy"
+
+	// Pubsub Input Dead Letter Policy Fields
+	pbiFieldDeadLetterTopic               = "topic"
+	pbiFieldDeadLetterMaxDeliveryAttempts = "max_delivery_attempts"
+
+	// Pubsub Input Retry Policy Fields
+	pbiFieldRetryEnabled        = "enabled"
+	pbiFieldRetryMinimumBackoff = "minimum_backoff"
+	pbiFieldRetryMaximumBackoff = "maximum_backoff"
 )
 
 type pbiConfig struct {
@@ -50,6 +66,14 @@ type pbiConfig struct {
 	Sync                   bool
 	CreateEnabled          bool
 	CreateTopicID          string
+	CreateExactlyOnce      bool
+	CreateOrdering         bool
+	CreateFilter           string
+	CreateDeadLetterTopic  string
+	CreateMaxDeliveries    int
+	CreateRetryEnabled     bool
+	CreateRetryMinBackoff  time.Duration
+	CreateRetryMaxBackoff  time.Duration
 }
 
 func pbiConfigFromParsed(pConf *service.ParsedConfig) (conf pbiConfig, err error) {
@@ -82,6 +106,42 @@ func pbiConfigFromParsed(pConf *service.ParsedConfig) (conf pbiConfig, err error
 		if conf.CreateTopicID, err = createConf.FieldString(pbiFieldCreateSubTopicID); err != nil {
 			return
 		}
+		if conf.CreateExactlyOnce, err = createConf.FieldBool(pbiFieldCreateSubExactlyOnce); err != nil {
+			return
+		}
+		if conf.CreateOrdering, err = createConf.FieldBool(pbiFieldCreateSubOrdering); err != nil {
+			return
+		}
+		if conf.CreateFilter, err = createConf.FieldString(pbiFieldCreateSubFilter); err != nil {
+			return
+		}
+
+		deadLetterConf := createConf.Namespace(pbiFieldCreateSubDeadLetter)
+		if conf.CreateDeadLetterTopic, err = deadLetterConf.FieldString(pbiFieldDeadLetterTopic); err != nil {
+			return
+		}
+		if conf.CreateMaxDeliveries, err = deadLetterConf.FieldInt(pbiFieldDeadLetterMaxDeliveryAttempts); err != nil {
+			return
+		}
+		if conf.CreateDeadLetterTopic != "" && (conf.CreateMaxDeliveries < 5 || conf.CreateMaxDeliveries > 100) {
+			err = fmt.Errorf("%v must be between 5 and 100, got %v", pbiFieldDeadLetterMaxDeliveryAttempts, conf.CreateMaxDeliveries)
+			return
+		}
+
+		retryConf := createConf.Namespace(pbiFieldCreateSubRetry)
+		if conf.CreateRetryEnabled, err = retryConf.FieldBool(pbiFieldRetryEnabled); err != nil {
+			return
+		}
+		if conf.CreateRetryMinBackoff, err = retryConf.FieldDuration(pbiFieldRetryMinimumBackoff); err != nil {
+			return
+		}
+		if conf.CreateRetryMaxBackoff, err = retryConf.FieldDuration(pbiFieldRetryMaximumBackoff); err != nil {
+			return
+		}
+		if conf.CreateRetryEnabled && conf.CreateRetryMinBackoff > conf.CreateRetryMaxBackoff {
+			err = fmt.Errorf("%v must not exceed %v", pbiFieldRetryMinimumBackoff, pbiFieldRetryMaximumBackoff)
+			return
+		}
 	}
 	return
 }
@@ -100,9 +160,14 @@ This input adds the following metadata fields to each message:
 
 - gcp_pubsub_publish_time_unix - The time at which the message was published to the topic.
 - gcp_pubsub_delivery_attempt - When dead lettering is enabled, this is set to the number of times PubSub has attempted to deliver a message.
+- gcp_pubsub_ordering_key - The ordering key of the message, if set.
 - All message attributes
 
 You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+
+== Exactly-once delivery
+
+When the subscription has https://cloud.google.com/pubsub/docs/exactly-once-delivery[exactly-once delivery^] enabled, which can be configured with the field `+"`create_subscription.enable_exactly_once_delivery`"+` for subscriptions created by this input, acknowledgements are only considered successful once they have been confirmed by Pub/Sub. Acknowledgements that fail, for example because the acknowledgement deadline of the message expired, are reported as errors and the message is redelivered by Pub/Sub, and therefore it's recommended to set the acknowledgement deadline of the subscription to a value that comfortably exceeds the time it takes to process a batch.
 `).
 		Fields(
 			service.NewStringField(pbiFieldProjectID).
@@ -133,6 +198,42 @@ You can access these metadata fields using xref:configuration:interpolation.adoc
 				service.NewStringField(pbiFieldCreateSubTopicID).
 					Description("Defines the topic that the subscription should be vinculated to.").
 					Default(""),
+				service.NewBoolField(pbiFieldCreateSubExactlyOnce).
+					Description("Whether to enable exactly-once delivery for the subscription.").
+					Version("4.33.0").
+					Default(false),
+				service.NewBoolField(pbiFieldCreateSubOrdering).
+					Description("Whether to deliver messages with the same ordering key in the order they were published.").
+					Version("4.33.0").
+					Default(false),
+				service.NewStringField(pbiFieldCreateSubFilter).
+					Description("An optional https://cloud.google.com/pubsub/docs/subscription-message-filter[filter^] for the messages of the subscription, messages that don't match the filter are acknowledged automatically.").
+					Example(`attributes.eventType = "OBJECT_FINALIZE"`).
+					Version("4.33.0").
+					Default(""),
+				service.NewObjectField(pbiFieldCreateSubDeadLetter,
+					service.NewStringField(pbiFieldDeadLetterTopic).
+						Description("The ID of a topic to which messages that can't be delivered are forwarded. If empty dead lettering is disabled.").
+						Default(""),
+					service.NewIntField(pbiFieldDeadLetterMaxDeliveryAttempts).
+						Description("The maximum number of delivery attempts of a message before it is forwarded to the dead letter topic, between 5 and 100.").
+						Default(5),
+				).
+					Description("Configures dead lettering of messages that repeatedly fail to be processed.").
+					Version("4.33.0"),
+				service.NewObjectField(pbiFieldCreateSubRetry,
+					service.NewBoolField(pbiFieldRetryEnabled).
+						Description("Whether to redeliver negatively acknowledged messages with an exponential backoff rather than immediately.").
+						Default(false),
+					service.NewDurationField(pbiFieldRetryMinimumBackoff).
+						Description("The minimum delay before a message is redelivered.").
+						Default("10s"),
+					service.NewDurationField(pbiFieldRetryMaximumBackoff).
+						Description("The maximum delay before a message is redelivered.").
+						Default("600s"),
+				).
+					Description("Configures the delay before negatively acknowledged messages are redelivered.").
+					Version("4.33.0"),
 			).
 				Description("Allows you to configure the input subscription and creates if it doesn't exist.").
 				Advanced(),
@@ -171,12 +272,39 @@ func createSubscription(conf pbiConfig, client *pubsub.Client, log *service.Logg
 	}
 
 	log.Infof("Creating subscription '%v' on topic '%v'\n", conf.SubscriptionID, conf.CreateTopicID)
-	_, err = client.CreateSubscription(context.Background(), conf.SubscriptionID, pubsub.SubscriptionConfig{Topic: client.Topic(conf.CreateTopicID)})
+	_, err = client.CreateSubscription(context.Background(), conf.SubscriptionID, subscriptionConfig(conf, client))
 	if err != nil {
 		log.Errorf("Error creating subscription %v", err)
 	}
 }
 
+func subscriptionConfig(conf pbiConfig, client *pubsub.Client) pubsub.SubscriptionConfig {
+	subConf := pubsub.SubscriptionConfig{
+		Topic:                     client.Topic(conf.CreateTopicID),
+		EnableExactlyOnceDelivery: conf.CreateExactlyOnce,
+		EnableMessageOrdering:     conf.CreateOrdering,
+		Filter:                    conf.CreateFilter,
+	}
+	if conf.CreateDeadLetterTopic != "" {
+		// The dead letter topic must be referenced by its full name.
+		topic := conf.CreateDeadLetterTopic
+		if !strings.Contains(topic, "/") {
+			topic = fmt.Sprintf("projects/%v/topics/%v", client.Project(), topic)
+		}
+		subConf.DeadLetterPolicy = &pubsub.DeadLetterPolicy{
+			DeadLetterTopic:     topic,
+			MaxDeliveryAttempts: conf.CreateMaxDeliveries,
+		}
+	}
+	if conf.CreateRetryEnabled {
+		subConf.RetryPolicy = &pubsub.RetryPolicy{
+			MinimumBackoff: conf.CreateRetryMinBackoff,
+			MaximumBackoff: conf.CreateRetryMaxBackoff,
+		}
+	}
+	return subConf
+}
+
 type gcpPubSubReader struct {
 	conf pbiConfig
 
@@ -292,12 +420,27 @@ func (c *gcpPubSubReader) Read(ctx context.Context) (*service.Message, service.A
 	if gmsg.DeliveryAttempt != nil {
 		part.MetaSetMut("gcp_pubsub_delivery_attempt", *gmsg.DeliveryAttempt)
 	}
+	if gmsg.OrderingKey != "" {
+		part.MetaSetMut("gcp_pubsub_ordering_key", gmsg.OrderingKey)
+	}
 
 	return part, func(ctx context.Context, res error) error {
+		// For subscriptions without exactly-once delivery the results resolve
+		// immediately, otherwise they resolve once Pub/Sub has confirmed the
+		// acknowledgement.
+		var ackRes *pubsub.AckResult
 		if res != nil {
-			gmsg.Nack()
+			ackRes = gmsg.NackWithResult()
 		} else {
-			gmsg.Ack()
+			ackRes = gmsg.AckWithResult()
+		}
+
+		status, err := ackRes.Get(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to acknowledge message %v: %w", gmsg.ID, err)
+		}
+		if status != pubsub.AcknowledgeStatusSuccess {
+			return fmt.Errorf("failed to acknowledge message %v: status %v", gmsg.ID, status)
 		}
 		return nil
 	}, nil
@@ -0,0 +1,185 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func testPubSubInputConfig(t testing.TB, yamlStr string) pbiConfig {
+	t.Helper()
+
+	pConf, err := pbiSpec().ParseYAML(yamlStr, nil)
+	require.NoError(t, err)
+
+	conf, err := pbiConfigFromParsed(pConf)
+	require.NoError(t, err)
+	return conf
+}
+
+func TestPubSubInputCreateSubscription(t *testing.T) {
+	_, client := testPubSubServerClient(t)
+	ctx := context.Background()
+
+	_, err := client.CreateTopic(ctx, "foo")
+	require.NoError(t, err)
+	_, err = client.CreateTopic(ctx, "foo-dlq")
+	require.NoError(t, err)
+
+	conf := testPubSubInputConfig(t, `
+project: test-project
+subscription: foo-sub
+create_subscription:
+  enabled: true
+  topic: foo
+  enable_exactly_once_delivery: true
+  enable_message_ordering: true
+  filter: attributes.type = "bar"
+  dead_letter_policy:
+    topic: foo-dlq
+    max_delivery_attempts: 10
+  retry_policy:
+    enabled: true
+    minimum_backoff: 5s
+    maximum_backoff: 1m
+`)
+
+	createSubscription(conf, client, service.MockResources().Logger())
+
+	subConf, err := client.Subscription("foo-sub").Config(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "projects/test-project/topics/foo", subConf.Topic.String())
+	assert.True(t, subConf.EnableExactlyOnceDelivery)
+	assert.True(t, subConf.EnableMessageOrdering)
+	assert.Equal(t, `attributes.type = "bar"`, subConf.Filter)
+	require.NotNil(t, subConf.DeadLetterPolicy)
+	assert.Equal(t, "projects/test-project/topics/foo-dlq", subConf.DeadLetterPolicy.DeadLetterTopic)
+	assert.Equal(t, 10, subConf.DeadLetterPolicy.MaxDeliveryAttempts)
+	require.NotNil(t, subConf.RetryPolicy)
+	assert.Equal(t, time.Second*5, subConf.RetryPolicy.MinimumBackoff)
+	assert.Equal(t, time.Minute, subConf.RetryPolicy.MaximumBackoff)
+}
+
+func TestPubSubInputDeliveryAttempts(t *testing.T) {
+	srv, client := testPubSubServerClient(t)
+	ctx := context.Background()
+
+	_, err := client.CreateTopic(ctx, "foo")
+	require.NoError(t, err)
+	_, err = client.CreateTopic(ctx, "foo-dlq")
+	require.NoError(t, err)
+
+	conf := testPubSubInputConfig(t, `
+project: test-project
+subscription: foo-sub
+create_subscription:
+  enabled: true
+  topic: foo
+  dead_letter_policy:
+    topic: foo-dlq
+`)
+	createSubscription(conf, client, service.MockResources().Logger())
+
+	r := &gcpPubSubReader{
+		conf:   conf,
+		log:    service.MockResources().Logger(),
+		client: client,
+	}
+	require.NoError(t, r.Connect(ctx))
+	t.Cleanup(func() {
+		_ = r.Close(ctx)
+	})
+
+	id := srv.Publish("projects/test-project/topics/foo", []byte("hello"), map[string]string{"a": "b"})
+
+	readCtx, done := context.WithTimeout(ctx, time.Second*10)
+	defer done()
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		msg, ackFn, err := r.Read(readCtx)
+		require.NoError(t, err)
+
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(b))
+
+		v, exists := msg.MetaGetMut("gcp_pubsub_delivery_attempt")
+		require.True(t, exists)
+		assert.Equal(t, attempt, v)
+
+		if attempt == 1 {
+			require.NoError(t, ackFn(readCtx, errors.New("nope")))
+		} else {
+			require.NoError(t, ackFn(readCtx, nil))
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return srv.Message(id).Acks > 0
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestPubSubInputConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`
+project: foo
+subscription: bar
+create_subscription:
+  enabled: true
+  topic: baz
+  dead_letter_policy:
+    topic: baz-dlq
+    max_delivery_attempts: 3
+`,
+		`
+project: foo
+subscription: bar
+create_subscription:
+  enabled: true
+  topic: baz
+  retry_policy:
+    enabled: true
+    minimum_backoff: 1m
+    maximum_backoff: 10s
+`,
+	} {
+		pConf, err := pbiSpec().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = pbiConfigFromParsed(pConf)
+		require.Error(t, err, conf)
+	}
+
+	// Dead lettering is disabled without a topic
+	conf := testPubSubInputConfig(t, `
+project: foo
+subscription: bar
+create_subscription:
+  enabled: true
+  topic: baz
+  dead_letter_policy:
+    max_delivery_attempts: 3
+`)
+	assert.Empty(t, conf.CreateDeadLetterTopic)
+}
@@ -76,6 +76,8 @@ type gcpBigQueryOutputConfig struct {
 
 	// CSV options
 	CSVOptions gcpBigQueryCSVConfig
+
+	StorageWrite gcpBigQueryStorageWriteConfig
 }
 
 func gcpBigQueryOutputConfigFromParsed(conf *service.ParsedConfig) (gconf gcpBigQueryOutputConfig, err error) {
@@ -118,6 +120,13 @@ func gcpBigQueryOutputConfigFromParsed(conf *service.ParsedConfig) (gconf gcpBig
 	if gconf.CSVOptions, err = gcpBigQueryCSVConfigFromParsed(conf.Namespace("csv")); err != nil {
 		return
 	}
+	if gconf.StorageWrite, err = gcpBigQueryStorageWriteConfigFromParsed(conf.Namespace("storage_write_api")); err != nil {
+		return
+	}
+	if gconf.StorageWrite.Enabled && gconf.Format != string(bigquery.JSON) {
+		err = fmt.Errorf("the storage write api requires the format %v", bigquery.JSON)
+		return
+	}
 	return
 }
 
@@ -177,7 +186,19 @@ The same is true for the CSV format.
 
 === CSV
 
-For the CSV format when the field ` + "`csv.header`" + ` is specified a header row will be inserted as the first line of each message batch. If this field is not provided then the first message of each message batch must include a header line.` + service.OutputPerformanceDocs(true, true)).
+For the CSV format when the field ` + "`csv.header`" + ` is specified a header row will be inserted as the first line of each message batch. If this field is not provided then the first message of each message batch must include a header line.
+
+== Storage Write API
+
+By default batches are written with load jobs, which are subject to daily quotas and can take a while to complete. Alternatively, when ` + "`storage_write_api.enabled`" + ` is set to ` + "`true`" + ` batches are written with the https://cloud.google.com/bigquery/docs/write-api[BigQuery Storage Write API^] instead, in which case the table must already exist and each message must be a single JSON object. Messages are converted into rows using the schema of the table obtained upon connecting, where columns of type ` + "`TIMESTAMP`" + ` accept either strings or integers of microseconds since the unix epoch, columns of type ` + "`DATE`" + ` accept either strings or integers of days since the unix epoch, and columns of type ` + "`BYTES`" + ` accept base64 encoded strings.
+
+The field ` + "`storage_write_api.stream_type`" + ` determines how rows are written:
+
+- ` + "`DEFAULT`" + `: Rows are appended to the default stream of the table, which offers the highest throughput with at-least-once delivery.
+- ` + "`COMMITTED`" + `: Rows are appended to a committed stream with offsets, which means appends that are retried are only written once. Appends are written in order and so ` + "`max_in_flight`" + ` doesn't increase the throughput. If an append keeps failing it's written to a new stream, in which case it might be written twice.
+- ` + "`PENDING`" + `: Each batch is appended to a new pending stream that is committed atomically once written, which means a batch either becomes visible as a whole or not at all. Creating streams is subject to quotas and therefore this should be combined with large batches.
+
+Messages that fail to convert into a row, or rows that are rejected by BigQuery, are reported as individual errors and can be handled with xref:configuration:error_handling.adoc[error handling patterns], and all other rows of the batch are written. The fields ` + "`write_disposition`" + `, ` + "`create_disposition`" + `, ` + "`max_bad_records`" + `, ` + "`auto_detect`" + `, ` + "`job_labels`" + ` and ` + "`csv`" + ` only apply to load jobs.` + service.OutputPerformanceDocs(true, true)).
 		Field(service.NewStringField("project").Description("The project ID of the dataset to insert data to. If not set, it will be inferred from the credentials or read from the GOOGLE_CLOUD_PROJECT environment variable.").Default("")).
 		Field(service.NewStringField("dataset").Description("The BigQuery Dataset ID.")).
 		Field(service.NewStringField("table").Description("The table to insert messages to.")).
@@ -234,6 +255,16 @@ For the CSV format when the field ` + "`csv.header`" + ` is specified a header r
 				Advanced().
 				Default(1),
 		).Description("Specify how CSV data should be interpretted.")).
+		Field(service.NewObjectField("storage_write_api",
+			service.NewBoolField("enabled").
+				Description("Whether to write rows with the Storage Write API rather than load jobs.").
+				Default(false),
+			service.NewStringEnumField("stream_type", bqStreamTypeDefault, bqStreamTypeCommitted, bqStreamTypePending).
+				Description("The type of stream to write rows to.").
+				Default(bqStreamTypeCommitted),
+		).
+			Description("Write rows with the BigQuery Storage Write API.").
+			Version("4.33.0")).
 		Field(service.NewBatchPolicyField("batching"))
 }
 
@@ -264,6 +295,7 @@ type gcpBigQueryOutput struct {
 	clientURL gcpBQClientURL
 
 	client  *bigquery.Client
+	storage *bigQueryStorageWriter
 	connMut sync.RWMutex
 
 	fieldDelimiterBytes []byte
@@ -347,9 +379,10 @@ func (g *gcpBigQueryOutput) Connect(ctx context.Context) (err error) {
 		return
 	}
 
-	if g.conf.CreateDisposition == string(bigquery.CreateNever) {
+	if g.conf.CreateDisposition == string(bigquery.CreateNever) || g.conf.StorageWrite.Enabled {
 		table := dataset.Table(g.conf.TableID)
-		if _, err = table.Metadata(ctx); err != nil {
+		var meta *bigquery.TableMetadata
+		if meta, err = table.Metadata(ctx); err != nil {
 			if hasStatusCode(err, http.StatusNotFound) {
 				err = fmt.Errorf("table does not exist: %v", g.conf.TableID)
 			} else {
@@ -357,12 +390,34 @@ func (g *gcpBigQueryOutput) Connect(ctx context.Context) (err error) {
 			}
 			return
 		}
+		if g.conf.StorageWrite.Enabled {
+			if g.storage, err = g.newStorageWriter(ctx, client.Project(), meta.Schema); err != nil {
+				return
+			}
+		}
 	}
 
 	g.client = client
 	return nil
 }
 
+func (g *gcpBigQueryOutput) newStorageWriter(ctx context.Context, projectID string, schema bigquery.Schema) (*bigQueryStorageWriter, error) {
+	desc, dp, err := bqDescriptorFromSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	appender, err := newBigQueryStorageAppender(ctx, g.conf, projectID, dp)
+	if err != nil {
+		return nil, err
+	}
+	return &bigQueryStorageWriter{
+		schema:        schema,
+		desc:          desc,
+		ignoreUnknown: g.conf.IgnoreUnknownValues,
+		appender:      appender,
+	}, nil
+}
+
 func hasStatusCode(err error, code int) bool {
 	if e, ok := err.(*googleapi.Error); ok && e.Code == code {
 		return true
@@ -372,12 +427,16 @@ func hasStatusCode(err error, code int) bool {
 
 func (g *gcpBigQueryOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
 	g.connMut.RLock()
-	client := g.client
+	client, storage := g.client, g.storage
 	g.connMut.RUnlock()
 	if client == nil {
 		return service.ErrNotConnected
 	}
 
+	if storage != nil {
+		return storage.writeBatch(ctx, batch)
+	}
+
 	var data bytes.Buffer
 
 	if g.csvHeaderBytes != nil {
@@ -437,6 +496,12 @@ func (g *gcpBigQueryOutput) createTableLoader(data *[]byte) *bigquery.Loader {
 
 func (g *gcpBigQueryOutput) Close(ctx context.Context) error {
 	g.connMut.Lock()
+	if g.storage != nil {
+		if err := g.storage.Close(); err != nil {
+			g.log.Warnf("Failed to close storage write client: %v", err)
+		}
+		g.storage = nil
+	}
 	if g.client != nil {
 		g.client.Close()
 		g.client = nil
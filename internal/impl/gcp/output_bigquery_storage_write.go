@@ -0,0 +1,607 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	bqStreamTypeDefault   = "DEFAULT"
+	bqStreamTypeCommitted = "COMMITTED"
+	bqStreamTypePending   = "PENDING"
+
+	// The number of attempts of an append to a committed stream at the same
+	// offset before the stream is abandoned.
+	bqCommittedAppendAttempts = 3
+)
+
+type gcpBigQueryStorageWriteConfig struct {
+	Enabled    bool
+	StreamType string
+}
+
+func gcpBigQueryStorageWriteConfigFromParsed(conf *service.ParsedConfig) (sconf gcpBigQueryStorageWriteConfig, err error) {
+	if sconf.Enabled, err = conf.FieldBool("enabled"); err != nil {
+		return
+	}
+	if sconf.StreamType, err = conf.FieldString("stream_type"); err != nil {
+		return
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// bqDescriptorFromSchema creates a self-contained proto descriptor of the rows
+// of a table, where nested records are defined as nested types. Column types
+// without a direct proto equivalent are represented as strings, which are
+// parsed by BigQuery.
+func bqDescriptorFromSchema(schema bigquery.Schema) (protoreflect.MessageDescriptor, *descriptorpb.DescriptorProto, error) {
+	dp, err := bqDescriptorProtoFromSchema("root", schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("bigquery_row.proto"),
+		Syntax:      proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{dp},
+	}, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create row descriptor: %w", err)
+	}
+	return fd.Messages().Get(0), dp, nil
+}
+
+func bqDescriptorProtoFromSchema(name string, schema bigquery.Schema) (*descriptorpb.DescriptorProto, error) {
+	dp := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	for i, field := range schema {
+		fdp := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(strings.ToLower(field.Name)),
+			Number: proto.Int32(int32(i + 1)),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if field.Repeated {
+			fdp.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+
+		switch field.Type {
+		case bigquery.StringFieldType, bigquery.GeographyFieldType, bigquery.JSONFieldType,
+			bigquery.NumericFieldType, bigquery.BigNumericFieldType,
+			bigquery.DateTimeFieldType, bigquery.TimeFieldType:
+			fdp.Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+		case bigquery.BytesFieldType:
+			fdp.Type = descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+		case bigquery.IntegerFieldType, bigquery.TimestampFieldType:
+			fdp.Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+		case bigquery.DateFieldType:
+			fdp.Type = descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum()
+		case bigquery.FloatFieldType:
+			fdp.Type = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE.Enum()
+		case bigquery.BooleanFieldType:
+			fdp.Type = descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum()
+		case bigquery.RecordFieldType:
+			nestedName := fmt.Sprintf("%v_%v", name, i+1)
+			nested, err := bqDescriptorProtoFromSchema(nestedName, field.Schema)
+			if err != nil {
+				return nil, err
+			}
+			dp.NestedType = append(dp.NestedType, nested)
+			fdp.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			fdp.TypeName = proto.String(nestedName)
+		default:
+			return nil, fmt.Errorf("column %v has unsupported type %v", field.Name, field.Type)
+		}
+		dp.Field = append(dp.Field, fdp)
+	}
+	return dp, nil
+}
+
+// bqRowFromJSON converts a JSON document into a row of the table, where values
+// are coerced into the representation expected for their column type.
+func bqRowFromJSON(desc protoreflect.MessageDescriptor, schema bigquery.Schema, b []byte, ignoreUnknown bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected message to be a JSON object, got %T", doc)
+	}
+
+	msg, err := bqMessageFromObject(desc, schema, obj, ignoreUnknown)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+func bqMessageFromObject(desc protoreflect.MessageDescriptor, schema bigquery.Schema, obj map[string]any, ignoreUnknown bool) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(desc)
+	for k, v := range obj {
+		if v == nil {
+			continue
+		}
+
+		// Column names are case insensitive.
+		fd := desc.Fields().ByName(protoreflect.Name(strings.ToLower(k)))
+		if fd == nil {
+			if ignoreUnknown {
+				continue
+			}
+			return nil, fmt.Errorf("field %v does not match any column", k)
+		}
+
+		field := schema[fd.Number()-1]
+		if fd.IsList() {
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("field %v: expected array, got %T", k, v)
+			}
+			list := msg.Mutable(fd).List()
+			for _, e := range arr {
+				pv, err := bqValueFromJSON(fd, field, e, ignoreUnknown)
+				if err != nil {
+					return nil, fmt.Errorf("field %v: %w", k, err)
+				}
+				list.Append(pv)
+			}
+			continue
+		}
+
+		pv, err := bqValueFromJSON(fd, field, v, ignoreUnknown)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %w", k, err)
+		}
+		msg.Set(fd, pv)
+	}
+	return msg, nil
+}
+
+func bqValueFromJSON(fd protoreflect.FieldDescriptor, field *bigquery.FieldSchema, v any, ignoreUnknown bool) (protoreflect.Value, error) {
+	switch field.Type {
+	case bigquery.RecordFieldType:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected object, got %T", v)
+		}
+		msg, err := bqMessageFromObject(fd.Message(), field.Schema, obj, ignoreUnknown)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfMessage(msg), nil
+
+	case bigquery.JSONFieldType:
+		if s, ok := v.(string); ok {
+			return protoreflect.ValueOfString(s), nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfString(string(b)), nil
+
+	case bigquery.BytesFieldType:
+		s, ok := v.(string)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected base64 encoded string, got %T", v)
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfBytes(b), nil
+
+	case bigquery.IntegerFieldType:
+		i, err := bqInt64FromJSON(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt64(i), nil
+
+	case bigquery.TimestampFieldType:
+		// Timestamps are either strings or integers of microseconds since the
+		// unix epoch.
+		if s, ok := v.(string); ok {
+			t, err := bqParseTimestamp(s)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfInt64(t.UnixMicro()), nil
+		}
+		i, err := bqInt64FromJSON(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt64(i), nil
+
+	case bigquery.DateFieldType:
+		// Dates are either strings or integers of days since the unix epoch.
+		if s, ok := v.(string); ok {
+			t, err := time.Parse(time.DateOnly, s)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfInt32(int32(t.Unix() / 86400)), nil
+		}
+		i, err := bqInt64FromJSON(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt32(int32(i)), nil
+
+	case bigquery.FloatFieldType:
+		var f float64
+		var err error
+		switch t := v.(type) {
+		case json.Number:
+			f, err = t.Float64()
+		case string:
+			f, err = strconv.ParseFloat(t, 64)
+		default:
+			err = fmt.Errorf("expected number, got %T", v)
+		}
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+
+	case bigquery.BooleanFieldType:
+		switch t := v.(type) {
+		case bool:
+			return protoreflect.ValueOfBool(t), nil
+		case string:
+			b, err := strconv.ParseBool(t)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfBool(b), nil
+		}
+		return protoreflect.Value{}, fmt.Errorf("expected boolean, got %T", v)
+	}
+
+	// All remaining types are represented as strings.
+	switch t := v.(type) {
+	case string:
+		return protoreflect.ValueOfString(t), nil
+	case json.Number:
+		return protoreflect.ValueOfString(t.String()), nil
+	case bool:
+		return protoreflect.ValueOfString(strconv.FormatBool(t)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("expected string, got %T", v)
+}
+
+func bqInt64FromJSON(v any) (int64, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return t.Int64()
+	case string:
+		return strconv.ParseInt(t, 10, 64)
+	}
+	return 0, fmt.Errorf("expected integer, got %T", v)
+}
+
+func bqParseTimestamp(s string) (time.Time, error) {
+	for _, layout := range []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse timestamp %q", s)
+}
+
+//------------------------------------------------------------------------------
+
+// bigQueryStorageAppender appends serialized rows to a table, returning the
+// errors of individual rows that caused the append to be rejected.
+type bigQueryStorageAppender interface {
+	appendRows(ctx context.Context, rows [][]byte) (rowErrs map[int]error, err error)
+	Close() error
+}
+
+func bqRowErrorsFromResult(ctx context.Context, res *managedwriter.AppendResult) (map[int]error, error) {
+	resp, err := res.FullResponse(ctx)
+	if resp != nil && len(resp.GetRowErrors()) > 0 {
+		rowErrs := map[int]error{}
+		for _, rerr := range resp.GetRowErrors() {
+			rowErrs[int(rerr.GetIndex())] = fmt.Errorf("%v: %v", rerr.GetCode(), rerr.GetMessage())
+		}
+		return rowErrs, nil
+	}
+	return nil, err
+}
+
+func bqStorageErrorCode(err error) storagepb.StorageError_StorageErrorCode {
+	if apiErr, ok := apierror.FromError(err); ok {
+		storageErr := &storagepb.StorageError{}
+		if e := apiErr.Details().ExtractProtoMessage(storageErr); e == nil {
+			return storageErr.GetCode()
+		}
+	}
+	return storagepb.StorageError_STORAGE_ERROR_CODE_UNSPECIFIED
+}
+
+// bqManagedAppender appends rows to either the default stream of a table, or
+// to a committed stream using offsets, where an append that fails is retried at
+// the same offset so that it's written exactly once.
+type bqManagedAppender struct {
+	client    *managedwriter.Client
+	newStream func(ctx context.Context) (*managedwriter.ManagedStream, error)
+	offsets   bool
+
+	mut    sync.Mutex
+	stream *managedwriter.ManagedStream
+	offset int64
+}
+
+func (a *bqManagedAppender) appendRows(ctx context.Context, rows [][]byte) (map[int]error, error) {
+	if !a.offsets {
+		res, err := a.stream.AppendRows(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		return bqRowErrorsFromResult(ctx, res)
+	}
+
+	// Appends with offsets must be written in order.
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if a.stream == nil {
+		var err error
+		if a.stream, err = a.newStream(ctx); err != nil {
+			return nil, err
+		}
+		a.offset = 0
+	}
+
+	var err error
+	for attempt := 0; attempt < bqCommittedAppendAttempts; attempt++ {
+		var res *managedwriter.AppendResult
+		if res, err = a.stream.AppendRows(ctx, rows, managedwriter.WithOffset(a.offset)); err != nil {
+			continue
+		}
+
+		var rowErrs map[int]error
+		if rowErrs, err = bqRowErrorsFromResult(ctx, res); err == nil || len(rowErrs) > 0 {
+			// Rejected rows are never written, and therefore the offset
+			// remains the same.
+			if len(rowErrs) == 0 {
+				a.offset += int64(len(rows))
+			}
+			return rowErrs, nil
+		}
+		if bqStorageErrorCode(err) == storagepb.StorageError_OFFSET_ALREADY_EXISTS {
+			// A previous attempt was written despite failing.
+			a.offset += int64(len(rows))
+			return nil, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	// The outcome of the append is unknown, and so we abandon the stream and
+	// write to a new one from now on.
+	_ = a.stream.Close()
+	a.stream = nil
+	return nil, err
+}
+
+func (a *bqManagedAppender) Close() error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	var err error
+	if a.stream != nil {
+		err = a.stream.Close()
+		a.stream = nil
+	}
+	return errors.Join(err, a.client.Close())
+}
+
+// bqPendingAppender appends each set of rows to a new pending stream, which is
+// committed to the table atomically.
+type bqPendingAppender struct {
+	client    *managedwriter.Client
+	table     string
+	newStream func(ctx context.Context) (*managedwriter.ManagedStream, error)
+}
+
+func (a *bqPendingAppender) appendRows(ctx context.Context, rows [][]byte) (map[int]error, error) {
+	stream, err := a.newStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	res, err := stream.AppendRows(ctx, rows, managedwriter.WithOffset(0))
+	if err != nil {
+		return nil, err
+	}
+	if rowErrs, err := bqRowErrorsFromResult(ctx, res); err != nil || len(rowErrs) > 0 {
+		return rowErrs, err
+	}
+
+	if _, err := stream.Finalize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to finalize stream: %w", err)
+	}
+	resp, err := a.client.BatchCommitWriteStreams(ctx, &storagepb.BatchCommitWriteStreamsRequest{
+		Parent:       a.table,
+		WriteStreams: []string{stream.StreamName()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit stream: %w", err)
+	}
+	if serrs := resp.GetStreamErrors(); len(serrs) > 0 {
+		return nil, fmt.Errorf("failed to commit stream: %v: %v", serrs[0].GetCode(), serrs[0].GetErrorMessage())
+	}
+	return nil, nil
+}
+
+func (a *bqPendingAppender) Close() error {
+	return a.client.Close()
+}
+
+func newBigQueryStorageAppender(ctx context.Context, conf gcpBigQueryOutputConfig, projectID string, dp *descriptorpb.DescriptorProto) (bigQueryStorageAppender, error) {
+	opts, err := getClientOptionWithCredential(conf.CredentialsJSON, nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := managedwriter.NewClient(ctx, projectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating storage write client: %w", err)
+	}
+
+	table := managedwriter.TableParentFromParts(projectID, conf.DatasetID, conf.TableID)
+	newStream := func(streamType managedwriter.StreamType, retries bool) func(ctx context.Context) (*managedwriter.ManagedStream, error) {
+		return func(ctx context.Context) (*managedwriter.ManagedStream, error) {
+			return client.NewManagedStream(ctx,
+				managedwriter.WithDestinationTable(table),
+				managedwriter.WithType(streamType),
+				managedwriter.WithSchemaDescriptor(dp),
+				managedwriter.EnableWriteRetries(retries),
+			)
+		}
+	}
+
+	switch conf.StorageWrite.StreamType {
+	case bqStreamTypeDefault:
+		stream, err := newStream(managedwriter.DefaultStream, true)(ctx)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+		return &bqManagedAppender{client: client, stream: stream}, nil
+	case bqStreamTypeCommitted:
+		return &bqManagedAppender{
+			client:    client,
+			newStream: newStream(managedwriter.CommittedStream, false),
+			offsets:   true,
+		}, nil
+	case bqStreamTypePending:
+		return &bqPendingAppender{
+			client:    client,
+			table:     table,
+			newStream: newStream(managedwriter.PendingStream, false),
+		}, nil
+	}
+	client.Close()
+	return nil, fmt.Errorf("stream type %v not recognised", conf.StorageWrite.StreamType)
+}
+
+//------------------------------------------------------------------------------
+
+// bigQueryStorageWriter writes batches of JSON messages as rows of a table with
+// the Storage Write API.
+type bigQueryStorageWriter struct {
+	schema        bigquery.Schema
+	desc          protoreflect.MessageDescriptor
+	ignoreUnknown bool
+	appender      bigQueryStorageAppender
+}
+
+func (w *bigQueryStorageWriter) writeBatch(ctx context.Context, batch service.MessageBatch) error {
+	var batchErr *service.BatchError
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = service.NewBatchError(batch, err)
+		}
+		batchErr.Failed(i, err)
+	}
+
+	rows := make([][]byte, 0, len(batch))
+	indexes := make([]int, 0, len(batch))
+	for i, msg := range batch {
+		b, err := msg.AsBytes()
+		if err != nil {
+			failed(i, err)
+			continue
+		}
+		row, err := bqRowFromJSON(w.desc, w.schema, b, w.ignoreUnknown)
+		if err != nil {
+			failed(i, err)
+			continue
+		}
+		rows = append(rows, row)
+		indexes = append(indexes, i)
+	}
+
+	// Appends containing invalid rows are rejected as a whole, in which case we
+	// report the invalid rows and append the remaining rows again.
+	for len(rows) > 0 {
+		rowErrs, err := w.appender.appendRows(ctx, rows)
+		if err != nil {
+			return err
+		}
+		if len(rowErrs) == 0 {
+			break
+		}
+
+		remainingRows, remainingIndexes := rows[:0:0], indexes[:0:0]
+		for j, row := range rows {
+			if rerr, exists := rowErrs[j]; exists {
+				failed(indexes[j], rerr)
+				continue
+			}
+			remainingRows = append(remainingRows, row)
+			remainingIndexes = append(remainingIndexes, indexes[j])
+		}
+		if len(remainingRows) == len(rows) {
+			return errors.New("append was rejected with errors for unknown rows")
+		}
+		rows, indexes = remainingRows, remainingIndexes
+	}
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (w *bigQueryStorageWriter) Close() error {
+	return w.appender.Close()
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+var bqTestSchema = bigquery.Schema{
+	{Name: "id", Type: bigquery.StringFieldType, Required: true},
+	{Name: "Count", Type: bigquery.IntegerFieldType},
+	{Name: "score", Type: bigquery.FloatFieldType},
+	{Name: "active", Type: bigquery.BooleanFieldType},
+	{Name: "created_at", Type: bigquery.TimestampFieldType},
+	{Name: "day", Type: bigquery.DateFieldType},
+	{Name: "price", Type: bigquery.NumericFieldType},
+	{Name: "payload", Type: bigquery.BytesFieldType},
+	{Name: "attributes", Type: bigquery.JSONFieldType},
+	{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+	{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+		{Name: "city", Type: bigquery.StringFieldType},
+		{Name: "zip", Type: bigquery.IntegerFieldType},
+	}},
+}
+
+func bqTestDecodeRow(t testing.TB, desc protoreflect.MessageDescriptor, row []byte) string {
+	t.Helper()
+
+	msg := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(row, msg))
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestBigQueryStorageWriteRowConversion(t *testing.T) {
+	desc, dp, err := bqDescriptorFromSchema(bqTestSchema)
+	require.NoError(t, err)
+	require.Len(t, dp.NestedType, 1)
+
+	row, err := bqRowFromJSON(desc, bqTestSchema, []byte(`{
+  "id": "foo",
+  "count": 12345678901234,
+  "score": 1.5,
+  "active": "true",
+  "created_at": "2024-01-02T03:04:05.123456Z",
+  "day": "1970-01-11",
+  "price": 10.25,
+  "payload": "aGVsbG8=",
+  "attributes": {"a":[1,2]},
+  "tags": ["a","b"],
+  "address": {"city":"London","zip":"123"},
+  "nope": null
+}`), false)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+  "id": "foo",
+  "count": "12345678901234",
+  "score": 1.5,
+  "active": true,
+  "created_at": "1704164645123456",
+  "day": 10,
+  "price": "10.25",
+  "payload": "aGVsbG8=",
+  "attributes": "{\"a\":[1,2]}",
+  "tags": ["a","b"],
+  "address": {"city":"London","zip":"123"}
+}`, bqTestDecodeRow(t, desc, row))
+
+	row, err = bqRowFromJSON(desc, bqTestSchema, []byte(`{"id":"bar","created_at":1704164645123456,"day":-1,"unknown":"x"}`), true)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"bar","created_at":"1704164645123456","day":-1}`, bqTestDecodeRow(t, desc, row))
+
+	for _, invalid := range []string{
+		`[]`,
+		`not json`,
+		`{"unknown":"x"}`,
+		`{"count":"nope"}`,
+		`{"tags":"a"}`,
+		`{"address":"London"}`,
+		`{"created_at":"yesterday"}`,
+		`{"payload":"!!!"}`,
+	} {
+		_, err := bqRowFromJSON(desc, bqTestSchema, []byte(invalid), false)
+		assert.Error(t, err, invalid)
+	}
+}
+
+type fakeBigQueryAppender struct {
+	appends [][][]byte
+	fn      func(rows [][]byte) (map[int]error, error)
+}
+
+func (f *fakeBigQueryAppender) appendRows(ctx context.Context, rows [][]byte) (map[int]error, error) {
+	f.appends = append(f.appends, rows)
+	return f.fn(rows)
+}
+
+func (f *fakeBigQueryAppender) Close() error {
+	return nil
+}
+
+func TestBigQueryStorageWriteRowErrors(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "id", Type: bigquery.StringFieldType},
+	}
+	desc, _, err := bqDescriptorFromSchema(schema)
+	require.NoError(t, err)
+
+	appender := &fakeBigQueryAppender{}
+	appender.fn = func(rows [][]byte) (map[int]error, error) {
+		rowErrs := map[int]error{}
+		for i, row := range rows {
+			if bqTestDecodeRow(t, desc, row) == `{"id":"bad"}` {
+				rowErrs[i] = errors.New("INVALID: bad row")
+			}
+		}
+		return rowErrs, nil
+	}
+
+	w := &bigQueryStorageWriter{schema: schema, desc: desc, appender: appender}
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a"}`)),
+		service.NewMessage([]byte(`{"id":"bad"}`)),
+		service.NewMessage([]byte(`{"nope":"b"}`)),
+		service.NewMessage([]byte(`{"id":"c"}`)),
+	}
+	indexer := batch.Index()
+
+	err = w.writeBatch(context.Background(), batch)
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.ErrorAs(t, err, &batchErr)
+
+	failed := map[int]string{}
+	batchErr.WalkMessagesIndexedBy(indexer, func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			failed[i] = err.Error()
+		}
+		return true
+	})
+	assert.Len(t, failed, 2)
+	assert.Equal(t, "INVALID: bad row", failed[1])
+	assert.Contains(t, failed[2], "does not match any column")
+
+	// The rejected append is retried without the bad row
+	require.Len(t, appender.appends, 2)
+	assert.Len(t, appender.appends[0], 3)
+	require.Len(t, appender.appends[1], 2)
+	assert.Equal(t, `{"id":"a"}`, bqTestDecodeRow(t, desc, appender.appends[1][0]))
+	assert.Equal(t, `{"id":"c"}`, bqTestDecodeRow(t, desc, appender.appends[1][1]))
+
+	// Errors of the append itself fail the entire batch
+	appender.fn = func(rows [][]byte) (map[int]error, error) {
+		return nil, errors.New("nope")
+	}
+	err = w.writeBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a"}`)),
+	})
+	require.EqualError(t, err, "nope")
+}
+
+func TestBigQueryStorageWriteConfig(t *testing.T) {
+	conf := gcpBigQueryConfFromYAML(t, `
+dataset: foo
+table: bar
+storage_write_api:
+  enabled: true
+  stream_type: PENDING
+`)
+	assert.True(t, conf.StorageWrite.Enabled)
+	assert.Equal(t, bqStreamTypePending, conf.StorageWrite.StreamType)
+
+	pConf, err := gcpBigQueryConfig().ParseYAML(`
+dataset: foo
+table: bar
+format: CSV
+storage_write_api:
+  enabled: true
+`, nil)
+	require.NoError(t, err)
+
+	_, err = gcpBigQueryOutputConfigFromParsed(pConf)
+	require.Error(t, err)
+}
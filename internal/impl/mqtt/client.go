@@ -42,6 +42,12 @@ const (
 	msFieldClientPassword          = "password"
 	msFieldClientKeepAlive         = "keepalive"
 	msFieldClientTLS               = "tls"
+	msFieldClientProtocolVersion   = "protocol_version"
+)
+
+const (
+	protocolVersion311 = "3.1.1"
+	protocolVersion5   = "5"
 )
 
 func clientFields() []*service.ConfigField {
@@ -97,6 +103,10 @@ func clientFields() []*service.ConfigField {
 			Default(30).
 			Advanced(),
 		service.NewTLSToggledField(msFieldClientTLS),
+		service.NewStringEnumField(msFieldClientProtocolVersion, protocolVersion311, protocolVersion5).
+			Description("The version of the MQTT protocol to connect with. Properties such as user properties, content types, message expiry and response topics are only supported with version `5`.").
+			Default(protocolVersion311).
+			Version("4.33.0"),
 	}
 }
 
@@ -110,6 +120,8 @@ type clientOptsBuilder struct {
 	tlsEnabled     bool
 	tlsConf        *tls.Config
 	will           willOpt
+
+	protocolVersion string
 }
 
 func clientOptsFromParsed(conf *service.ParsedConfig) (opts clientOptsBuilder, err error) {
@@ -156,6 +168,9 @@ func clientOptsFromParsed(conf *service.ParsedConfig) (opts clientOptsBuilder, e
 	if opts.tlsConf, opts.tlsEnabled, err = conf.FieldTLSToggled(msFieldClientTLS); err != nil {
 		return
 	}
+	if opts.protocolVersion, err = conf.FieldString(msFieldClientProtocolVersion); err != nil {
+		return
+	}
 	return
 }
 
@@ -0,0 +1,302 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/eclipse/paho.golang/paho/session/state"
+	"github.com/eclipse/paho.golang/paho/store/file"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+// The session expiry interval requested for persistent sessions, which
+// instructs the broker to never expire them.
+const sessionExpiryNever = math.MaxUint32
+
+var reasonCodeNames = map[byte]string{
+	0x80: "Unspecified error",
+	0x81: "Malformed Packet",
+	0x82: "Protocol Error",
+	0x83: "Implementation specific error",
+	0x84: "Unsupported Protocol Version",
+	0x85: "Client Identifier not valid",
+	0x86: "Bad User Name or Password",
+	0x87: "Not authorized",
+	0x88: "Server unavailable",
+	0x89: "Server busy",
+	0x8A: "Banned",
+	0x8B: "Server shutting down",
+	0x8C: "Bad authentication method",
+	0x8D: "Keep Alive timeout",
+	0x8E: "Session taken over",
+	0x8F: "Topic Filter invalid",
+	0x90: "Topic Name invalid",
+	0x91: "Packet Identifier in use",
+	0x92: "Packet Identifier not found",
+	0x93: "Receive Maximum exceeded",
+	0x94: "Topic Alias invalid",
+	0x95: "Packet too large",
+	0x96: "Message rate too high",
+	0x97: "Quota exceeded",
+	0x98: "Administrative action",
+	0x99: "Payload format invalid",
+	0x9A: "Retain not supported",
+	0x9B: "QoS not supported",
+	0x9C: "Use another server",
+	0x9D: "Server moved",
+	0x9E: "Shared Subscriptions not supported",
+	0x9F: "Connection rate exceeded",
+	0xA0: "Maximum connect time",
+	0xA1: "Subscription Identifiers not supported",
+	0xA2: "Wildcard Subscriptions not supported",
+}
+
+// reasonCodeError describes a failure reported by an MQTT 5 broker with a
+// reason code and an optional reason string.
+type reasonCodeError struct {
+	op     string
+	code   byte
+	reason string
+}
+
+func (e *reasonCodeError) Error() string {
+	msg := fmt.Sprintf("%v failed with reason code 0x%02X", e.op, e.code)
+	if name, exists := reasonCodeNames[e.code]; exists {
+		msg += " (" + name + ")"
+	}
+	if e.reason != "" {
+		msg += ": " + e.reason
+	}
+	return msg
+}
+
+func connackError(ca *paho.Connack) error {
+	if ca == nil || ca.ReasonCode < 0x80 {
+		return nil
+	}
+	var reason string
+	if ca.Properties != nil {
+		reason = ca.Properties.ReasonString
+	}
+	return &reasonCodeError{op: "connect", code: ca.ReasonCode, reason: reason}
+}
+
+func subackError(topics []string, sa *paho.Suback) error {
+	if sa == nil {
+		return nil
+	}
+	var reason string
+	if sa.Properties != nil {
+		reason = sa.Properties.ReasonString
+	}
+	var errs []error
+	for i, code := range sa.Reasons {
+		if code < 0x80 {
+			continue
+		}
+		op := "subscribe"
+		if i < len(topics) {
+			op = fmt.Sprintf("subscribe to topic '%v'", topics[i])
+		}
+		errs = append(errs, &reasonCodeError{op: op, code: code, reason: reason})
+	}
+	return errors.Join(errs...)
+}
+
+func publishResponseError(pr *paho.PublishResponse) error {
+	if pr == nil || pr.ReasonCode < 0x80 {
+		return nil
+	}
+	var reason string
+	if pr.Properties != nil {
+		reason = pr.Properties.ReasonString
+	}
+	return &reasonCodeError{op: "publish", code: pr.ReasonCode, reason: reason}
+}
+
+func disconnectError(d *paho.Disconnect) error {
+	var reason string
+	if d.Properties != nil {
+		reason = d.Properties.ReasonString
+	}
+	return &reasonCodeError{op: "connection", code: d.ReasonCode, reason: reason}
+}
+
+// dialV5 attempts to open a network connection to each configured URL in
+// turn, returning the first that succeeds.
+func (b *clientOptsBuilder) dialV5(ctx context.Context) (net.Conn, error) {
+	var errs []error
+	for _, u := range b.urls {
+		conn, err := b.dialURL(ctx, u)
+		if err == nil {
+			return packets.NewThreadSafeConn(conn), nil
+		}
+		errs = append(errs, fmt.Errorf("%v: %w", u.Redacted(), err))
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no urls specified")
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (b *clientOptsBuilder) dialURL(ctx context.Context, u *url.URL) (net.Conn, error) {
+	ctx, done := context.WithTimeout(ctx, b.connectTimeout)
+	defer done()
+
+	host := u.Host
+	switch u.Scheme {
+	case "tcp", "mqtt", "":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "1883")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", host)
+	case "ssl", "tls", "tcps", "mqtts":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "8883")
+		}
+		tlsConf := b.tlsConf
+		if tlsConf == nil {
+			tlsConf = &tls.Config{}
+		}
+		d := tls.Dialer{Config: tlsConf}
+		return d.DialContext(ctx, "tcp", host)
+	}
+	return nil, fmt.Errorf("url scheme '%v' is not supported with protocol version %v", u.Scheme, protocolVersion5)
+}
+
+// connectPacketV5 creates an MQTT 5 connect packet from the client options.
+func (b *clientOptsBuilder) connectPacketV5(cleanStart bool) *paho.Connect {
+	cp := &paho.Connect{
+		ClientID:   b.clientID,
+		KeepAlive:  uint16(b.keepAlive),
+		CleanStart: cleanStart,
+		Username:   b.username,
+		Password:   []byte(b.password),
+		Properties: &paho.ConnectProperties{
+			// Problem information is required in order for reason strings
+			// to be provided alongside reason codes.
+			RequestProblemInfo: true,
+		},
+	}
+	cp.UsernameFlag = b.username != ""
+	cp.PasswordFlag = b.password != ""
+	if !cleanStart {
+		expiry := uint32(sessionExpiryNever)
+		cp.Properties.SessionExpiryInterval = &expiry
+	}
+	if b.will.Enabled {
+		cp.WillMessage = &paho.WillMessage{
+			Retain:  b.will.Retained,
+			QoS:     b.will.QoS,
+			Topic:   b.will.Topic,
+			Payload: []byte(b.will.Payload),
+		}
+	}
+	return cp
+}
+
+// connectV5 dials the configured brokers and performs an MQTT 5 handshake,
+// the provided config is used for everything other than the connection.
+func (b *clientOptsBuilder) connectV5(ctx context.Context, conf paho.ClientConfig, cleanStart bool) (*paho.Client, error) {
+	conn, err := b.dialV5(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conf.ClientID = b.clientID
+	conf.Conn = conn
+	client := paho.NewClient(conf)
+
+	ctx, done := context.WithTimeout(ctx, b.connectTimeout)
+	defer done()
+
+	ca, err := client.Connect(ctx, b.connectPacketV5(cleanStart))
+	if caErr := connackError(ca); caErr != nil {
+		_ = conn.Close()
+		return nil, caErr
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func clientIsDone(client *paho.Client) bool {
+	select {
+	case <-client.Done():
+		return true
+	default:
+	}
+	return false
+}
+
+// newFileSessionState creates MQTT 5 session state that persists in-flight
+// packets within the provided directory.
+func newFileSessionState(dir string) (*state.State, error) {
+	if err := os.MkdirAll(dir, 0o770); err != nil {
+		return nil, fmt.Errorf("failed to create session store directory: %w", err)
+	}
+	clientStore, err := file.New(dir, "client_", ".pkt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session store: %w", err)
+	}
+	serverStore, err := file.New(dir, "server_", ".pkt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session store: %w", err)
+	}
+	return state.New(clientStore, serverStore), nil
+}
+
+// messageFromPublishV5 converts an MQTT 5 publish packet into a message, user
+// properties are added as metadata alongside the standard mqtt fields.
+func messageFromPublishV5(p *paho.Publish) *service.Message {
+	msg := service.NewMessage(p.Payload)
+	if props := p.Properties; props != nil {
+		for _, up := range props.User {
+			msg.MetaSetMut(up.Key, up.Value)
+		}
+		if props.ContentType != "" {
+			msg.MetaSetMut("mqtt_content_type", props.ContentType)
+		}
+		if props.ResponseTopic != "" {
+			msg.MetaSetMut("mqtt_response_topic", props.ResponseTopic)
+		}
+		if len(props.CorrelationData) > 0 {
+			msg.MetaSetMut("mqtt_correlation_data", string(props.CorrelationData))
+		}
+		if props.MessageExpiry != nil {
+			msg.MetaSetMut("mqtt_message_expiry", int64(*props.MessageExpiry))
+		}
+	}
+	msg.MetaSetMut("mqtt_duplicate", p.Duplicate())
+	msg.MetaSetMut("mqtt_qos", int(p.QoS))
+	msg.MetaSetMut("mqtt_retained", p.Retain)
+	msg.MetaSetMut("mqtt_topic", p.Topic)
+	msg.MetaSetMut("mqtt_message_id", int(p.PacketID))
+	return msg
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestReasonCodeErrors(t *testing.T) {
+	assert.NoError(t, connackError(&paho.Connack{ReasonCode: 0}))
+	assert.EqualError(t, connackError(&paho.Connack{
+		ReasonCode: 0x87,
+		Properties: &paho.ConnackProperties{ReasonString: "go away"},
+	}), "connect failed with reason code 0x87 (Not authorized): go away")
+
+	assert.EqualError(t, subackError([]string{"foo", "$share/bar/baz"}, &paho.Suback{
+		Reasons: []byte{1, 0x9E},
+	}), "subscribe to topic '$share/bar/baz' failed with reason code 0x9E (Shared Subscriptions not supported)")
+
+	assert.NoError(t, publishResponseError(&paho.PublishResponse{ReasonCode: 0x10}))
+	assert.EqualError(t, publishResponseError(&paho.PublishResponse{
+		ReasonCode: 0x97,
+	}), "publish failed with reason code 0x97 (Quota exceeded)")
+}
+
+func TestMessageFromPublishV5(t *testing.T) {
+	expiry := uint32(30)
+	pub := &paho.Publish{
+		PacketID: 5,
+		QoS:      1,
+		Topic:    "foo/bar",
+		Payload:  []byte("hello world"),
+		Properties: &paho.PublishProperties{
+			ContentType:     "text/plain",
+			ResponseTopic:   "foo/replies",
+			CorrelationData: []byte("abc"),
+			MessageExpiry:   &expiry,
+			User: paho.UserProperties{
+				{Key: "tenant", Value: "acme"},
+				{Key: "region", Value: "eu"},
+			},
+		},
+	}
+
+	msg := messageFromPublishV5(pub)
+
+	mBytes, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(mBytes))
+
+	meta := map[string]any{}
+	require.NoError(t, msg.MetaWalkMut(func(k string, v any) error {
+		meta[k] = v
+		return nil
+	}))
+	assert.Equal(t, map[string]any{
+		"tenant":                "acme",
+		"region":                "eu",
+		"mqtt_content_type":     "text/plain",
+		"mqtt_response_topic":   "foo/replies",
+		"mqtt_correlation_data": "abc",
+		"mqtt_message_expiry":   int64(30),
+		"mqtt_duplicate":        false,
+		"mqtt_qos":              1,
+		"mqtt_retained":         false,
+		"mqtt_topic":            "foo/bar",
+		"mqtt_message_id":       5,
+	}, meta)
+}
+
+func TestOutputV5PublishFromMessage(t *testing.T) {
+	conf, err := outputConfigSpec().ParseYAML(`
+urls: [ tcp://localhost:1883 ]
+protocol_version: "5"
+topic: ${! @mqtt_response_topic }
+qos: 2
+user_properties:
+  include_prefixes: [ tenant ]
+content_type: application/json
+message_expiry: 90s
+correlation_data: ${! @mqtt_correlation_data }
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newMQTTV5WriterFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	msg := service.NewMessage([]byte(`{"hello":"world"}`))
+	msg.MetaSetMut("tenant", "acme")
+	msg.MetaSetMut("region", "eu")
+	msg.MetaSetMut("mqtt_response_topic", "foo/replies")
+	msg.MetaSetMut("mqtt_correlation_data", "abc")
+
+	pub, err := w.publishFromMessage(msg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo/replies", pub.Topic)
+	assert.Equal(t, byte(2), pub.QoS)
+	assert.Equal(t, `{"hello":"world"}`, string(pub.Payload))
+	assert.Equal(t, "application/json", pub.Properties.ContentType)
+	assert.Equal(t, []byte("abc"), pub.Properties.CorrelationData)
+	assert.Empty(t, pub.Properties.ResponseTopic)
+	require.NotNil(t, pub.Properties.MessageExpiry)
+	assert.Equal(t, uint32(90), *pub.Properties.MessageExpiry)
+	assert.Equal(t, paho.UserProperties{{Key: "tenant", Value: "acme"}}, pub.Properties.User)
+}
+
+func TestOutputV5FieldsRequireProtocolVersion(t *testing.T) {
+	conf, err := outputConfigSpec().ParseYAML(`
+urls: [ tcp://localhost:1883 ]
+topic: foo
+content_type: application/json
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newMQTTWriterFromParsed(conf, service.MockResources())
+	require.EqualError(t, err, "field content_type requires protocol_version 5")
+
+	conf, err = outputConfigSpec().ParseYAML(`
+urls: [ tcp://localhost:1883 ]
+topic: foo
+user_properties:
+  include_patterns: [ .* ]
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newMQTTWriterFromParsed(conf, service.MockResources())
+	require.EqualError(t, err, "field user_properties requires protocol_version 5")
+}
+
+func TestInputSessionStoreRequiresPersistentSession(t *testing.T) {
+	conf, err := inputConfigSpec().ParseYAML(`
+urls: [ tcp://localhost:1883 ]
+topics: [ foo ]
+session_store_path: /tmp/mqtt_session
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newMQTTReaderFromParsed(conf, service.MockResources())
+	require.EqualError(t, err, "field session_store_path requires clean_session to be false")
+
+	conf, err = inputConfigSpec().ParseYAML(`
+urls: [ tcp://localhost:1883 ]
+topics: [ foo ]
+protocol_version: "5"
+clean_session: false
+session_store_path: /tmp/mqtt_session
+`, nil)
+	require.NoError(t, err)
+
+	r, err := newMQTTV5ReaderFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/mqtt_session", r.storePath)
+}
+
+func TestFileSessionState(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "session")
+
+	s, err := newFileSessionState(dir)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
@@ -16,6 +16,7 @@ package mqtt
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -28,6 +29,7 @@ const (
 	miFieldTopics       = "topics"
 	miFieldQoS          = "qos"
 	miFieldCleanSession = "clean_session"
+	miFieldSessionStore = "session_store_path"
 )
 
 func inputConfigSpec() *service.ConfigSpec {
@@ -46,7 +48,26 @@ This input adds the following metadata fields to each message:
 - mqtt_topic
 - mqtt_message_id
 
-You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].`).
+When `+"`protocol_version`"+` is set to `+"`5`"+` the following metadata fields are also added when the corresponding properties are present:
+
+- mqtt_content_type
+- mqtt_response_topic
+- mqtt_correlation_data
+- mqtt_message_expiry
+
+And each user property of a message is added as a metadata field of the same name.
+
+You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+
+== Delivery guarantees
+
+Messages with a QoS of 1 or 2 are only acknowledged to the broker once they have been processed by the pipeline. Messages that are rejected by the pipeline are acknowledged too, as the broker does not redeliver messages within a connection, and are therefore dropped unless `+"`auto_replay_nacks`"+` is enabled. In order for unacknowledged messages to be redelivered after a restart `+"`clean_session`"+` must be set to `+"`false`"+`, and `+"`session_store_path`"+` should be set to a directory where in-flight session state is persisted, as otherwise that state is held in memory and is lost when the process stops.
+
+== MQTT 5
+
+With `+"`protocol_version`"+` set to `+"`5`"+` topics may include shared subscriptions of the form `+"`$share/<group>/<topic>`"+`, in which case messages are distributed between all clients subscribed with the same group. When `+"`clean_session`"+` is `+"`false`"+` the broker is asked to keep the session indefinitely so that unacknowledged messages are redelivered after a reconnect.
+
+Failures reported by the broker, such as rejected connections and subscriptions, include the reason code and reason string provided by the broker.`).
 		Fields(clientFields()...).
 		Fields(
 			service.NewStringListField(miFieldTopics).
@@ -59,12 +80,24 @@ You can access these metadata fields using xref:configuration:interpolation.adoc
 				Description("Set whether the connection is non-persistent.").
 				Default(true).
 				Advanced(),
+			service.NewStringField(miFieldSessionStore).
+				Description("An optional directory in which the state of in-flight QoS 1 and 2 messages is persisted, allowing it to survive restarts. Requires `clean_session` to be `false`, and each client should be given its own directory.").
+				Optional().
+				Advanced().
+				Version("4.33.0"),
 			service.NewAutoRetryNacksToggleField(),
 		)
 }
 
 func init() {
 	err := service.RegisterInput("mqtt", inputConfigSpec(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+		if v, _ := conf.FieldString(msFieldClientProtocolVersion); v == protocolVersion5 {
+			rdr, err := newMQTTV5ReaderFromParsed(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksToggled(conf, rdr)
+		}
 		rdr, err := newMQTTReaderFromParsed(conf, mgr)
 		if err != nil {
 			return nil, err
@@ -81,6 +114,7 @@ type mqttReader struct {
 	topics        []string
 	qos           uint8
 	cleanSession  bool
+	storePath     string
 
 	client  mqtt.Client
 	msgChan chan mqtt.Message
@@ -113,10 +147,27 @@ func newMQTTReaderFromParsed(conf *service.ParsedConfig, mgr *service.Resources)
 	if m.cleanSession, err = conf.FieldBool(miFieldCleanSession); err != nil {
 		return nil, err
 	}
+	if m.storePath, err = sessionStorePathFromParsed(conf, m.cleanSession); err != nil {
+		return nil, err
+	}
 
 	return m, nil
 }
 
+func sessionStorePathFromParsed(conf *service.ParsedConfig, cleanSession bool) (string, error) {
+	if !conf.Contains(miFieldSessionStore) {
+		return "", nil
+	}
+	path, err := conf.FieldString(miFieldSessionStore)
+	if err != nil {
+		return "", err
+	}
+	if path != "" && cleanSession {
+		return "", fmt.Errorf("field %v requires %v to be false", miFieldSessionStore, miFieldCleanSession)
+	}
+	return path, nil
+}
+
 func (m *mqttReader) Connect(ctx context.Context) error {
 	m.cMut.Lock()
 	defer m.cMut.Unlock()
@@ -141,6 +192,7 @@ func (m *mqttReader) Connect(ctx context.Context) error {
 
 	conf := m.clientBuilder.apply(mqtt.NewClientOptions()).
 		SetCleanSession(m.cleanSession).
+		SetAutoAckDisabled(true).
 		SetConnectionLostHandler(func(client mqtt.Client, reason error) {
 			client.Disconnect(0)
 			closeMsgChan()
@@ -170,6 +222,10 @@ func (m *mqttReader) Connect(ctx context.Context) error {
 			}
 		})
 
+	if m.storePath != "" {
+		conf = conf.SetStore(mqtt.NewFileStore(m.storePath))
+	}
+
 	client := mqtt.NewClient(conf)
 
 	tok := client.Connect()
@@ -201,7 +257,7 @@ func (m *mqttReader) Connect(ctx context.Context) error {
 
 func (m *mqttReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
 	m.cMut.Lock()
-	msgChan := m.msgChan
+	client, msgChan := m.client, m.msgChan
 	m.cMut.Unlock()
 
 	if msgChan == nil {
@@ -227,7 +283,12 @@ func (m *mqttReader) Read(ctx context.Context) (*service.Message, service.AckFun
 		message.MetaSetMut("mqtt_message_id", int(msg.MessageID()))
 
 		return message, func(ctx context.Context, res error) error {
-			if res == nil {
+			// The broker does not redeliver messages on the same connection,
+			// and therefore nacks are acknowledged too, which means they are
+			// dropped unless auto_replay_nacks is enabled. Acknowledging a
+			// message of a closed connection would block indefinitely, the
+			// broker redelivers it after reconnecting instead.
+			if client.IsConnectionOpen() {
 				msg.Ack()
 			}
 			return nil
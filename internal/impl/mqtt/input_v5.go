@@ -0,0 +1,224 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/eclipse/paho.golang/paho/session/state"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mqttV5Reader struct {
+	clientBuilder clientOptsBuilder
+	topics        []string
+	qos           uint8
+	cleanSession  bool
+	storePath     string
+
+	session  *state.State
+	client   *paho.Client
+	msgChan  chan paho.PublishReceived
+	connDone <-chan struct{}
+	cMut     sync.Mutex
+
+	interruptChan chan struct{}
+	interruptOnce sync.Once
+
+	log *service.Logger
+}
+
+func newMQTTV5ReaderFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*mqttV5Reader, error) {
+	m := &mqttV5Reader{
+		interruptChan: make(chan struct{}),
+		log:           mgr.Logger(),
+	}
+
+	var err error
+	if m.clientBuilder, err = clientOptsFromParsed(conf); err != nil {
+		return nil, err
+	}
+
+	if m.topics, err = conf.FieldStringList(miFieldTopics); err != nil {
+		return nil, err
+	}
+	var tmpQoS int
+	if tmpQoS, err = conf.FieldInt(miFieldQoS); err != nil {
+		return nil, err
+	}
+	m.qos = uint8(tmpQoS)
+	if m.cleanSession, err = conf.FieldBool(miFieldCleanSession); err != nil {
+		return nil, err
+	}
+	if m.storePath, err = sessionStorePathFromParsed(conf, m.cleanSession); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *mqttV5Reader) Connect(ctx context.Context) error {
+	m.cMut.Lock()
+	defer m.cMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	connDone := make(chan struct{})
+	var connDoneOnce sync.Once
+	closeConn := func() {
+		connDoneOnce.Do(func() {
+			close(connDone)
+		})
+	}
+
+	// The session state outlives individual connections so that in-flight
+	// messages are resumed after reconnecting.
+	if m.storePath != "" && m.session == nil {
+		session, err := newFileSessionState(m.storePath)
+		if err != nil {
+			return err
+		}
+		m.session = session
+	}
+
+	msgChan := make(chan paho.PublishReceived)
+	conf := paho.ClientConfig{
+		EnableManualAcknowledgment: true,
+		OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+			func(pr paho.PublishReceived) (bool, error) {
+				select {
+				case msgChan <- pr:
+				case <-connDone:
+				case <-m.interruptChan:
+				}
+				return true, nil
+			},
+		},
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			m.log.Errorf("Connection closed by server: %v", disconnectError(d))
+			closeConn()
+		},
+		OnClientError: func(err error) {
+			m.log.Errorf("Connection lost due to: %v", err)
+			closeConn()
+		},
+	}
+	if m.session != nil {
+		conf.Session = m.session
+	}
+
+	client, err := m.clientBuilder.connectV5(ctx, conf, m.cleanSession)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		select {
+		case <-client.Done():
+			closeConn()
+		case <-connDone:
+		}
+	}()
+
+	subs := make([]paho.SubscribeOptions, 0, len(m.topics))
+	for _, topic := range m.topics {
+		subs = append(subs, paho.SubscribeOptions{
+			Topic: topic,
+			QoS:   m.qos,
+		})
+	}
+	sa, err := client.Subscribe(ctx, &paho.Subscribe{Subscriptions: subs})
+	if saErr := subackError(m.topics, sa); saErr != nil {
+		err = saErr
+	}
+	if err != nil {
+		closeConn()
+		_ = client.Disconnect(&paho.Disconnect{})
+		return err
+	}
+
+	m.client = client
+	m.msgChan = msgChan
+	m.connDone = connDone
+	return nil
+}
+
+func (m *mqttV5Reader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	m.cMut.Lock()
+	client, msgChan, connDone := m.client, m.msgChan, m.connDone
+	m.cMut.Unlock()
+
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case pr := <-msgChan:
+		message := messageFromPublishV5(pr.Packet)
+		return message, func(ctx context.Context, res error) error {
+			// Acknowledgements must be sent to the broker in the order that
+			// messages were received, and so withholding one for a rejected
+			// message would stall all that follow it. Nacks are therefore
+			// acknowledged too, which means they are dropped unless
+			// auto_replay_nacks is enabled.
+			if clientIsDone(pr.Client) {
+				// The broker redelivers unacknowledged messages of a
+				// persistent session once we reconnect.
+				return nil
+			}
+			return pr.Client.Ack(pr.Packet)
+		}, nil
+	case <-connDone:
+		m.cMut.Lock()
+		if m.client == client {
+			_ = client.Disconnect(&paho.Disconnect{})
+			m.client = nil
+			m.msgChan = nil
+			m.connDone = nil
+		}
+		m.cMut.Unlock()
+		return nil, nil, service.ErrNotConnected
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-m.interruptChan:
+		return nil, nil, service.ErrEndOfInput
+	}
+}
+
+func (m *mqttV5Reader) Close(ctx context.Context) (err error) {
+	m.interruptOnce.Do(func() {
+		close(m.interruptChan)
+	})
+
+	m.cMut.Lock()
+	defer m.cMut.Unlock()
+
+	if m.client != nil {
+		_ = m.client.Disconnect(&paho.Disconnect{})
+		m.client = nil
+		m.msgChan = nil
+		m.connDone = nil
+	}
+	if m.session != nil {
+		err = m.session.Close()
+		m.session = nil
+	}
+	return
+}
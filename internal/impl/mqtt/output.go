@@ -32,15 +32,37 @@ const (
 	moFieldWriteTimeout         = "write_timeout"
 	moFieldRetained             = "retained"
 	moFieldRetainedInterpolated = "retained_interpolated"
+	moFieldUserProperties       = "user_properties"
+	moFieldContentType          = "content_type"
+	moFieldMessageExpiry        = "message_expiry"
+	moFieldResponseTopic        = "response_topic"
+	moFieldCorrelationData      = "correlation_data"
 )
 
+// Fields that are only supported with protocol version 5.
+var moV5OnlyFields = []string{
+	moFieldContentType,
+	moFieldMessageExpiry,
+	moFieldResponseTopic,
+	moFieldCorrelationData,
+	moFieldUserProperties,
+}
+
 func outputConfigSpec() *service.ConfigSpec {
 	return service.NewConfigSpec().
 		Stable().
 		Categories("Services").
 		Summary("Pushes messages to an MQTT broker.").
 		Description(`
-The `+"`topic`"+` field can be dynamically set using function interpolations described xref:configuration:interpolation.adoc#bloblang-queries[here]. When sending batched messages these interpolations are performed per message part.`+service.OutputPerformanceDocs(true, false)).
+The `+"`topic`"+` field can be dynamically set using function interpolations described xref:configuration:interpolation.adoc#bloblang-queries[here]. When sending batched messages these interpolations are performed per message part.
+
+== MQTT 5
+
+When `+"`protocol_version`"+` is set to `+"`5`"+` messages can be published with properties: metadata can be added as user properties with the `+"`user_properties`"+` field, and the fields `+"`content_type`"+`, `+"`message_expiry`"+`, `+"`response_topic`"+` and `+"`correlation_data`"+` set the properties of the same name.
+
+Replies to requests consumed with the `+"`mqtt`"+` input can be published by setting `+"`topic`"+` to `+"`${! @mqtt_response_topic }`"+` and `+"`correlation_data`"+` to `+"`${! @mqtt_correlation_data }`"+`.
+
+A publish rejected by the broker results in an error that includes the reason code and reason string provided by the broker.`+service.OutputPerformanceDocs(true, false)).
 		Fields(clientFields()...).
 		Fields(
 			service.NewInterpolatedStringField(moFieldTopic).
@@ -61,6 +83,34 @@ The `+"`topic`"+` field can be dynamically set using function interpolations des
 				Advanced().
 				Optional().
 				Version("3.59.0"),
+			service.NewMetadataFilterField(moFieldUserProperties).
+				Description("Determine which (if any) metadata values should be added to messages as user properties. Requires `protocol_version` `5`.").
+				Optional().
+				Advanced().
+				Version("4.33.0"),
+			service.NewInterpolatedStringField(moFieldContentType).
+				Description("An optional content type to set for each message. Requires `protocol_version` `5`.").
+				Example("application/json").
+				Optional().
+				Advanced().
+				Version("4.33.0"),
+			service.NewDurationField(moFieldMessageExpiry).
+				Description("An optional lifetime of each message, after which the broker discards it if it has not yet been delivered. Requires `protocol_version` `5`.").
+				Example("60s").
+				Optional().
+				Advanced().
+				Version("4.33.0"),
+			service.NewInterpolatedStringField(moFieldResponseTopic).
+				Description("An optional topic that receivers of each message should publish responses to. Requires `protocol_version` `5`.").
+				Optional().
+				Advanced().
+				Version("4.33.0"),
+			service.NewInterpolatedStringField(moFieldCorrelationData).
+				Description("Optional correlation data to set for each message, used by requesters to match responses to requests. Requires `protocol_version` `5`.").
+				Example("${! @mqtt_correlation_data }").
+				Optional().
+				Advanced().
+				Version("4.33.0"),
 			service.NewOutputMaxInFlightField(),
 		)
 }
@@ -70,6 +120,10 @@ func init() {
 		if maxInFlight, err = conf.FieldMaxInFlight(); err != nil {
 			return
 		}
+		if v, _ := conf.FieldString(msFieldClientProtocolVersion); v == protocolVersion5 {
+			out, err = newMQTTV5WriterFromParsed(conf, mgr)
+			return
+		}
 		out, err = newMQTTWriterFromParsed(conf, mgr)
 		return
 	})
@@ -98,6 +152,12 @@ func newMQTTWriterFromParsed(conf *service.ParsedConfig, mgr *service.Resources)
 		log: mgr.Logger(),
 	}
 
+	for _, f := range moV5OnlyFields {
+		if conf.Contains(f) {
+			return nil, fmt.Errorf("field %v requires %v %v", f, msFieldClientProtocolVersion, protocolVersion5)
+		}
+	}
+
 	var err error
 	if m.clientBuilder, err = clientOptsFromParsed(conf); err != nil {
 		return nil, err
@@ -0,0 +1,234 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/eclipse/paho.golang/paho/session"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mqttV5Writer struct {
+	log *service.Logger
+
+	clientBuilder clientOptsBuilder
+
+	writeTimeout    time.Duration
+	topic           *service.InterpolatedString
+	retained        bool
+	retainedInterp  *service.InterpolatedString
+	qos             uint8
+	userProps       *service.MetadataFilter
+	contentType     *service.InterpolatedString
+	messageExpiry   *uint32
+	responseTopic   *service.InterpolatedString
+	correlationData *service.InterpolatedString
+
+	client  *paho.Client
+	connMut sync.RWMutex
+}
+
+func newMQTTV5WriterFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*mqttV5Writer, error) {
+	m := &mqttV5Writer{
+		log: mgr.Logger(),
+	}
+
+	var err error
+	if m.clientBuilder, err = clientOptsFromParsed(conf); err != nil {
+		return nil, err
+	}
+
+	if m.writeTimeout, err = conf.FieldDuration(moFieldWriteTimeout); err != nil {
+		return nil, err
+	}
+	if m.topic, err = conf.FieldInterpolatedString(moFieldTopic); err != nil {
+		return nil, err
+	}
+	if m.retained, err = conf.FieldBool(moFieldRetained); err != nil {
+		return nil, err
+	}
+	if iStrp, _ := conf.FieldString(moFieldRetainedInterpolated); iStrp != "" {
+		if m.retainedInterp, err = conf.FieldInterpolatedString(moFieldRetainedInterpolated); err != nil {
+			return nil, err
+		}
+	}
+	var tmpQoS int
+	if tmpQoS, err = conf.FieldInt(moFieldQoS); err != nil {
+		return nil, err
+	}
+	m.qos = uint8(tmpQoS)
+
+	if conf.Contains(moFieldUserProperties) {
+		if m.userProps, err = conf.FieldMetadataFilter(moFieldUserProperties); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(moFieldContentType) {
+		if m.contentType, err = conf.FieldInterpolatedString(moFieldContentType); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(moFieldMessageExpiry) {
+		var expiry time.Duration
+		if expiry, err = conf.FieldDuration(moFieldMessageExpiry); err != nil {
+			return nil, err
+		}
+		expirySecs := uint32(expiry / time.Second)
+		m.messageExpiry = &expirySecs
+	}
+	if conf.Contains(moFieldResponseTopic) {
+		if m.responseTopic, err = conf.FieldInterpolatedString(moFieldResponseTopic); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(moFieldCorrelationData) {
+		if m.correlationData, err = conf.FieldInterpolatedString(moFieldCorrelationData); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *mqttV5Writer) Connect(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	client, err := m.clientBuilder.connectV5(ctx, paho.ClientConfig{
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			m.log.Errorf("Connection closed by server: %v", disconnectError(d))
+		},
+		OnClientError: func(err error) {
+			m.log.Errorf("Connection lost due to: %v", err)
+		},
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	m.client = client
+	return nil
+}
+
+func (m *mqttV5Writer) publishFromMessage(msg *service.Message) (*paho.Publish, error) {
+	retained := m.retained
+	if m.retainedInterp != nil {
+		retainedStr, parseErr := m.retainedInterp.TryString(msg)
+		if parseErr != nil {
+			m.log.Errorf("Retained interpolation error: %v", parseErr)
+		} else if retained, parseErr = strconv.ParseBool(retainedStr); parseErr != nil {
+			m.log.Errorf("Error parsing boolean value from retained flag: %v \n", parseErr)
+		}
+	}
+
+	topicStr, err := m.topic.TryString(msg)
+	if err != nil {
+		return nil, fmt.Errorf("topic interpolation error: %w", err)
+	}
+
+	mBytes, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	props := &paho.PublishProperties{
+		MessageExpiry: m.messageExpiry,
+	}
+	_ = m.userProps.Walk(msg, func(key, value string) error {
+		props.User.Add(key, value)
+		return nil
+	})
+	if m.contentType != nil {
+		if props.ContentType, err = m.contentType.TryString(msg); err != nil {
+			return nil, fmt.Errorf("content type interpolation error: %w", err)
+		}
+	}
+	if m.responseTopic != nil {
+		if props.ResponseTopic, err = m.responseTopic.TryString(msg); err != nil {
+			return nil, fmt.Errorf("response topic interpolation error: %w", err)
+		}
+	}
+	if m.correlationData != nil {
+		if props.CorrelationData, err = m.correlationData.TryBytes(msg); err != nil {
+			return nil, fmt.Errorf("correlation data interpolation error: %w", err)
+		}
+		if len(props.CorrelationData) == 0 {
+			props.CorrelationData = nil
+		}
+	}
+
+	return &paho.Publish{
+		QoS:        m.qos,
+		Retain:     retained,
+		Topic:      topicStr,
+		Properties: props,
+		Payload:    mBytes,
+	}, nil
+}
+
+func (m *mqttV5Writer) Write(ctx context.Context, msg *service.Message) error {
+	m.connMut.RLock()
+	client := m.client
+	m.connMut.RUnlock()
+
+	if client == nil {
+		return service.ErrNotConnected
+	}
+
+	pub, err := m.publishFromMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, done := context.WithTimeout(ctx, m.writeTimeout)
+	defer done()
+
+	pr, err := client.Publish(ctx, pub)
+	if prErr := publishResponseError(pr); prErr != nil {
+		return prErr
+	}
+	if err != nil && (errors.Is(err, paho.ErrConnectionLost) || errors.Is(err, session.ErrNoConnection) || clientIsDone(client)) {
+		m.connMut.Lock()
+		if m.client == client {
+			_ = client.Disconnect(&paho.Disconnect{})
+			m.client = nil
+		}
+		m.connMut.Unlock()
+		return service.ErrNotConnected
+	}
+	return err
+}
+
+func (m *mqttV5Writer) Close(context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.client != nil {
+		_ = m.client.Disconnect(&paho.Disconnect{})
+		m.client = nil
+	}
+	return nil
+}
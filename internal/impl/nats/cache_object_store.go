@@ -0,0 +1,190 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func natsObjectStoreCacheConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.33.0").
+		Summary("Cache key/values in a NATS object store bucket.").
+		Description(`
+Each key is stored as an object with the key as its name, values larger than the chunk size of the bucket are chunked by the object store. TTLs are not supported per key, but can be configured for the entire bucket.
+
+The ` + "`add`" + ` operation checks whether an object already exists before writing it and therefore, unlike ` + "`nats_kv`" + `, it is not atomic.
+
+` + connectionNameDescription() + authDescription()).
+		Fields(objectStoreDocs()...)
+}
+
+func init() {
+	err := service.RegisterCache(
+		"nats_object_store", natsObjectStoreCacheConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Cache, error) {
+			return newObjectStoreCache(conf, mgr)
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+type objectStoreCache struct {
+	connDetails connectionDetails
+	bucket      string
+
+	log *service.Logger
+
+	shutSig *shutdown.Signaller
+
+	connMut  sync.RWMutex
+	natsConn *nats.Conn
+	objStore jetstream.ObjectStore
+}
+
+func newObjectStoreCache(conf *service.ParsedConfig, mgr *service.Resources) (*objectStoreCache, error) {
+	p := &objectStoreCache{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if p.connDetails, err = connectionDetailsFromParsed(conf, mgr); err != nil {
+		return nil, err
+	}
+
+	if p.bucket, err = conf.FieldString(osFieldBucket); err != nil {
+		return nil, err
+	}
+
+	err = p.connect(context.Background())
+	return p, err
+}
+
+func (p *objectStoreCache) disconnect() {
+	p.connMut.Lock()
+	defer p.connMut.Unlock()
+
+	if p.natsConn != nil {
+		p.natsConn.Close()
+		p.natsConn = nil
+	}
+	p.objStore = nil
+}
+
+func (p *objectStoreCache) connect(ctx context.Context) error {
+	p.connMut.Lock()
+	defer p.connMut.Unlock()
+
+	if p.natsConn != nil {
+		return nil
+	}
+
+	var err error
+	if p.natsConn, err = p.connDetails.get(ctx); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			p.natsConn.Close()
+			p.natsConn = nil
+		}
+	}()
+
+	var js jetstream.JetStream
+	if js, err = jetstream.New(p.natsConn); err != nil {
+		return err
+	}
+
+	if p.objStore, err = js.ObjectStore(ctx, p.bucket); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *objectStoreCache) Get(ctx context.Context, key string) ([]byte, error) {
+	p.connMut.RLock()
+	defer p.connMut.RUnlock()
+
+	value, err := p.objStore.GetBytes(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrObjectNotFound) {
+			err = service.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+func (p *objectStoreCache) Set(ctx context.Context, key string, value []byte, _ *time.Duration) error {
+	p.connMut.RLock()
+	defer p.connMut.RUnlock()
+
+	_, err := p.objStore.PutBytes(ctx, key, value)
+	return err
+}
+
+func (p *objectStoreCache) Add(ctx context.Context, key string, value []byte, _ *time.Duration) error {
+	p.connMut.RLock()
+	defer p.connMut.RUnlock()
+
+	if _, err := p.objStore.GetInfo(ctx, key); err == nil {
+		return service.ErrKeyAlreadyExists
+	} else if !errors.Is(err, jetstream.ErrObjectNotFound) {
+		return err
+	}
+
+	_, err := p.objStore.PutBytes(ctx, key, value)
+	return err
+}
+
+func (p *objectStoreCache) Delete(ctx context.Context, key string) error {
+	p.connMut.RLock()
+	defer p.connMut.RUnlock()
+
+	err := p.objStore.Delete(ctx, key)
+	if errors.Is(err, jetstream.ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (p *objectStoreCache) Close(ctx context.Context) error {
+	go func() {
+		p.disconnect()
+		p.shutSig.TriggerHasStopped()
+	}()
+	select {
+	case <-p.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 
 const (
 	kvFieldBucket = "bucket"
+	osFieldBucket = "bucket"
 )
 
 const (
@@ -58,3 +59,17 @@ func kvDocs(extraFields ...*service.ConfigField) []*service.ConfigField {
 
 	return fields
 }
+
+func objectStoreDocs(extraFields ...*service.ConfigField) []*service.ConfigField {
+	fields := append(
+		connectionHeadFields(),
+		[]*service.ConfigField{
+			service.NewStringField(osFieldBucket).
+				Description("The name of the object store bucket.").Example("my_bucket"),
+		}...,
+	)
+	fields = append(fields, extraFields...)
+	fields = append(fields, connectionTailFields()...)
+
+	return fields
+}
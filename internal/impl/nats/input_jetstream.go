@@ -40,6 +40,10 @@ func natsJetStreamInputConfig() *service.ConfigSpec {
 
 In the case where a stream being consumed is mirrored from a different JetStream domain the stream cannot be resolved from the subject name alone, and so the stream name as well as the subject (if applicable) must both be specified.
 
+== Provisioning
+
+The stream and a durable pull consumer can optionally be created, or updated to match the configuration, when the input connects by enabling the fields ` + "`" + psFieldProvisionStream + "`" + ` and ` + "`" + pcFieldProvisionConsumer + "`" + ` respectively. Otherwise they are expected to exist already, or an ephemeral consumer is created.
+
 == Metadata
 
 This input adds the following metadata fields to each message:
@@ -96,6 +100,12 @@ xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
 			Description("The maximum number of outstanding acks to be allowed before consuming is halted.").
 			Advanced().
 			Default(1024)).
+		Field(streamProvisionDocs()).
+		Field(consumerProvisionDocs()).
+		LintRule(`root = match {
+			this.provision_consumer.enabled.or(false) && this.durable.or("") == "" => [ "field 'durable' is required when 'provision_consumer' is enabled" ],
+			this.provision_consumer.enabled.or(false) && this.stream.or("") == "" && !this.provision_stream.enabled.or(false) => [ "field 'stream' is required when 'provision_consumer' is enabled" ],
+			}`).
 		Fields(connectionTailFields()...).
 		Field(inputTracingDocs())
 }
@@ -120,6 +130,7 @@ func init() {
 type jetStreamReader struct {
 	connDetails   connectionDetails
 	deliverOpt    nats.SubOpt
+	deliverPolicy nats.DeliverPolicy
 	subject       string
 	queue         string
 	stream        string
@@ -129,6 +140,9 @@ type jetStreamReader struct {
 	ackWait       time.Duration
 	maxAckPending int
 
+	streamProvision   *streamProvision
+	consumerProvision *consumerProvision
+
 	log *service.Logger
 
 	connMut  sync.Mutex
@@ -156,12 +170,16 @@ func newJetStreamReaderFromConfig(conf *service.ParsedConfig, mgr *service.Resou
 	switch deliver {
 	case "all":
 		j.deliverOpt = nats.DeliverAll()
+		j.deliverPolicy = nats.DeliverAllPolicy
 	case "last":
 		j.deliverOpt = nats.DeliverLast()
+		j.deliverPolicy = nats.DeliverLastPolicy
 	case "last_per_subject":
 		j.deliverOpt = nats.DeliverLastPerSubject()
+		j.deliverPolicy = nats.DeliverLastPerSubjectPolicy
 	case "new":
 		j.deliverOpt = nats.DeliverNew()
+		j.deliverPolicy = nats.DeliverNewPolicy
 	default:
 		return nil, fmt.Errorf("deliver option %v was not recognised", deliver)
 	}
@@ -190,11 +208,43 @@ func newJetStreamReaderFromConfig(conf *service.ParsedConfig, mgr *service.Resou
 			return nil, err
 		}
 	}
+	if j.streamProvision, err = streamProvisionFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if j.streamProvision != nil {
+		if j.stream == "" {
+			j.stream = j.streamProvision.cfg.Name
+		} else if j.stream != j.streamProvision.cfg.Name {
+			return nil, errors.New("the name of the provisioned stream must match the field 'stream'")
+		}
+	}
+
+	if j.consumerProvision, err = consumerProvisionFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if j.consumerProvision != nil {
+		if j.stream == "" || j.durable == "" {
+			return nil, errors.New("stream and durable are required when provisioning a consumer")
+		}
+		// A subscription bound to the consumer must match its filter.
+		pcfg := j.consumerProvision.cfg
+		if j.subject != "" && len(pcfg.FilterSubjects) > 0 {
+			return nil, errors.New("field 'subject' must be empty when provisioning a consumer with multiple filter subjects")
+		}
+		if j.subject != "" && pcfg.FilterSubject != "" && j.subject != pcfg.FilterSubject {
+			return nil, errors.New("field 'subject' must match the filter subject of the provisioned consumer")
+		}
+	}
+
 	if conf.Contains("bind") {
 		if j.bind, err = conf.FieldBool("bind"); err != nil {
 			return nil, err
 		}
 	}
+	if j.consumerProvision != nil {
+		// Provisioned consumers are pull consumers that we bind to.
+		j.bind = true
+	}
 	if j.bind {
 		if j.stream == "" && j.durable == "" {
 			return nil, errors.New("stream or durable is required, when bind is true")
@@ -255,6 +305,17 @@ func (j *jetStreamReader) Connect(ctx context.Context) (err error) {
 		return err
 	}
 
+	if j.streamProvision != nil {
+		if err = j.streamProvision.provision(jCtx); err != nil {
+			return fmt.Errorf("failed to provision stream: %w", err)
+		}
+	}
+	if j.consumerProvision != nil {
+		if err = j.consumerProvision.provision(jCtx, j.stream, j.durable, j.deliverPolicy, j.ackWait, j.maxAckPending); err != nil {
+			return fmt.Errorf("failed to provision consumer: %w", err)
+		}
+	}
+
 	if j.bind && j.stream != "" && j.durable != "" {
 		info, err := jCtx.ConsumerInfo(j.stream, j.durable)
 		if err != nil {
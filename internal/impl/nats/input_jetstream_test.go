@@ -16,7 +16,9 @@ package nats
 
 import (
 	"testing"
+	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -165,4 +167,124 @@ bind: false
 		_, err = newJetStreamReaderFromConfig(conf, service.MockResources())
 		require.Error(t, err)
 	})
+
+	t.Run("Provisioned stream and consumer", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+durable: foodurable
+deliver: new
+provision_stream:
+  enabled: true
+  name: foostream
+  subjects: [ foo.> ]
+  retention: workqueue
+provision_consumer:
+  enabled: true
+  filter_subjects: [ foo.bar ]
+  max_deliver: 5
+  backoff: [ 1s, 10s ]
+  replay_policy: original
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		j, err := newJetStreamReaderFromConfig(conf, service.MockResources())
+		require.NoError(t, err)
+
+		assert.Equal(t, "foostream", j.stream)
+		assert.True(t, j.bind)
+		assert.Equal(t, nats.DeliverNewPolicy, j.deliverPolicy)
+		assert.Equal(t, nats.WorkQueuePolicy, j.streamProvision.cfg.Retention)
+		assert.True(t, j.streamProvision.hasRetention)
+		assert.False(t, j.streamProvision.hasReplicas)
+		assert.Equal(t, nats.ConsumerConfig{
+			FilterSubject: "foo.bar",
+			AckPolicy:     nats.AckExplicitPolicy,
+			MaxDeliver:    5,
+			BackOff:       []time.Duration{time.Second, 10 * time.Second},
+			ReplayPolicy:  nats.ReplayOriginalPolicy,
+		}, j.consumerProvision.cfg)
+		assert.True(t, j.consumerProvision.hasFilterSubjects)
+		assert.True(t, j.consumerProvision.hasMaxDeliver)
+		assert.True(t, j.consumerProvision.hasBackoff)
+	})
+
+	t.Run("Provisioned consumer with too few deliveries", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+stream: foostream
+durable: foodurable
+provision_consumer:
+  enabled: true
+  max_deliver: 2
+  backoff: [ 1s, 10s ]
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		_, err = newJetStreamReaderFromConfig(conf, service.MockResources())
+		require.EqualError(t, err, "max_deliver must be greater than the 2 backoff durations")
+	})
+
+	t.Run("Provisioned consumer subject mismatch", func(t *testing.T) {
+		for _, inputConfig := range []string{
+			`
+urls: [ url1 ]
+subject: foo.baz
+stream: foostream
+durable: foodurable
+provision_consumer:
+  enabled: true
+  filter_subjects: [ foo.bar ]
+`,
+			`
+urls: [ url1 ]
+subject: foo.bar
+stream: foostream
+durable: foodurable
+provision_consumer:
+  enabled: true
+  filter_subjects: [ foo.bar, foo.baz ]
+`,
+		} {
+			conf, err := spec.ParseYAML(inputConfig, env)
+			require.NoError(t, err)
+
+			_, err = newJetStreamReaderFromConfig(conf, service.MockResources())
+			require.Error(t, err, inputConfig)
+		}
+	})
+
+	t.Run("Provisioned consumer without durable", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+stream: foostream
+provision_consumer:
+  enabled: true
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		_, err = newJetStreamReaderFromConfig(conf, service.MockResources())
+		require.Error(t, err)
+	})
+
+	t.Run("Provisioned stream name mismatch", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+stream: foostream
+provision_stream:
+  enabled: true
+  name: barstream
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		_, err = newJetStreamReaderFromConfig(conf, service.MockResources())
+		require.Error(t, err)
+	})
 }
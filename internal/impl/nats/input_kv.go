@@ -16,21 +16,28 @@ package nats
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"sync"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 
+	"github.com/Jeffail/checkpoint"
 	"github.com/Jeffail/shutdown"
 
 	"github.com/redpanda-data/benthos/v4/public/service"
 )
 
 const (
-	kviFieldKey            = "key"
-	kviFieldIgnoreDeletes  = "ignore_deletes"
-	kviFieldIncludeHistory = "include_history"
-	kviFieldMetaOnly       = "meta_only"
+	kviFieldKey              = "key"
+	kviFieldIgnoreDeletes    = "ignore_deletes"
+	kviFieldIncludeHistory   = "include_history"
+	kviFieldMetaOnly         = "meta_only"
+	kviFieldRevisionCache    = "revision_cache"
+	kviFieldRevisionCacheKey = "revision_cache_key"
+	kviFieldSnapshot         = "initial_snapshot"
 )
 
 func natsKVInputConfig() *service.ConfigSpec {
@@ -40,6 +47,10 @@ func natsKVInputConfig() *service.ConfigSpec {
 		Version("4.12.0").
 		Summary("Watches for updates in a NATS key-value bucket.").
 		Description(`
+== Resuming watches
+
+When a ` + "`" + kviFieldRevisionCache + "`" + ` is configured the revision of the latest update that has been processed, along with all updates before it, is stored in that cache resource. When the input is started again the watch resumes from the update following the stored revision, delivering every change made to the watched keys in the meantime. The initial snapshot is only delivered when no revision has been stored yet.
+
 == Metadata
 
 This input adds the following metadata fields to each message:
@@ -72,6 +83,20 @@ This input adds the following metadata fields to each message:
 				Description("Retrieve only the metadata of the entry").
 				Default(false).
 				Advanced(),
+			service.NewBoolField(kviFieldSnapshot).
+				Description("Whether to begin the watch by delivering the current value of each key, when set to `false` only updates made after the watch begins are delivered. When resuming a watch from a stored revision this field is ignored.").
+				Default(true).
+				Advanced().
+				Version("4.33.0"),
+			service.NewStringField(kviFieldRevisionCache).
+				Description("A https://www.docs.redpanda.com/redpanda-connect/components/caches/about[cache resource^] to use for storing the revision of the latest update that has been processed, this allows the watch to resume from that revision upon restart.").
+				Optional().
+				Version("4.33.0"),
+			service.NewStringField(kviFieldRevisionCacheKey).
+				Description("The key identifier used when storing the latest processed revision.").
+				Default("nats_kv_revision").
+				Advanced().
+				Version("4.33.0"),
 		}...)...)
 }
 
@@ -98,20 +123,28 @@ type kvReader struct {
 	ignoreDeletes  bool
 	includeHistory bool
 	metaOnly       bool
+	snapshot       bool
+	revCache       string
+	revCacheKey    string
 
-	log *service.Logger
+	mgr          *service.Resources
+	log          *service.Logger
+	checkpointer *checkpoint.Capped[uint64]
 
 	shutSig *shutdown.Signaller
 
 	connMut  sync.Mutex
 	natsConn *nats.Conn
 	watcher  jetstream.KeyWatcher
+	lastRev  uint64
 }
 
 func newKVReader(conf *service.ParsedConfig, mgr *service.Resources) (*kvReader, error) {
 	r := &kvReader{
-		log:     mgr.Logger(),
-		shutSig: shutdown.NewSignaller(),
+		mgr:          mgr,
+		log:          mgr.Logger(),
+		checkpointer: checkpoint.NewCapped[uint64](1024),
+		shutSig:      shutdown.NewSignaller(),
 	}
 
 	var err error
@@ -139,6 +172,23 @@ func newKVReader(conf *service.ParsedConfig, mgr *service.Resources) (*kvReader,
 		return nil, err
 	}
 
+	if r.snapshot, err = conf.FieldBool(kviFieldSnapshot); err != nil {
+		return nil, err
+	}
+
+	if conf.Contains(kviFieldRevisionCache) {
+		if r.revCache, err = conf.FieldString(kviFieldRevisionCache); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(r.revCache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", r.revCache)
+		}
+	}
+
+	if r.revCacheKey, err = conf.FieldString(kviFieldRevisionCacheKey); err != nil {
+		return nil, err
+	}
+
 	return r, nil
 }
 
@@ -186,6 +236,23 @@ func (r *kvReader) Connect(ctx context.Context) (err error) {
 		watchOpts = append(watchOpts, jetstream.MetaOnly())
 	}
 
+	// Prefer the last revision we've delivered ourselves when reconnecting,
+	// and otherwise resume from the last revision stored in the cache.
+	lastRev := r.lastRev
+	if lastRev == 0 && r.revCache != "" {
+		if lastRev, err = r.getStoredRevision(ctx); err != nil {
+			return err
+		}
+		if lastRev > 0 {
+			r.log.Infof("Resuming watch from revision %v", lastRev+1)
+		}
+	}
+	if lastRev > 0 {
+		watchOpts = append(watchOpts, jetstream.ResumeFromRevision(lastRev+1))
+	} else if !r.snapshot {
+		watchOpts = append(watchOpts, jetstream.UpdatesOnly())
+	}
+
 	r.watcher, err = kv.Watch(ctx, r.key, watchOpts...)
 	if err != nil {
 		return err
@@ -193,6 +260,39 @@ func (r *kvReader) Connect(ctx context.Context) (err error) {
 	return nil
 }
 
+func (r *kvReader) getStoredRevision(ctx context.Context) (uint64, error) {
+	var revBytes []byte
+	var cacheErr error
+	if err := r.mgr.AccessCache(ctx, r.revCache, func(c service.Cache) {
+		if revBytes, cacheErr = c.Get(ctx, r.revCacheKey); errors.Is(cacheErr, service.ErrKeyNotFound) {
+			cacheErr = nil
+		}
+	}); err != nil {
+		return 0, fmt.Errorf("failed to obtain latest processed revision: %w", err)
+	}
+	if cacheErr != nil {
+		return 0, fmt.Errorf("failed to obtain latest processed revision: %w", cacheErr)
+	}
+	if len(revBytes) == 0 {
+		return 0, nil
+	}
+	rev, err := strconv.ParseUint(string(revBytes), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse latest processed revision: %w", err)
+	}
+	return rev, nil
+}
+
+func (r *kvReader) storeRevision(ctx context.Context, rev uint64) error {
+	var setErr error
+	if err := r.mgr.AccessCache(ctx, r.revCache, func(c service.Cache) {
+		setErr = c.Set(ctx, r.revCacheKey, []byte(strconv.FormatUint(rev, 10)), nil)
+	}); err != nil {
+		return err
+	}
+	return setErr
+}
+
 func (r *kvReader) disconnect() {
 	r.connMut.Lock()
 	defer r.connMut.Unlock()
@@ -241,8 +341,31 @@ func (r *kvReader) Read(ctx context.Context) (*service.Message, service.AckFunc,
 			metaKVOperation, entry.Operation().String(),
 		).Debugf("Received kv bucket update")
 
+		r.connMut.Lock()
+		r.lastRev = entry.Revision()
+		r.connMut.Unlock()
+
+		if r.revCache == "" {
+			return newMessageFromKVEntry(entry), func(ctx context.Context, res error) error {
+				return nil
+			}, nil
+		}
+
+		release, err := r.checkpointer.Track(ctx, entry.Revision(), 1)
+		if err != nil {
+			return nil, nil, err
+		}
 		return newMessageFromKVEntry(entry), func(ctx context.Context, res error) error {
-			return nil
+			if res != nil {
+				// Leaving the revision tracked prevents the checkpoint from
+				// moving past an entry that wasn't delivered.
+				return nil
+			}
+			highestRev := release()
+			if highestRev == nil {
+				return nil
+			}
+			return r.storeRevision(ctx, *highestRev)
 		}, nil
 	}
 }
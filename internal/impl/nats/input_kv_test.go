@@ -97,4 +97,38 @@ auth:
 		_, err = newJetStreamReaderFromConfig(conf, service.MockResources())
 		require.Error(t, err)
 	})
+
+	t.Run("Revision cache", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+bucket: testbucket
+initial_snapshot: false
+revision_cache: foocache
+revision_cache_key: fookey
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		e, err := newKVReader(conf, service.MockResources(service.MockResourcesOptAddCache("foocache")))
+		require.NoError(t, err)
+
+		assert.False(t, e.snapshot)
+		assert.Equal(t, "foocache", e.revCache)
+		assert.Equal(t, "fookey", e.revCacheKey)
+	})
+
+	t.Run("Missing revision cache", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+bucket: testbucket
+revision_cache: foocache
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		_, err = newKVReader(conf, service.MockResources())
+		require.Error(t, err)
+	})
 }
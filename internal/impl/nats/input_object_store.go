@@ -0,0 +1,301 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	osiFieldWatch         = "watch"
+	osiFieldUpdatesOnly   = "updates_only"
+	osiFieldIgnoreDeletes = "ignore_deletes"
+)
+
+func natsObjectStoreInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.33.0").
+		Summary("Reads objects from a NATS object store bucket.").
+		Description(`
+By default the input lists the objects present in the bucket when it connects, creates a message for each of them and shuts down once they have all been consumed. When ` + "`" + osiFieldWatch + "`" + ` is set the input instead watches the bucket for changes and creates a message for each object that is added or updated.
+
+Objects are read in their entirety, chunked objects are reassembled before being emitted as a single message.
+
+== Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- nats_object_store_bucket
+- nats_object_store_name
+- nats_object_store_nuid
+- nats_object_store_size
+- nats_object_store_chunks
+- nats_object_store_digest
+- nats_object_store_mod_time
+- nats_object_store_deleted
+- All object headers
+` + "```" + `
+
+` + connectionNameDescription() + authDescription()).
+		Fields(objectStoreDocs([]*service.ConfigField{
+			service.NewAutoRetryNacksToggleField(),
+			service.NewBoolField(osiFieldWatch).
+				Description("Whether to watch the bucket for changes rather than consuming the objects present upon connecting and then shutting down.").
+				Default(false),
+			service.NewBoolField(osiFieldUpdatesOnly).
+				Description("When watching the bucket, only consume objects that are added or updated after the watch begins, rather than starting with the objects already present.").
+				Default(false).
+				Advanced(),
+			service.NewBoolField(osiFieldIgnoreDeletes).
+				Description("When watching the bucket, do not send delete markers as messages. Delete markers are empty messages with the metadata field `nats_object_store_deleted` set to `true`.").
+				Default(false).
+				Advanced(),
+		}...)...)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"nats_object_store", natsObjectStoreInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			reader, err := newObjectStoreReader(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksToggled(conf, reader)
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+type objectStoreReader struct {
+	connDetails   connectionDetails
+	bucket        string
+	watch         bool
+	updatesOnly   bool
+	ignoreDeletes bool
+
+	log *service.Logger
+
+	shutSig *shutdown.Signaller
+
+	connMut  sync.Mutex
+	natsConn *nats.Conn
+	objStore jetstream.ObjectStore
+	watcher  jetstream.ObjectWatcher
+	listed   []*jetstream.ObjectInfo
+}
+
+func newObjectStoreReader(conf *service.ParsedConfig, mgr *service.Resources) (*objectStoreReader, error) {
+	r := &objectStoreReader{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if r.connDetails, err = connectionDetailsFromParsed(conf, mgr); err != nil {
+		return nil, err
+	}
+
+	if r.bucket, err = conf.FieldString(osFieldBucket); err != nil {
+		return nil, err
+	}
+
+	if r.watch, err = conf.FieldBool(osiFieldWatch); err != nil {
+		return nil, err
+	}
+
+	if r.updatesOnly, err = conf.FieldBool(osiFieldUpdatesOnly); err != nil {
+		return nil, err
+	}
+
+	if r.ignoreDeletes, err = conf.FieldBool(osiFieldIgnoreDeletes); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *objectStoreReader) Connect(ctx context.Context) (err error) {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.natsConn != nil {
+		return nil
+	}
+
+	defer func() {
+		if err != nil {
+			if r.watcher != nil {
+				_ = r.watcher.Stop()
+				r.watcher = nil
+			}
+			if r.natsConn != nil {
+				r.natsConn.Close()
+				r.natsConn = nil
+			}
+		}
+	}()
+
+	if r.natsConn, err = r.connDetails.get(ctx); err != nil {
+		return err
+	}
+
+	js, err := jetstream.New(r.natsConn)
+	if err != nil {
+		return err
+	}
+
+	if r.objStore, err = js.ObjectStore(ctx, r.bucket); err != nil {
+		return err
+	}
+
+	if r.watch {
+		var watchOpts []jetstream.WatchOpt
+		if r.updatesOnly {
+			watchOpts = append(watchOpts, jetstream.UpdatesOnly())
+		}
+		if r.ignoreDeletes {
+			watchOpts = append(watchOpts, jetstream.IgnoreDeletes())
+		}
+		r.watcher, err = r.objStore.Watch(ctx, watchOpts...)
+		return err
+	}
+
+	if r.listed, err = r.objStore.List(ctx); err != nil {
+		if !errors.Is(err, jetstream.ErrNoObjectsFound) {
+			return err
+		}
+		err = nil
+	}
+	return nil
+}
+
+func (r *objectStoreReader) disconnect() {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.watcher != nil {
+		_ = r.watcher.Stop()
+		r.watcher = nil
+	}
+	if r.natsConn != nil {
+		r.natsConn.Close()
+		r.natsConn = nil
+	}
+	r.objStore = nil
+}
+
+func (r *objectStoreReader) nextInfo(ctx context.Context) (*jetstream.ObjectInfo, error) {
+	r.connMut.Lock()
+	if r.objStore == nil {
+		r.connMut.Unlock()
+		return nil, service.ErrNotConnected
+	}
+	if !r.watch {
+		defer r.connMut.Unlock()
+		if len(r.listed) == 0 {
+			return nil, service.ErrEndOfInput
+		}
+		var info *jetstream.ObjectInfo
+		info, r.listed = r.listed[0], r.listed[1:]
+		return info, nil
+	}
+	watcher := r.watcher
+	r.connMut.Unlock()
+
+	for {
+		var info *jetstream.ObjectInfo
+		var open bool
+		select {
+		case info, open = <-watcher.Updates():
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if !open {
+			r.disconnect()
+			return nil, service.ErrNotConnected
+		}
+
+		// A nil entry marks the end of the initial set of objects.
+		if info != nil {
+			return info, nil
+		}
+	}
+}
+
+func (r *objectStoreReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	for {
+		info, err := r.nextInfo(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		r.log.With(
+			metaObjectStoreBucket, info.Bucket,
+			metaObjectStoreName, info.Name,
+			metaObjectStoreDeleted, info.Deleted,
+		).Debugf("Received object store update")
+
+		var data []byte
+		if !info.Deleted {
+			r.connMut.Lock()
+			objStore := r.objStore
+			r.connMut.Unlock()
+			if objStore == nil {
+				return nil, nil, service.ErrNotConnected
+			}
+
+			if data, err = objStore.GetBytes(ctx, info.Name); err != nil {
+				if errors.Is(err, jetstream.ErrObjectNotFound) {
+					// The object was deleted after being listed.
+					continue
+				}
+				return nil, nil, err
+			}
+		}
+
+		return newMessageFromObjectInfo(info, data), func(ctx context.Context, res error) error {
+			return nil
+		}, nil
+	}
+}
+
+func (r *objectStoreReader) Close(ctx context.Context) error {
+	go func() {
+		r.disconnect()
+		r.shutSig.TriggerHasStopped()
+	}()
+	select {
+	case <-r.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestInputObjectStoreParse(t *testing.T) {
+	spec := natsObjectStoreInputConfig()
+	env := service.NewEnvironment()
+
+	t.Run("Successful config parsing", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1, url2 ]
+bucket: testbucket
+watch: true
+updates_only: true
+ignore_deletes: true
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		e, err := newObjectStoreReader(conf, service.MockResources())
+		require.NoError(t, err)
+
+		assert.Equal(t, "url1,url2", e.connDetails.urls)
+		assert.Equal(t, "testbucket", e.bucket)
+		assert.True(t, e.watch)
+		assert.True(t, e.updatesOnly)
+		assert.True(t, e.ignoreDeletes)
+	})
+
+	t.Run("Default config parsing", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+bucket: testbucket
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		e, err := newObjectStoreReader(conf, service.MockResources())
+		require.NoError(t, err)
+
+		assert.False(t, e.watch)
+		assert.False(t, e.updatesOnly)
+		assert.False(t, e.ignoreDeletes)
+	})
+}
+
+func TestOutputObjectStoreParse(t *testing.T) {
+	spec := natsObjectStoreOutputConfig()
+	env := service.NewEnvironment()
+
+	t.Run("Successful config parsing", func(t *testing.T) {
+		outputConfig := `
+urls: [ url1 ]
+bucket: testbucket
+object_name: ${! meta("name") }
+headers:
+  Content-Type: application/json
+chunk_size: 1024
+`
+
+		conf, err := spec.ParseYAML(outputConfig, env)
+		require.NoError(t, err)
+
+		o, err := newObjectStoreOutput(conf, service.MockResources())
+		require.NoError(t, err)
+
+		assert.Equal(t, "testbucket", o.bucket)
+		assert.Equal(t, uint32(1024), o.chunkSize)
+		require.Contains(t, o.headers, "Content-Type")
+
+		msg := service.NewMessage(nil)
+		msg.MetaSetMut("name", "foo")
+		name, err := o.objectName.TryString(msg)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", name)
+	})
+
+	t.Run("Negative chunk size", func(t *testing.T) {
+		outputConfig := `
+urls: [ url1 ]
+bucket: testbucket
+object_name: foo
+chunk_size: -1
+`
+
+		conf, err := spec.ParseYAML(outputConfig, env)
+		require.NoError(t, err)
+
+		_, err = newObjectStoreOutput(conf, service.MockResources())
+		require.Error(t, err)
+	})
+}
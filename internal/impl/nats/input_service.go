@@ -0,0 +1,510 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	nsiFieldName             = "name"
+	nsiFieldVersion          = "version"
+	nsiFieldDescription      = "description"
+	nsiFieldMetadata         = "metadata"
+	nsiFieldQueueGroup       = "queue_group"
+	nsiFieldEndpoints        = "endpoints"
+	nsiFieldGroups           = "groups"
+	nsiFieldResponseMetadata = "response_metadata"
+
+	nsiFieldEndpointName       = "name"
+	nsiFieldEndpointSubject    = "subject"
+	nsiFieldEndpointQueueGroup = "queue_group"
+	nsiFieldEndpointMetadata   = "metadata"
+
+	nsiFieldGroupName       = "name"
+	nsiFieldGroupQueueGroup = "queue_group"
+	nsiFieldGroupEndpoints  = "endpoints"
+)
+
+const (
+	metaServiceEndpoint  = "nats_service_endpoint"
+	metaServiceErrorCode = "nats_service_error_code"
+)
+
+func natsServiceEndpointFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField(nsiFieldEndpointName).
+			Description("The name of the endpoint.").
+			Example("get_user"),
+		service.NewStringField(nsiFieldEndpointSubject).
+			Description("The subject the endpoint listens on, prefixed by the subject of its group if any. Defaults to the name of the endpoint.").
+			Example("users.get").
+			Optional(),
+		service.NewStringField(nsiFieldEndpointQueueGroup).
+			Description("An optional queue group for the endpoint, overriding the queue group of its group or service.").
+			Optional().
+			Advanced(),
+		service.NewStringMapField(nsiFieldEndpointMetadata).
+			Description("Optional metadata describing the endpoint, which is included in service discovery responses.").
+			Optional().
+			Advanced(),
+	}
+}
+
+func natsServiceInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.33.0").
+		Summary("Registers a NATS micro service and creates a message for each request received by its endpoints.").
+		Description(`
+The service is registered with the NATS service API and can therefore be discovered through the `+"`$SRV.PING`, `$SRV.INFO` and `$SRV.STATS`"+` subjects. Each instance of the service joins a queue group, which allows requests to be load balanced across multiple instances.
+
+== Responses
+
+The response to a request is formed from the message added to the synchronous response of the request with the xref:components:processors/sync_response.adoc[`+"`sync_response`"+` processor] or output. When more than one response message is added only the first is sent. When no response message is added once the request has been processed an empty response is sent.
+
+If the pipeline fails to process a request, or the response message has been flagged as failed by a processor, an error response is sent with the error message as the `+"`Nats-Service-Error`"+` header. The `+"`Nats-Service-Error-Code`"+` header defaults to `+"`500`"+` and can be overridden by setting the metadata field `+"`nats_service_error_code`"+` on the response message.
+
+Requests are handed to the pipeline asynchronously and therefore the processing time and error counts reported by the service statistics do not include the time spent processing requests or errors produced by the pipeline.
+
+== Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- nats_subject
+- nats_reply_subject
+- nats_service_endpoint
+- All request headers
+`+"```"+`
+
+You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+
+`+connectionNameDescription()+authDescription()).
+		Fields(connectionHeadFields()...).
+		Fields(
+			service.NewStringField(nsiFieldName).
+				Description("The name of the service, which may only contain alphanumeric characters, dashes and underscores.").
+				Example("users"),
+			service.NewStringField(nsiFieldVersion).
+				Description("The version of the service, which must be a valid semantic version.").
+				Example("1.0.0"),
+			service.NewStringField(nsiFieldDescription).
+				Description("A description of the service.").
+				Default(""),
+			service.NewStringMapField(nsiFieldMetadata).
+				Description("Optional metadata describing the service, which is included in service discovery responses.").
+				Optional().
+				Advanced(),
+			service.NewStringField(nsiFieldQueueGroup).
+				Description("The queue group joined by each endpoint of the service, defaults to the queue group of the NATS service API.").
+				Optional().
+				Advanced(),
+			service.NewObjectListField(nsiFieldEndpoints, natsServiceEndpointFields()...).
+				Description("A list of endpoints registered at the root of the service.").
+				Default([]any{}),
+			service.NewObjectListField(nsiFieldGroups,
+				service.NewStringField(nsiFieldGroupName).
+					Description("The name of the group, which is used as the subject prefix of its endpoints.").
+					Example("users"),
+				service.NewStringField(nsiFieldGroupQueueGroup).
+					Description("An optional queue group for the endpoints of the group, overriding the queue group of the service.").
+					Optional().
+					Advanced(),
+				service.NewObjectListField(nsiFieldGroupEndpoints, natsServiceEndpointFields()...).
+					Description("A list of endpoints registered within the group."),
+			).
+				Description("A list of groups of endpoints, where the subject of each endpoint is prefixed with the name of its group.").
+				Default([]any{}),
+			service.NewMetadataFilterField(nsiFieldResponseMetadata).
+				Description("Determine which (if any) metadata values of response messages should be added to responses as headers.").
+				Optional(),
+		).
+		Fields(connectionTailFields()...).
+		Field(inputTracingDocs()).
+		LintRule(`root = if this.endpoints.or([]).length() == 0 && this.groups.or([]).length() == 0 { [ "at least one endpoint must be specified" ] }`).
+		Example("Greeter", "Responds to requests sent to the subject `greeter.hello` with a greeting.", `
+input:
+  nats_service:
+    urls: [ nats://127.0.0.1:4222 ]
+    name: greeter
+    version: 1.0.0
+    groups:
+      - name: greeter
+        endpoints:
+          - name: hello
+
+pipeline:
+  processors:
+    - mapping: 'root = "Hello, %s!".format(content().string())'
+    - sync_response: {}
+
+output:
+  drop: {}
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"nats_service", natsServiceInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			input, err := newNATSServiceReader(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return conf.WrapInputExtractTracingSpanMapping("nats_service", input)
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+type natsServiceEndpoint struct {
+	name       string
+	subject    string
+	queueGroup string
+	metadata   map[string]string
+}
+
+type natsServiceGroup struct {
+	name       string
+	queueGroup string
+	endpoints  []natsServiceEndpoint
+}
+
+type natsServiceRequest struct {
+	endpoint string
+	req      micro.Request
+}
+
+type natsServiceReader struct {
+	connDetails  connectionDetails
+	svcConf      micro.Config
+	endpoints    []natsServiceEndpoint
+	groups       []natsServiceGroup
+	respMetadata *service.MetadataFilter
+
+	log *service.Logger
+
+	shutSig *shutdown.Signaller
+
+	connMut    sync.Mutex
+	natsConn   *nats.Conn
+	svc        micro.Service
+	reqChan    chan natsServiceRequest
+	closedChan chan struct{}
+}
+
+func natsServiceEndpointsFromParsed(confs []*service.ParsedConfig) ([]natsServiceEndpoint, error) {
+	endpoints := make([]natsServiceEndpoint, 0, len(confs))
+	for _, c := range confs {
+		var e natsServiceEndpoint
+		var err error
+		if e.name, err = c.FieldString(nsiFieldEndpointName); err != nil {
+			return nil, err
+		}
+		if c.Contains(nsiFieldEndpointSubject) {
+			if e.subject, err = c.FieldString(nsiFieldEndpointSubject); err != nil {
+				return nil, err
+			}
+		}
+		if c.Contains(nsiFieldEndpointQueueGroup) {
+			if e.queueGroup, err = c.FieldString(nsiFieldEndpointQueueGroup); err != nil {
+				return nil, err
+			}
+		}
+		if c.Contains(nsiFieldEndpointMetadata) {
+			if e.metadata, err = c.FieldStringMap(nsiFieldEndpointMetadata); err != nil {
+				return nil, err
+			}
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
+
+func newNATSServiceReader(conf *service.ParsedConfig, mgr *service.Resources) (*natsServiceReader, error) {
+	r := &natsServiceReader{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if r.connDetails, err = connectionDetailsFromParsed(conf, mgr); err != nil {
+		return nil, err
+	}
+
+	if r.svcConf.Name, err = conf.FieldString(nsiFieldName); err != nil {
+		return nil, err
+	}
+	if r.svcConf.Version, err = conf.FieldString(nsiFieldVersion); err != nil {
+		return nil, err
+	}
+	if r.svcConf.Description, err = conf.FieldString(nsiFieldDescription); err != nil {
+		return nil, err
+	}
+	if conf.Contains(nsiFieldMetadata) {
+		if r.svcConf.Metadata, err = conf.FieldStringMap(nsiFieldMetadata); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(nsiFieldQueueGroup) {
+		if r.svcConf.QueueGroup, err = conf.FieldString(nsiFieldQueueGroup); err != nil {
+			return nil, err
+		}
+	}
+
+	endpointConfs, err := conf.FieldObjectList(nsiFieldEndpoints)
+	if err != nil {
+		return nil, err
+	}
+	if r.endpoints, err = natsServiceEndpointsFromParsed(endpointConfs); err != nil {
+		return nil, err
+	}
+
+	groupConfs, err := conf.FieldObjectList(nsiFieldGroups)
+	if err != nil {
+		return nil, err
+	}
+	for _, gConf := range groupConfs {
+		var g natsServiceGroup
+		if g.name, err = gConf.FieldString(nsiFieldGroupName); err != nil {
+			return nil, err
+		}
+		if gConf.Contains(nsiFieldGroupQueueGroup) {
+			if g.queueGroup, err = gConf.FieldString(nsiFieldGroupQueueGroup); err != nil {
+				return nil, err
+			}
+		}
+		if endpointConfs, err = gConf.FieldObjectList(nsiFieldGroupEndpoints); err != nil {
+			return nil, err
+		}
+		if g.endpoints, err = natsServiceEndpointsFromParsed(endpointConfs); err != nil {
+			return nil, err
+		}
+		r.groups = append(r.groups, g)
+	}
+
+	if len(r.endpoints) == 0 && len(r.groups) == 0 {
+		return nil, errors.New("at least one endpoint must be specified")
+	}
+
+	if conf.Contains(nsiFieldResponseMetadata) {
+		if r.respMetadata, err = conf.FieldMetadataFilter(nsiFieldResponseMetadata); err != nil {
+			return nil, err
+		}
+	}
+
+	r.svcConf.ErrorHandler = func(_ micro.Service, err *micro.NATSError) {
+		r.log.Errorf("Service error on subject %v: %v", err.Subject, err.Description)
+	}
+	return r, nil
+}
+
+func (r *natsServiceReader) handler(endpoint string, reqChan chan<- natsServiceRequest) micro.Handler {
+	return micro.HandlerFunc(func(req micro.Request) {
+		select {
+		case reqChan <- natsServiceRequest{endpoint: endpoint, req: req}:
+		case <-r.shutSig.SoftStopChan():
+			_ = req.Error("503", "service is shutting down", nil)
+		}
+	})
+}
+
+func endpointOpts(e natsServiceEndpoint) []micro.EndpointOpt {
+	var opts []micro.EndpointOpt
+	if e.subject != "" {
+		opts = append(opts, micro.WithEndpointSubject(e.subject))
+	}
+	if e.queueGroup != "" {
+		opts = append(opts, micro.WithEndpointQueueGroup(e.queueGroup))
+	}
+	if len(e.metadata) > 0 {
+		opts = append(opts, micro.WithEndpointMetadata(e.metadata))
+	}
+	return opts
+}
+
+func (r *natsServiceReader) Connect(ctx context.Context) (err error) {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.natsConn != nil {
+		return nil
+	}
+
+	var natsConn *nats.Conn
+	var svc micro.Service
+
+	defer func() {
+		if err != nil {
+			if svc != nil {
+				_ = svc.Stop()
+			}
+			if natsConn != nil {
+				natsConn.Close()
+			}
+		}
+	}()
+
+	// Closed when the connection is lost for good, which happens once the
+	// client gives up reconnecting.
+	closedChan := make(chan struct{})
+	if natsConn, err = r.connDetails.get(ctx, nats.ClosedHandler(func(*nats.Conn) {
+		close(closedChan)
+	})); err != nil {
+		return err
+	}
+
+	if svc, err = micro.AddService(natsConn, r.svcConf); err != nil {
+		return err
+	}
+
+	reqChan := make(chan natsServiceRequest)
+	for _, e := range r.endpoints {
+		if err = svc.AddEndpoint(e.name, r.handler(e.name, reqChan), endpointOpts(e)...); err != nil {
+			return fmt.Errorf("failed to add endpoint %v: %w", e.name, err)
+		}
+	}
+	for _, g := range r.groups {
+		var groupOpts []micro.GroupOpt
+		if g.queueGroup != "" {
+			groupOpts = append(groupOpts, micro.WithGroupQueueGroup(g.queueGroup))
+		}
+		group := svc.AddGroup(g.name, groupOpts...)
+		for _, e := range g.endpoints {
+			if err = group.AddEndpoint(e.name, r.handler(e.name, reqChan), endpointOpts(e)...); err != nil {
+				return fmt.Errorf("failed to add endpoint %v to group %v: %w", e.name, g.name, err)
+			}
+		}
+	}
+
+	r.natsConn = natsConn
+	r.svc = svc
+	r.reqChan = reqChan
+	r.closedChan = closedChan
+	return nil
+}
+
+func (r *natsServiceReader) disconnect() {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.svc != nil {
+		_ = r.svc.Stop()
+		r.svc = nil
+	}
+	if r.natsConn != nil {
+		r.natsConn.Close()
+		r.natsConn = nil
+	}
+	r.reqChan = nil
+	r.closedChan = nil
+}
+
+func (r *natsServiceReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	r.connMut.Lock()
+	reqChan, closedChan := r.reqChan, r.closedChan
+	r.connMut.Unlock()
+
+	if reqChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	var sReq natsServiceRequest
+	select {
+	case sReq = <-reqChan:
+	case <-closedChan:
+		r.disconnect()
+		return nil, nil, service.ErrNotConnected
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-r.shutSig.SoftStopChan():
+		return nil, nil, service.ErrEndOfInput
+	}
+	req := sReq.req
+
+	msg := service.NewMessage(req.Data())
+	msg.MetaSetMut("nats_subject", req.Subject())
+	msg.MetaSetMut("nats_reply_subject", req.Reply())
+	msg.MetaSetMut(metaServiceEndpoint, sReq.endpoint)
+	for key := range req.Headers() {
+		msg.MetaSetMut(key, req.Headers().Get(key))
+	}
+
+	msg, store := msg.WithSyncResponseStore()
+	return msg, func(_ context.Context, res error) error {
+		if res != nil {
+			return req.Error("500", res.Error(), nil)
+		}
+
+		var resMsg *service.Message
+		for _, b := range store.Read() {
+			if len(b) > 0 {
+				resMsg = b[0]
+				break
+			}
+		}
+		if resMsg == nil {
+			return req.Respond(nil)
+		}
+
+		resBytes, err := resMsg.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		headers := micro.Headers{}
+		_ = r.respMetadata.Walk(resMsg, func(key, value string) error {
+			nats.Header(headers).Add(key, value)
+			return nil
+		})
+
+		if resErr := resMsg.GetError(); resErr != nil {
+			code, _ := resMsg.MetaGet(metaServiceErrorCode)
+			if code == "" {
+				code = "500"
+			}
+			return req.Error(code, resErr.Error(), resBytes, micro.WithHeaders(headers))
+		}
+		return req.Respond(resBytes, micro.WithHeaders(headers))
+	}, nil
+}
+
+func (r *natsServiceReader) Close(ctx context.Context) error {
+	go func() {
+		r.shutSig.TriggerSoftStop()
+		r.disconnect()
+		r.shutSig.TriggerHasStopped()
+	}()
+	select {
+	case <-r.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestInputServiceParse(t *testing.T) {
+	spec := natsServiceInputConfig()
+	env := service.NewEnvironment()
+
+	t.Run("Successful config parsing", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+name: users
+version: 1.2.3
+description: Manages users
+queue_group: foo
+endpoints:
+  - name: ping
+groups:
+  - name: users
+    queue_group: bar
+    endpoints:
+      - name: get
+        subject: get.*
+        metadata:
+          kind: read
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		r, err := newNATSServiceReader(conf, service.MockResources())
+		require.NoError(t, err)
+
+		assert.Equal(t, "users", r.svcConf.Name)
+		assert.Equal(t, "1.2.3", r.svcConf.Version)
+		assert.Equal(t, "Manages users", r.svcConf.Description)
+		assert.Equal(t, "foo", r.svcConf.QueueGroup)
+		assert.Equal(t, []natsServiceEndpoint{{name: "ping", metadata: map[string]string{}}}, r.endpoints)
+		assert.Equal(t, []natsServiceGroup{{
+			name:       "users",
+			queueGroup: "bar",
+			endpoints: []natsServiceEndpoint{{
+				name:     "get",
+				subject:  "get.*",
+				metadata: map[string]string{"kind": "read"},
+			}},
+		}}, r.groups)
+	})
+
+	t.Run("No endpoints", func(t *testing.T) {
+		inputConfig := `
+urls: [ url1 ]
+name: users
+version: 1.2.3
+`
+
+		conf, err := spec.ParseYAML(inputConfig, env)
+		require.NoError(t, err)
+
+		_, err = newNATSServiceReader(conf, service.MockResources())
+		require.Error(t, err)
+	})
+}
@@ -136,6 +136,62 @@ cache_resources:
 		)
 	})
 
+	t.Run("resume from revision", func(t *testing.T) {
+		ctx, done := context.WithTimeout(context.Background(), time.Minute)
+		defer done()
+
+		js, err := jetstream.New(natsConn)
+		require.NoError(t, err)
+
+		bucket, err := js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:  "bucket-resume",
+			History: 5,
+		})
+		require.NoError(t, err)
+
+		_, err = bucket.PutString(ctx, "foo", "first")
+		require.NoError(t, err)
+
+		mgr := service.MockResources(service.MockResourcesOptAddCache("revcache"))
+		newReader := func() *kvReader {
+			conf, err := natsKVInputConfig().ParseYAML(fmt.Sprintf(`
+urls: [ tcp://localhost:%v ]
+bucket: bucket-resume
+revision_cache: revcache
+`, resource.GetPort("4222/tcp")), nil)
+			require.NoError(t, err)
+
+			r, err := newKVReader(conf, mgr)
+			require.NoError(t, err)
+			require.NoError(t, r.Connect(ctx))
+			return r
+		}
+
+		readValue := func(r *kvReader) string {
+			msg, ackFn, err := r.Read(ctx)
+			require.NoError(t, err)
+			require.NoError(t, ackFn(ctx, nil))
+
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+			return string(b)
+		}
+
+		r := newReader()
+		assert.Equal(t, "first", readValue(r))
+		require.NoError(t, r.Close(ctx))
+
+		_, err = bucket.PutString(ctx, "foo", "second")
+		require.NoError(t, err)
+		_, err = bucket.PutString(ctx, "foo", "third")
+		require.NoError(t, err)
+
+		r = newReader()
+		assert.Equal(t, "second", readValue(r))
+		assert.Equal(t, "third", readValue(r))
+		require.NoError(t, r.Close(ctx))
+	})
+
 	t.Run("processor", func(t *testing.T) {
 		createBucket := func(t *testing.T) (jetstream.KeyValue, string) {
 			u4, err := uuid.NewV4()
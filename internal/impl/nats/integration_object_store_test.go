@@ -0,0 +1,124 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service/integration"
+)
+
+func TestIntegrationNatsObjectStore(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "nats",
+		Tag:        "latest",
+		Cmd:        []string{"--js", "--trace"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	var natsConn *nats.Conn
+	_ = resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		natsConn, err = nats.Connect(fmt.Sprintf("tcp://localhost:%v", resource.GetPort("4222/tcp")))
+		return err
+	}))
+	t.Cleanup(func() {
+		natsConn.Close()
+	})
+
+	createBucket := func(t testing.TB, id string) {
+		js, err := jetstream.New(natsConn)
+		require.NoError(t, err)
+
+		_, err = js.CreateObjectStore(context.Background(), jetstream.ObjectStoreConfig{
+			Bucket: "bucket-" + id,
+		})
+		require.NoError(t, err)
+	}
+
+	template := `
+output:
+  label: object_store_output
+  nats_object_store:
+    urls: [ tcp://localhost:$PORT ]
+    bucket: bucket-$ID
+    object_name: ${! ksuid() }
+    chunk_size: 16
+
+input:
+  label: object_store_input
+  nats_object_store:
+    urls: [ tcp://localhost:$PORT ]
+    bucket: bucket-$ID
+    watch: true
+`
+	suite := integration.StreamTests(
+		integration.StreamTestOpenClose(),
+		integration.StreamTestSendBatch(10),
+		integration.StreamTestStreamParallel(100),
+		integration.StreamTestStreamSequential(100),
+	)
+	suite.Run(
+		t, template,
+		integration.StreamTestOptPreTest(func(t testing.TB, _ context.Context, vars *integration.StreamTestConfigVars) {
+			createBucket(t, vars.ID)
+		}),
+		integration.StreamTestOptSleepAfterInput(100*time.Millisecond),
+		integration.StreamTestOptSleepAfterOutput(100*time.Millisecond),
+		integration.StreamTestOptPort(resource.GetPort("4222/tcp")),
+	)
+
+	t.Run("cache", func(t *testing.T) {
+		template := `
+cache_resources:
+  - label: testcache
+    nats_object_store:
+      bucket: bucket-$ID
+      urls: [ tcp://localhost:$PORT ]`
+		suite := integration.CacheTests(
+			integration.CacheTestOpenClose(),
+			integration.CacheTestMissingKey(),
+			integration.CacheTestDoubleAdd(),
+			integration.CacheTestDelete(),
+			integration.CacheTestGetAndSet(50),
+		)
+		suite.Run(
+			t, template,
+			integration.CacheTestOptPreTest(func(t testing.TB, _ context.Context, vars *integration.CacheTestConfigVars) {
+				createBucket(t, vars.ID)
+			}),
+			integration.CacheTestOptPort(resource.GetPort("4222/tcp")),
+		)
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/redpanda-data/benthos/v4/public/components/pure"
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/redpanda-data/benthos/v4/public/service/integration"
+)
+
+func TestIntegrationNatsService(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "nats",
+		Tag:        "latest",
+		Cmd:        []string{"--trace"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	var natsConn *nats.Conn
+	_ = resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		natsConn, err = nats.Connect(fmt.Sprintf("tcp://localhost:%v", resource.GetPort("4222/tcp")))
+		return err
+	}))
+	t.Cleanup(func() {
+		natsConn.Close()
+	})
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.SetYAML(fmt.Sprintf(`
+input:
+  nats_service:
+    urls: [ tcp://localhost:%v ]
+    name: greeter
+    version: 1.0.0
+    groups:
+      - name: greeter
+        endpoints:
+          - name: hello
+          - name: fail
+
+pipeline:
+  processors:
+    - switch:
+        - check: '@nats_service_endpoint == "fail"'
+          processors:
+            - mapping: 'meta nats_service_error_code = "400"'
+            - mapping: 'root = throw("nope")'
+        - processors:
+            - mapping: 'root = "hello " + content().string()'
+    - sync_response: {}
+
+output:
+  drop: {}
+`, resource.GetPort("4222/tcp"))))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	go func() {
+		_ = strm.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		require.NoError(t, strm.StopWithin(time.Second*10))
+	})
+
+	var resp *nats.Msg
+	require.Eventually(t, func() bool {
+		resp, err = natsConn.Request("greeter.hello", []byte("world"), time.Second)
+		return err == nil
+	}, time.Second*30, time.Millisecond*100)
+	assert.Equal(t, "hello world", string(resp.Data))
+
+	resp, err = natsConn.Request("greeter.fail", []byte("world"), time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "400", resp.Header.Get(micro.ErrorCodeHeader))
+	assert.Contains(t, resp.Header.Get(micro.ErrorHeader), "nope")
+
+	resp, err = natsConn.Request("$SRV.INFO.greeter", nil, time.Second)
+	require.NoError(t, err)
+
+	var info micro.Info
+	require.NoError(t, json.Unmarshal(resp.Data, &info))
+	assert.Equal(t, "greeter", info.Name)
+	assert.Equal(t, "1.0.0", info.Version)
+	require.Len(t, info.Endpoints, 2)
+	assert.Equal(t, "greeter.hello", info.Endpoints[0].Subject)
+}
@@ -29,6 +29,17 @@ const (
 	metaKVCreated   = "nats_kv_created"
 )
 
+const (
+	metaObjectStoreBucket  = "nats_object_store_bucket"
+	metaObjectStoreName    = "nats_object_store_name"
+	metaObjectStoreNUID    = "nats_object_store_nuid"
+	metaObjectStoreSize    = "nats_object_store_size"
+	metaObjectStoreChunks  = "nats_object_store_chunks"
+	metaObjectStoreDigest  = "nats_object_store_digest"
+	metaObjectStoreModTime = "nats_object_store_mod_time"
+	metaObjectStoreDeleted = "nats_object_store_deleted"
+)
+
 func newMessageFromKVEntry(entry jetstream.KeyValueEntry) *service.Message {
 	msg := service.NewMessage(entry.Value())
 	msg.MetaSetMut(metaKVKey, entry.Key())
@@ -40,3 +51,20 @@ func newMessageFromKVEntry(entry jetstream.KeyValueEntry) *service.Message {
 
 	return msg
 }
+
+func newMessageFromObjectInfo(info *jetstream.ObjectInfo, data []byte) *service.Message {
+	msg := service.NewMessage(data)
+	for k := range info.Headers {
+		msg.MetaSetMut(k, info.Headers.Get(k))
+	}
+	msg.MetaSetMut(metaObjectStoreBucket, info.Bucket)
+	msg.MetaSetMut(metaObjectStoreName, info.Name)
+	msg.MetaSetMut(metaObjectStoreNUID, info.NUID)
+	msg.MetaSetMut(metaObjectStoreSize, info.Size)
+	msg.MetaSetMut(metaObjectStoreChunks, info.Chunks)
+	msg.MetaSetMut(metaObjectStoreDigest, info.Digest)
+	msg.MetaSetMut(metaObjectStoreModTime, info.ModTime)
+	msg.MetaSetMut(metaObjectStoreDeleted, info.Deleted)
+
+	return msg
+}
@@ -32,7 +32,16 @@ func natsJetStreamOutputConfig() *service.ConfigSpec {
 		Categories("Services").
 		Version("3.46.0").
 		Summary("Write messages to a NATS JetStream subject.").
-		Description(connectionNameDescription() + authDescription()).
+		Description(`
+== Deduplication
+
+When the field ` + "`msg_id`" + ` is set its value is added to each message as the ` + "`Nats-Msg-Id`" + ` header, and JetStream discards messages published with an ID that has already been seen within the duplicate window of the stream. This makes it safe to retry publishing messages without producing duplicates.
+
+== Provisioning
+
+The stream can optionally be created, or updated to match the configuration, when the output connects by enabling the field ` + "`" + psFieldProvisionStream + "`" + `. Otherwise it is expected to exist already.
+
+` + connectionNameDescription() + authDescription()).
 		Fields(connectionHeadFields()...).
 		Field(service.NewInterpolatedStringField("subject").
 			Description("A subject to write to.").
@@ -49,7 +58,14 @@ func natsJetStreamOutputConfig() *service.ConfigSpec {
 		Field(service.NewMetadataFilterField("metadata").
 			Description("Determine which (if any) metadata values should be added to messages as headers.").
 			Optional()).
+		Field(service.NewInterpolatedStringField("msg_id").
+			Description("An optional message ID added to messages as the `Nats-Msg-Id` header, allowing JetStream to deduplicate messages published more than once.").
+			Example(`${! meta("kafka_topic") }-${! meta("kafka_partition") }-${! meta("kafka_offset") }`).
+			Example(`${! json("id") }`).
+			Optional().
+			Version(provisionVersion)).
 		Field(service.NewOutputMaxInFlightField().Default(1024)).
+		Field(streamProvisionDocs()).
 		Fields(connectionTailFields()...).
 		Field(outputTracingDocs())
 }
@@ -82,6 +98,8 @@ type jetStreamOutput struct {
 	subjectStr    *service.InterpolatedString
 	headers       map[string]*service.InterpolatedString
 	metaFilter    *service.MetadataFilter
+	msgID         *service.InterpolatedString
+	provision     *streamProvision
 
 	log *service.Logger
 
@@ -120,6 +138,16 @@ func newJetStreamWriterFromConfig(conf *service.ParsedConfig, mgr *service.Resou
 			return nil, err
 		}
 	}
+
+	if conf.Contains("msg_id") {
+		if j.msgID, err = conf.FieldInterpolatedString("msg_id"); err != nil {
+			return nil, err
+		}
+	}
+
+	if j.provision, err = streamProvisionFromParsed(conf); err != nil {
+		return nil, err
+	}
 	return &j, nil
 }
 
@@ -150,6 +178,12 @@ func (j *jetStreamOutput) Connect(ctx context.Context) (err error) {
 		return err
 	}
 
+	if j.provision != nil {
+		if err = j.provision.provision(jCtx); err != nil {
+			return fmt.Errorf("failed to provision stream: %w", err)
+		}
+	}
+
 	j.natsConn = natsConn
 	j.jCtx = jCtx
 	return nil
@@ -200,6 +234,15 @@ func (j *jetStreamOutput) Write(ctx context.Context, msg *service.Message) error
 		jsmsg.Header.Add(key, value)
 		return nil
 	})
+	if j.msgID != nil {
+		msgID, err := j.msgID.TryString(msg)
+		if err != nil {
+			return fmt.Errorf(`failed string interpolation on field "msg_id": %w`, err)
+		}
+		if msgID != "" {
+			jsmsg.Header.Set(nats.MsgIdHdr, msgID)
+		}
+	}
 
 	_, err = jCtx.PublishMsg(jsmsg)
 	return err
@@ -16,7 +16,9 @@ package nats
 
 import (
 	"testing"
+	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -98,4 +100,40 @@ auth:
 		_, err = newJetStreamReaderFromConfig(conf, service.MockResources())
 		require.Error(t, err)
 	})
+
+	t.Run("Provisioning and message IDs", func(t *testing.T) {
+		outputConfig := `
+urls: [ url1 ]
+subject: events.foo
+msg_id: ${! json("id") }
+provision_stream:
+  enabled: true
+  name: events
+  subjects: [ events.> ]
+  storage: memory
+  max_age: 1h
+  duplicate_window: 5m
+`
+
+		conf, err := spec.ParseYAML(outputConfig, env)
+		require.NoError(t, err)
+
+		e, err := newJetStreamWriterFromConfig(conf, service.MockResources())
+		require.NoError(t, err)
+
+		msgID, err := e.msgID.TryString(service.NewMessage([]byte(`{"id":"foo"}`)))
+		require.NoError(t, err)
+		assert.Equal(t, "foo", msgID)
+
+		require.NotNil(t, e.provision)
+		assert.Equal(t, nats.StreamConfig{
+			Name:       "events",
+			Subjects:   []string{"events.>"},
+			Retention:  nats.LimitsPolicy,
+			Storage:    nats.MemoryStorage,
+			Replicas:   1,
+			MaxAge:     time.Hour,
+			Duplicates: 5 * time.Minute,
+		}, e.provision.cfg)
+	})
 }
@@ -0,0 +1,266 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	osoFieldObjectName = "object_name"
+	osoFieldHeaders    = "headers"
+	osoFieldMetadata   = "metadata"
+	osoFieldChunkSize  = "chunk_size"
+)
+
+func natsObjectStoreOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.33.0").
+		Summary("Put messages in a NATS object store bucket.").
+		Description(`
+The field ` + "`object_name`" + ` supports
+xref:configuration:interpolation.adoc#bloblang-queries[interpolation functions], allowing
+you to create a unique object name for each message. Writing a message to the name of an
+existing object replaces that object.
+
+Messages larger than the chunk size of the bucket are split into multiple chunks by the
+object store and reassembled when read.
+
+` + connectionNameDescription() + authDescription()).
+		Fields(objectStoreDocs([]*service.ConfigField{
+			service.NewInterpolatedStringField(osoFieldObjectName).
+				Description("The name of the object for each message.").
+				Example("foo").
+				Example(`${! meta("kafka_key") }`).
+				Example(`images/${! json("id") }.png`),
+			service.NewInterpolatedStringMapField(osoFieldHeaders).
+				Description("Explicit headers to add to objects.").
+				Default(map[string]any{}).
+				Example(map[string]any{
+					"Content-Type": "application/json",
+					"Timestamp":    `${!meta("Timestamp")}`,
+				}),
+			service.NewMetadataFilterField(osoFieldMetadata).
+				Description("Determine which (if any) metadata values should be added to objects as headers.").
+				Optional(),
+			service.NewIntField(osoFieldChunkSize).
+				Description("The maximum size in bytes of each chunk an object is split into, when set to zero the default of the client library (128KB) is used.").
+				Default(0).
+				Advanced(),
+			service.NewOutputMaxInFlightField().Default(64),
+		}...)...)
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"nats_object_store", natsObjectStoreOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt("max_in_flight")
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newObjectStoreOutput(conf, mgr)
+			return w, maxInFlight, err
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type objectStoreOutput struct {
+	connDetails connectionDetails
+	bucket      string
+	objectName  *service.InterpolatedString
+	headers     map[string]*service.InterpolatedString
+	metaFilter  *service.MetadataFilter
+	chunkSize   uint32
+
+	log *service.Logger
+
+	connMut  sync.Mutex
+	natsConn *nats.Conn
+	objStore jetstream.ObjectStore
+
+	shutSig *shutdown.Signaller
+}
+
+func newObjectStoreOutput(conf *service.ParsedConfig, mgr *service.Resources) (*objectStoreOutput, error) {
+	o := objectStoreOutput{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if o.connDetails, err = connectionDetailsFromParsed(conf, mgr); err != nil {
+		return nil, err
+	}
+
+	if o.bucket, err = conf.FieldString(osFieldBucket); err != nil {
+		return nil, err
+	}
+
+	if o.objectName, err = conf.FieldInterpolatedString(osoFieldObjectName); err != nil {
+		return nil, err
+	}
+
+	if o.headers, err = conf.FieldInterpolatedStringMap(osoFieldHeaders); err != nil {
+		return nil, err
+	}
+
+	if conf.Contains(osoFieldMetadata) {
+		if o.metaFilter, err = conf.FieldMetadataFilter(osoFieldMetadata); err != nil {
+			return nil, err
+		}
+	}
+
+	chunkSize, err := conf.FieldInt(osoFieldChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	if chunkSize < 0 {
+		return nil, fmt.Errorf("%v must not be negative", osoFieldChunkSize)
+	}
+	o.chunkSize = uint32(chunkSize)
+	return &o, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (o *objectStoreOutput) Connect(ctx context.Context) (err error) {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.natsConn != nil {
+		return nil
+	}
+
+	var natsConn *nats.Conn
+
+	defer func() {
+		if err != nil && natsConn != nil {
+			natsConn.Close()
+		}
+	}()
+
+	if natsConn, err = o.connDetails.get(ctx); err != nil {
+		return err
+	}
+
+	js, err := jetstream.New(natsConn)
+	if err != nil {
+		return err
+	}
+
+	if o.objStore, err = js.ObjectStore(ctx, o.bucket); err != nil {
+		return err
+	}
+
+	o.natsConn = natsConn
+	return nil
+}
+
+func (o *objectStoreOutput) disconnect() {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.natsConn != nil {
+		o.natsConn.Close()
+		o.natsConn = nil
+	}
+	o.objStore = nil
+}
+
+//------------------------------------------------------------------------------
+
+func (o *objectStoreOutput) Write(ctx context.Context, msg *service.Message) error {
+	o.connMut.Lock()
+	objStore := o.objStore
+	o.connMut.Unlock()
+	if objStore == nil {
+		return service.ErrNotConnected
+	}
+
+	name, err := o.objectName.TryString(msg)
+	if err != nil {
+		return fmt.Errorf(`failed string interpolation on field "%v": %w`, osoFieldObjectName, err)
+	}
+
+	value, err := msg.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	meta := jetstream.ObjectMeta{
+		Name:    name,
+		Headers: nats.Header{},
+	}
+	for k, v := range o.headers {
+		value, err := v.TryString(msg)
+		if err != nil {
+			return fmt.Errorf(`failed string interpolation on header %q: %w`, k, err)
+		}
+		meta.Headers.Add(k, value)
+	}
+	_ = o.metaFilter.Walk(msg, func(key, value string) error {
+		meta.Headers.Add(key, value)
+		return nil
+	})
+	if len(meta.Headers) == 0 {
+		meta.Headers = nil
+	}
+	if o.chunkSize > 0 {
+		meta.Opts = &jetstream.ObjectMetaOptions{ChunkSize: o.chunkSize}
+	}
+
+	info, err := objStore.Put(ctx, meta, bytes.NewReader(value))
+	if err != nil {
+		return err
+	}
+
+	o.log.With(
+		metaObjectStoreBucket, info.Bucket,
+		metaObjectStoreName, info.Name,
+		metaObjectStoreChunks, info.Chunks,
+	).Debug("Put object")
+
+	return nil
+}
+
+func (o *objectStoreOutput) Close(ctx context.Context) error {
+	go func() {
+		o.disconnect()
+		o.shutSig.TriggerHasStopped()
+	}()
+	select {
+	case <-o.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	psFieldProvisionStream = "provision_stream"
+	psFieldEnabled         = "enabled"
+	psFieldName            = "name"
+	psFieldSubjects        = "subjects"
+	psFieldRetention       = "retention"
+	psFieldStorage         = "storage"
+	psFieldReplicas        = "replicas"
+	psFieldMaxAge          = "max_age"
+	psFieldDuplicateWindow = "duplicate_window"
+
+	pcFieldProvisionConsumer = "provision_consumer"
+	pcFieldEnabled           = "enabled"
+	pcFieldFilterSubjects    = "filter_subjects"
+	pcFieldAckPolicy         = "ack_policy"
+	pcFieldMaxDeliver        = "max_deliver"
+	pcFieldBackoff           = "backoff"
+	pcFieldReplayPolicy      = "replay_policy"
+)
+
+const provisionVersion = "4.33.0"
+
+func streamProvisionDocs() *service.ConfigField {
+	return service.NewObjectField(psFieldProvisionStream,
+		service.NewBoolField(psFieldEnabled).
+			Description("Whether to provision the stream.").
+			Default(false),
+		service.NewStringField(psFieldName).
+			Description("The name of the stream.").
+			Example("events").
+			Default(""),
+		service.NewStringListField(psFieldSubjects).
+			Description("The subjects captured by the stream, wildcards are supported. When not set a new stream captures the subject with the same name as the stream, and the subjects of an existing stream are left unchanged.").
+			Example([]string{"events.>"}).
+			Optional(),
+		service.NewStringAnnotatedEnumField(psFieldRetention, map[string]string{
+			"limits":    "Messages are retained until any of the limits of the stream are reached.",
+			"interest":  "Messages are retained until they have been acknowledged by all consumers with interest in them.",
+			"workqueue": "Messages are retained until they have been acknowledged by a consumer.",
+		}).
+			Description("The retention policy of the stream. When not set a new stream uses the `limits` policy, and the policy of an existing stream is left unchanged.").
+			Optional(),
+		service.NewStringAnnotatedEnumField(psFieldStorage, map[string]string{
+			"file":   "Messages are persisted to disk.",
+			"memory": "Messages are held in memory.",
+		}).
+			Description("The storage type of a new stream, which cannot be changed once the stream has been created and is therefore ignored for existing streams.").
+			Default("file"),
+		service.NewIntField(psFieldReplicas).
+			Description("The number of replicas of the stream to maintain in clustered deployments. When not set a new stream has a single replica, and the replicas of an existing stream are left unchanged.").
+			Optional(),
+		service.NewDurationField(psFieldMaxAge).
+			Description("The maximum age of messages in the stream, when empty messages are retained regardless of their age.").
+			Example("24h").
+			Optional(),
+		service.NewDurationField(psFieldDuplicateWindow).
+			Description("The window of time within which messages published with the same `Nats-Msg-Id` header are deduplicated, when empty the server default is used.").
+			Example("2m").
+			Optional(),
+	).
+		Description("Optionally create the stream when connecting, or update it when it already exists such that its configuration matches this block. Only the fields of an existing stream that are explicitly set in this block are updated.").
+		Advanced().
+		Version(provisionVersion)
+}
+
+func consumerProvisionDocs() *service.ConfigField {
+	return service.NewObjectField(pcFieldProvisionConsumer,
+		service.NewBoolField(pcFieldEnabled).
+			Description("Whether to provision the consumer.").
+			Default(false),
+		service.NewStringListField(pcFieldFilterSubjects).
+			Description("The subjects of the stream consumed, wildcards are supported. When not set a new consumer consumes all subjects of the stream, and the filter of an existing consumer is left unchanged. When a single subject is set the field `subject` must either be empty or match it, and when multiple subjects are set the field `subject` must be empty.").
+			Example([]string{"events.orders.>"}).
+			Optional(),
+		service.NewStringAnnotatedEnumField(pcFieldAckPolicy, map[string]string{
+			"explicit": "Each message must be acknowledged individually.",
+			"all":      "Acknowledging a message acknowledges all messages delivered before it.",
+			"none":     "Messages are not acknowledged.",
+		}).
+			Description("The acknowledgement policy of a new consumer, which cannot be changed once the consumer has been created and is therefore ignored for existing consumers.").
+			Default("explicit"),
+		service.NewIntField(pcFieldMaxDeliver).
+			Description("The maximum number of delivery attempts of a message, when set to zero the number of attempts is unlimited. When not set a new consumer has no limit, and the limit of an existing consumer is left unchanged.").
+			Optional(),
+		service.NewStringListField(pcFieldBackoff).
+			Description("A list of durations to wait before each redelivery of a message that is not acknowledged. When set `max_deliver` must be greater than the number of durations.").
+			Example([]string{"1s", "10s", "1m"}).
+			Optional(),
+		service.NewStringAnnotatedEnumField(pcFieldReplayPolicy, map[string]string{
+			"instant":  "Messages are delivered as fast as possible.",
+			"original": "Messages are delivered at the rate they were originally published.",
+		}).
+			Description("The replay policy of a new consumer, which cannot be changed once the consumer has been created and is therefore ignored for existing consumers.").
+			Default("instant"),
+	).
+		Description("Optionally create a durable pull consumer named after the `durable` field when connecting, or update it when it already exists such that its configuration matches this block along with the fields `ack_wait` and `max_ack_pending`. Only the fields of an existing consumer that are explicitly set in this block are updated, and the field `deliver` only applies to new consumers. Requires the fields `stream` (or `provision_stream`) and `durable` to be set.").
+		Advanced().
+		Version(provisionVersion)
+}
+
+//------------------------------------------------------------------------------
+
+type streamProvision struct {
+	cfg nats.StreamConfig
+
+	hasSubjects  bool
+	hasRetention bool
+	hasReplicas  bool
+	hasMaxAge    bool
+	hasDupWindow bool
+}
+
+func streamProvisionFromParsed(pConf *service.ParsedConfig) (*streamProvision, error) {
+	conf := pConf.Namespace(psFieldProvisionStream)
+	if enabled, err := conf.FieldBool(psFieldEnabled); err != nil || !enabled {
+		return nil, err
+	}
+
+	var p streamProvision
+	var err error
+	if p.cfg.Name, err = conf.FieldString(psFieldName); err != nil {
+		return nil, err
+	}
+	if p.cfg.Name == "" {
+		return nil, errors.New("a stream name must be specified when provisioning a stream")
+	}
+	if conf.Contains(psFieldSubjects) {
+		if p.cfg.Subjects, err = conf.FieldStringList(psFieldSubjects); err != nil {
+			return nil, err
+		}
+		p.hasSubjects = true
+	}
+
+	p.cfg.Retention = nats.LimitsPolicy
+	if conf.Contains(psFieldRetention) {
+		retention, err := conf.FieldString(psFieldRetention)
+		if err != nil {
+			return nil, err
+		}
+		switch retention {
+		case "limits":
+			p.cfg.Retention = nats.LimitsPolicy
+		case "interest":
+			p.cfg.Retention = nats.InterestPolicy
+		case "workqueue":
+			p.cfg.Retention = nats.WorkQueuePolicy
+		default:
+			return nil, fmt.Errorf("retention policy %v was not recognised", retention)
+		}
+		p.hasRetention = true
+	}
+
+	storage, err := conf.FieldString(psFieldStorage)
+	if err != nil {
+		return nil, err
+	}
+	switch storage {
+	case "file":
+		p.cfg.Storage = nats.FileStorage
+	case "memory":
+		p.cfg.Storage = nats.MemoryStorage
+	default:
+		return nil, fmt.Errorf("storage type %v was not recognised", storage)
+	}
+
+	p.cfg.Replicas = 1
+	if conf.Contains(psFieldReplicas) {
+		if p.cfg.Replicas, err = conf.FieldInt(psFieldReplicas); err != nil {
+			return nil, err
+		}
+		p.hasReplicas = true
+	}
+
+	if conf.Contains(psFieldMaxAge) {
+		if p.cfg.MaxAge, err = conf.FieldDuration(psFieldMaxAge); err != nil {
+			return nil, err
+		}
+		p.hasMaxAge = true
+	}
+	if conf.Contains(psFieldDuplicateWindow) {
+		if p.cfg.Duplicates, err = conf.FieldDuration(psFieldDuplicateWindow); err != nil {
+			return nil, err
+		}
+		p.hasDupWindow = true
+	}
+	return &p, nil
+}
+
+// provision creates the stream, or updates the fields of an existing stream
+// that are explicitly set by this provision. The storage type of a stream is
+// immutable and is therefore only set when the stream is created.
+func (p *streamProvision) provision(jCtx nats.JetStreamContext) error {
+	info, err := jCtx.StreamInfo(p.cfg.Name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		cfg := p.cfg
+		_, err = jCtx.AddStream(&cfg)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg := info.Config
+	if p.hasSubjects {
+		cfg.Subjects = p.cfg.Subjects
+	}
+	if p.hasRetention {
+		cfg.Retention = p.cfg.Retention
+	}
+	if p.hasReplicas {
+		cfg.Replicas = p.cfg.Replicas
+	}
+	if p.hasMaxAge {
+		cfg.MaxAge = p.cfg.MaxAge
+	}
+	if p.hasDupWindow {
+		cfg.Duplicates = p.cfg.Duplicates
+	}
+	_, err = jCtx.UpdateStream(&cfg)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+type consumerProvision struct {
+	cfg nats.ConsumerConfig
+
+	hasFilterSubjects bool
+	hasMaxDeliver     bool
+	hasBackoff        bool
+}
+
+func consumerProvisionFromParsed(pConf *service.ParsedConfig) (*consumerProvision, error) {
+	conf := pConf.Namespace(pcFieldProvisionConsumer)
+	if enabled, err := conf.FieldBool(pcFieldEnabled); err != nil || !enabled {
+		return nil, err
+	}
+
+	var p consumerProvision
+	var err error
+	if conf.Contains(pcFieldFilterSubjects) {
+		filterSubjects, err := conf.FieldStringList(pcFieldFilterSubjects)
+		if err != nil {
+			return nil, err
+		}
+		if len(filterSubjects) == 1 {
+			p.cfg.FilterSubject = filterSubjects[0]
+		} else if len(filterSubjects) > 1 {
+			p.cfg.FilterSubjects = filterSubjects
+		}
+		p.hasFilterSubjects = true
+	}
+
+	ackPolicy, err := conf.FieldString(pcFieldAckPolicy)
+	if err != nil {
+		return nil, err
+	}
+	switch ackPolicy {
+	case "explicit":
+		p.cfg.AckPolicy = nats.AckExplicitPolicy
+	case "all":
+		p.cfg.AckPolicy = nats.AckAllPolicy
+	case "none":
+		p.cfg.AckPolicy = nats.AckNonePolicy
+	default:
+		return nil, fmt.Errorf("ack policy %v was not recognised", ackPolicy)
+	}
+
+	if conf.Contains(pcFieldMaxDeliver) {
+		if p.cfg.MaxDeliver, err = conf.FieldInt(pcFieldMaxDeliver); err != nil {
+			return nil, err
+		}
+		p.hasMaxDeliver = true
+	}
+
+	if conf.Contains(pcFieldBackoff) {
+		backoffStrs, err := conf.FieldStringList(pcFieldBackoff)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range backoffStrs {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse backoff duration: %w", err)
+			}
+			p.cfg.BackOff = append(p.cfg.BackOff, d)
+		}
+		p.hasBackoff = true
+	}
+	if n := len(p.cfg.BackOff); n > 0 && p.cfg.MaxDeliver > 0 && p.cfg.MaxDeliver <= n {
+		return nil, fmt.Errorf("max_deliver must be greater than the %v backoff durations", n)
+	}
+
+	replayPolicy, err := conf.FieldString(pcFieldReplayPolicy)
+	if err != nil {
+		return nil, err
+	}
+	switch replayPolicy {
+	case "instant":
+		p.cfg.ReplayPolicy = nats.ReplayInstantPolicy
+	case "original":
+		p.cfg.ReplayPolicy = nats.ReplayOriginalPolicy
+	default:
+		return nil, fmt.Errorf("replay policy %v was not recognised", replayPolicy)
+	}
+	return &p, nil
+}
+
+// provision creates the durable pull consumer, or updates the fields of an
+// existing consumer that are explicitly set by this provision.
+func (p *consumerProvision) provision(jCtx nats.JetStreamContext, stream, durable string, deliverPolicy nats.DeliverPolicy, ackWait time.Duration, maxAckPending int) error {
+	info, err := jCtx.ConsumerInfo(stream, durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		cfg := p.cfg
+		cfg.Durable = durable
+		cfg.DeliverPolicy = deliverPolicy
+		cfg.AckWait = ackWait
+		cfg.MaxAckPending = maxAckPending
+		_, err = jCtx.AddConsumer(stream, &cfg)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// The deliver, ack and replay policies of an existing consumer cannot be
+	// changed and are therefore left as is.
+	cfg := info.Config
+	if p.hasFilterSubjects {
+		cfg.FilterSubject = p.cfg.FilterSubject
+		cfg.FilterSubjects = p.cfg.FilterSubjects
+	}
+	if p.hasMaxDeliver {
+		cfg.MaxDeliver = p.cfg.MaxDeliver
+	}
+	if p.hasBackoff {
+		cfg.BackOff = p.cfg.BackOff
+	}
+	cfg.AckWait = ackWait
+	cfg.MaxAckPending = maxAckPending
+	_, err = jCtx.UpdateConsumer(stream, &cfg)
+	return err
+}
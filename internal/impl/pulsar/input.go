@@ -68,6 +68,14 @@ This input adds the following metadata fields to each message:
 
 You can access these metadata fields using
 xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+
+== Schemas
+
+When ` + "`schema.enabled`" + ` is ` + "`true`" + ` messages are decoded into JSON documents according to their schema. Avro and JSON messages are decoded with the schema registered with the broker for the schema version of each message, and so a ` + "`definition`" + ` is only required in order to register the schema of the consumer. Protobuf messages are always decoded with the configured ` + "`definition`" + `. Messages that cannot be decoded are passed through unchanged with an error flag that can be handled as described in xref:configuration:error_handling.adoc[].
+
+== Dead letter policy
+
+Messages that are rejected (nacked) are redelivered after ` + "`nack_redelivery_delay`" + `. In order to prevent messages that can never be processed from being redelivered indefinitely a ` + "`dead_letter_policy`" + ` can be configured, which sends messages that have been delivered ` + "`max_redeliveries`" + ` times to a dead letter topic. Enabling ` + "`retry_enabled`" + ` also sends rejected messages to a retry letter topic rather than having the broker redeliver them, which is compatible with the retry letter topics of the Java client.
 `).
 		Field(service.NewURLField("url").
 			Description("A URL to connect to.").
@@ -83,11 +91,47 @@ xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
 		Field(service.NewStringField("subscription_name").
 			Description("Specify the subscription name for this consumer.")).
 		Field(service.NewStringEnumField("subscription_type", "shared", "key_shared", "failover", "exclusive").
-			Description("Specify the subscription type for this consumer.\n\n> NOTE: Using a `key_shared` subscription type will __allow out-of-order delivery__ since nack-ing messages sets non-zero nack delivery delay - this can potentially cause consumers to stall. See https://pulsar.apache.org/docs/en/2.8.1/concepts-messaging/#negative-acknowledgement[Pulsar documentation^] and https://github.com/apache/pulsar/issues/12208[this Github issue^] for more details.").
+			Description("Specify the subscription type for this consumer.\n\n> NOTE: By default a `key_shared` subscription type will __allow out-of-order delivery__ since nack-ing messages sets non-zero nack delivery delay - this can potentially cause consumers to stall. See https://pulsar.apache.org/docs/en/2.8.1/concepts-messaging/#negative-acknowledgement[Pulsar documentation^] and https://github.com/apache/pulsar/issues/12208[this Github issue^] for more details.").
 			Default(defaultSubscriptionType)).
 		Field(service.NewStringEnumField("subscription_initial_position", "latest", "earliest").
 			Description("Specify the subscription initial position for this consumer.").
 			Default(defaultSubscriptionInitialPosition)).
+		Field(service.NewObjectField("key_shared",
+			service.NewBoolField("allow_out_of_order_delivery").
+				Description("Whether the broker is allowed to deliver messages of the same key out of order in case of failures, which prevents new consumers from being stalled by slow existing consumers.").
+				Default(true),
+			service.NewStringListField("sticky_hash_ranges").
+				Description("A list of hash ranges of keys to consume from in the form `start-end`, where each bound is between 0 and 65535. When set the consumer is attached to these ranges rather than being assigned ranges automatically by the broker.").
+				Default([]any{}).
+				Example([]string{"0-32767"}).
+				Example([]string{"0-16383", "32768-49151"}),
+		).
+			Description("Options specific to `key_shared` subscriptions.").
+			Version("4.33.0").
+			Advanced()).
+		Field(service.NewDurationField("nack_redelivery_delay").
+			Description("The delay after which messages that are rejected (nacked) are redelivered.").
+			Version("4.33.0").
+			Default("1m").
+			Advanced()).
+		Field(service.NewObjectField("dead_letter_policy",
+			service.NewIntField("max_redeliveries").
+				Description("The maximum number of times a message is delivered before it is sent to the dead letter topic. Set to `0` in order to disable the dead letter policy.").
+				Default(0),
+			service.NewStringField("dead_letter_topic").
+				Description("The topic that messages exceeding `max_redeliveries` are sent to. When empty and `retry_enabled` is `true` it defaults to `<topic>-<subscription_name>-DLQ`.").
+				Default(""),
+			service.NewBoolField("retry_enabled").
+				Description("Whether rejected messages are sent to a retry letter topic after `nack_redelivery_delay`, rather than redelivered by the broker. The retry letter topic is consumed alongside the configured topics, and messages that were retried more than `max_redeliveries` times are sent to the dead letter topic. This requires that `topics` is set.").
+				Default(false),
+			service.NewStringField("retry_letter_topic").
+				Description("The topic that rejected messages are sent to when `retry_enabled` is `true`. When empty it defaults to `<topic>-<subscription_name>-RETRY`.").
+				Default(""),
+		).
+			Description("A dead letter policy that moves messages which repeatedly fail to be processed to a dead letter topic, rather than redelivering them indefinitely.").
+			Version("4.33.0").
+			Advanced()).
+		Field(schemaField(false)).
 		Field(service.NewObjectField("tls",
 			service.NewStringField("root_cas_file").
 				Description("An optional path of a root certificate authority file to use. This is a file, often with a .pem extension, containing a certificate chain from the parent trusted root certificate, to possible intermediate signing certificates, to the host certificate.").
@@ -114,6 +158,11 @@ type pulsarReader struct {
 	subType       string
 	subInitial    string
 	rootCasFile   string
+	keyShared     *pulsar.KeySharedPolicy
+	nackDelay     time.Duration
+	dlq           *pulsar.DLQPolicy
+	retryEnabled  bool
+	schema        *messageSchema
 }
 
 func newPulsarReaderFromParsed(conf *service.ParsedConfig, log *service.Logger) (p *pulsarReader, err error) {
@@ -175,6 +224,103 @@ func newPulsarReaderFromParsed(conf *service.ParsedConfig, log *service.Logger)
 	}
 	if err = p.authConf.Validate(); err != nil {
 		err = fmt.Errorf("field auth is invalid: %v", err)
+		return
+	}
+	if p.keyShared, err = keySharedPolicyFromParsed(conf); err != nil {
+		return
+	}
+	if p.nackDelay, err = conf.FieldDuration("nack_redelivery_delay"); err != nil {
+		return
+	}
+	if p.dlq, p.retryEnabled, err = dlqPolicyFromParsed(conf); err != nil {
+		return
+	}
+	if p.retryEnabled && len(p.topics) == 0 {
+		err = errors.New("field dead_letter_policy.retry_enabled requires topics to be set")
+		return
+	}
+	p.schema, err = schemaFromParsed(conf, false)
+	return
+}
+
+func keySharedPolicyFromParsed(conf *service.ParsedConfig) (*pulsar.KeySharedPolicy, error) {
+	conf = conf.Namespace("key_shared")
+
+	allowOutOfOrder, err := conf.FieldBool("allow_out_of_order_delivery")
+	if err != nil {
+		return nil, err
+	}
+
+	rangeStrs, err := conf.FieldStringList("sticky_hash_ranges")
+	if err != nil {
+		return nil, err
+	}
+	if len(rangeStrs) == 0 {
+		return &pulsar.KeySharedPolicy{
+			Mode:                    pulsar.KeySharedPolicyModeAutoSplit,
+			AllowOutOfOrderDelivery: allowOutOfOrder,
+		}, nil
+	}
+
+	var hashRanges []int
+	for _, r := range rangeStrs {
+		var start, end int
+		if _, err := fmt.Sscanf(r, "%d-%d", &start, &end); err != nil {
+			return nil, fmt.Errorf("field key_shared.sticky_hash_ranges contains invalid range %q: expected the form start-end", r)
+		}
+		hashRanges = append(hashRanges, start, end)
+	}
+
+	policy, err := pulsar.NewKeySharedPolicySticky(hashRanges)
+	if err != nil {
+		return nil, fmt.Errorf("field key_shared.sticky_hash_ranges is invalid: %w", err)
+	}
+	policy.AllowOutOfOrderDelivery = allowOutOfOrder
+	return policy, nil
+}
+
+func dlqPolicyFromParsed(conf *service.ParsedConfig) (policy *pulsar.DLQPolicy, retryEnabled bool, err error) {
+	conf = conf.Namespace("dead_letter_policy")
+
+	var maxRedeliveries int
+	if maxRedeliveries, err = conf.FieldInt("max_redeliveries"); err != nil {
+		return
+	}
+	if retryEnabled, err = conf.FieldBool("retry_enabled"); err != nil {
+		return
+	}
+
+	var dlqTopic, retryTopic string
+	if dlqTopic, err = conf.FieldString("dead_letter_topic"); err != nil {
+		return
+	}
+	if retryTopic, err = conf.FieldString("retry_letter_topic"); err != nil {
+		return
+	}
+
+	if maxRedeliveries < 0 {
+		err = errors.New("field dead_letter_policy.max_redeliveries must not be negative")
+		return
+	}
+	if maxRedeliveries == 0 {
+		if dlqTopic != "" || retryTopic != "" || retryEnabled {
+			err = errors.New("field dead_letter_policy.max_redeliveries must be set in order to enable a dead letter policy")
+		}
+		return
+	}
+	if dlqTopic == "" && !retryEnabled {
+		err = errors.New("field dead_letter_policy.dead_letter_topic must be set unless retry_enabled is true")
+		return
+	}
+	if retryTopic != "" && !retryEnabled {
+		err = errors.New("field dead_letter_policy.retry_letter_topic requires retry_enabled to be true")
+		return
+	}
+
+	policy = &pulsar.DLQPolicy{
+		MaxDeliveries:    uint32(maxRedeliveries),
+		DeadLetterTopic:  dlqTopic,
+		RetryLetterTopic: retryTopic,
 	}
 	return
 }
@@ -253,9 +399,16 @@ func (p *pulsarReader) Connect(ctx context.Context) error {
 		SubscriptionName:            p.subName,
 		SubscriptionInitialPosition: subInitial,
 		Type:                        subType,
-		KeySharedPolicy: &pulsar.KeySharedPolicy{
-			AllowOutOfOrderDelivery: true,
-		},
+		KeySharedPolicy:             p.keyShared,
+		NackRedeliveryDelay:         p.nackDelay,
+		RetryEnable:                 p.retryEnabled,
+		Schema:                      p.schema.pulsarSchema(),
+	}
+	if p.dlq != nil {
+		// The client fills in default topics, and so each connection gets its
+		// own copy of the policy.
+		dlq := *p.dlq
+		options.DLQ = &dlq
 	}
 	if consumer, err = client.Subscribe(options); err != nil {
 		client.Close()
@@ -307,6 +460,15 @@ func (p *pulsarReader) Read(ctx context.Context) (*service.Message, service.AckF
 	}
 
 	msg := service.NewMessage(pulMsg.Payload())
+	if p.schema != nil {
+		// Messages that cannot be decoded are delivered as they are with the
+		// error attached, so that they can be handled within the pipeline.
+		if decoded, err := p.schema.decode(pulMsg); err != nil {
+			msg.SetError(err)
+		} else {
+			msg.SetBytes(decoded)
+		}
+	}
 
 	msg.MetaSet("pulsar_message_id", string(pulMsg.ID().Serialize()))
 	msg.MetaSet("pulsar_topic", pulMsg.Topic())
@@ -337,7 +499,11 @@ func (p *pulsarReader) Read(ctx context.Context) (*service.Message, service.AckF
 		p.m.RUnlock()
 		if r != nil {
 			if res != nil {
-				r.Nack(pulMsg)
+				if p.retryEnabled {
+					r.ReconsumeLater(pulMsg, p.nackDelay)
+				} else {
+					r.Nack(pulMsg)
+				}
 			} else {
 				return r.Ack(pulMsg)
 			}
@@ -17,7 +17,10 @@ package pulsar
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/redpanda-data/benthos/v4/public/service"
@@ -73,3 +76,117 @@ subscription_name: "sub"
 		})
 	}
 }
+
+func TestParseInputPolicies(t *testing.T) {
+	tests := []struct {
+		name, config string
+		errStr       string
+		check        func(t *testing.T, r *pulsarReader)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, r *pulsarReader) {
+				assert.Equal(t, pulsar.KeySharedPolicyModeAutoSplit, r.keyShared.Mode)
+				assert.True(t, r.keyShared.AllowOutOfOrderDelivery)
+				assert.Equal(t, time.Minute, r.nackDelay)
+				assert.Nil(t, r.dlq)
+				assert.False(t, r.retryEnabled)
+				assert.Nil(t, r.schema)
+			},
+		},
+		{
+			name: "sticky key shared",
+			config: `
+key_shared:
+  allow_out_of_order_delivery: false
+  sticky_hash_ranges: [ "0-16383", "32768-49151" ]
+`,
+			check: func(t *testing.T, r *pulsarReader) {
+				assert.Equal(t, pulsar.KeySharedPolicyModeSticky, r.keyShared.Mode)
+				assert.False(t, r.keyShared.AllowOutOfOrderDelivery)
+				assert.Equal(t, []int{0, 16383, 32768, 49151}, r.keyShared.HashRanges)
+			},
+		},
+		{
+			name:   "invalid hash range",
+			config: `key_shared: { sticky_hash_ranges: [ "nope" ] }`,
+			errStr: `field key_shared.sticky_hash_ranges contains invalid range "nope": expected the form start-end`,
+		},
+		{
+			name: "dead letter topic",
+			config: `
+nack_redelivery_delay: 5s
+dead_letter_policy:
+  max_redeliveries: 3
+  dead_letter_topic: my_cool_topic-DLQ
+`,
+			check: func(t *testing.T, r *pulsarReader) {
+				assert.Equal(t, 5*time.Second, r.nackDelay)
+				assert.Equal(t, &pulsar.DLQPolicy{
+					MaxDeliveries:   3,
+					DeadLetterTopic: "my_cool_topic-DLQ",
+				}, r.dlq)
+				assert.False(t, r.retryEnabled)
+			},
+		},
+		{
+			name: "retry letter topic",
+			config: `
+dead_letter_policy:
+  max_redeliveries: 3
+  retry_enabled: true
+  retry_letter_topic: my_cool_topic-RETRY
+`,
+			check: func(t *testing.T, r *pulsarReader) {
+				assert.Equal(t, &pulsar.DLQPolicy{
+					MaxDeliveries:    3,
+					RetryLetterTopic: "my_cool_topic-RETRY",
+				}, r.dlq)
+				assert.True(t, r.retryEnabled)
+			},
+		},
+		{
+			name:   "dead letter topic without max redeliveries",
+			config: `dead_letter_policy: { dead_letter_topic: foo }`,
+			errStr: "field dead_letter_policy.max_redeliveries must be set in order to enable a dead letter policy",
+		},
+		{
+			name:   "max redeliveries without dead letter topic",
+			config: `dead_letter_policy: { max_redeliveries: 3 }`,
+			errStr: "field dead_letter_policy.dead_letter_topic must be set unless retry_enabled is true",
+		},
+		{
+			name:   "protobuf schema without definition",
+			config: `schema: { enabled: true, type: protobuf }`,
+			errStr: "field schema.definition must be set for protobuf schemas",
+		},
+		{
+			name:   "avro schema without definition",
+			config: `schema: { enabled: true, type: avro }`,
+			check: func(t *testing.T, r *pulsarReader) {
+				require.NotNil(t, r.schema)
+				assert.Nil(t, r.schema.pulsarSchema())
+			},
+		},
+	}
+
+	baseConfig := `
+url: pulsar://localhost:6650/
+subscription_name: "sub"
+topics: [ my_cool_topic ]
+`
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := inputConfigSpec().ParseYAML(baseConfig+test.config, nil)
+			require.NoError(t, err)
+
+			reader, err := newPulsarReaderFromParsed(parsed, service.MockResources().Logger())
+			if test.errStr != "" {
+				require.EqualError(t, err, test.errStr)
+				return
+			}
+			require.NoError(t, err)
+			test.check(t, reader)
+		})
+	}
+}
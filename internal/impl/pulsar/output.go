@@ -68,6 +68,7 @@ func outputConfigSpec() *service.ConfigSpec {
 		Field(service.NewInterpolatedStringField("ordering_key").
 			Description("The ordering key to publish messages with.").
 			Default("")).
+		Field(schemaField(true)).
 		Field(service.NewIntField("max_in_flight").
 			Description("The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").
 			Default(64)).
@@ -89,6 +90,7 @@ type pulsarWriter struct {
 	rootCasFile string
 	key         *service.InterpolatedString
 	orderingKey *service.InterpolatedString
+	schema      *messageSchema
 }
 
 func newPulsarWriterFromParsed(conf *service.ParsedConfig, log *service.Logger) (p *pulsarWriter, err error) {
@@ -115,6 +117,7 @@ func newPulsarWriterFromParsed(conf *service.ParsedConfig, log *service.Logger)
 	if p.orderingKey, err = conf.FieldInterpolatedString("ordering_key"); err != nil {
 		return
 	}
+	p.schema, err = schemaFromParsed(conf, true)
 	return
 }
 
@@ -152,7 +155,8 @@ func (p *pulsarWriter) Connect(ctx context.Context) error {
 	}
 
 	if producer, err = client.CreateProducer(pulsar.ProducerOptions{
-		Topic: p.topic,
+		Topic:  p.topic,
+		Schema: p.schema.pulsarSchema(),
 	}); err != nil {
 		client.Close()
 		return err
@@ -198,6 +202,11 @@ func (p *pulsarWriter) Write(ctx context.Context, msg *service.Message) error {
 	if err != nil {
 		return err
 	}
+	if p.schema != nil {
+		if b, err = p.schema.encode(b); err != nil {
+			return err
+		}
+	}
 
 	m := &pulsar.ProducerMessage{
 		Payload: b,
@@ -0,0 +1,244 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulsar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+
+	"github.com/redpanda-data/connect/v4/internal/impl/protobuf"
+)
+
+const (
+	schemaTypeAvro     = "avro"
+	schemaTypeJSON     = "json"
+	schemaTypeProtobuf = "protobuf"
+)
+
+func schemaField(definitionRequired bool) *service.ConfigField {
+	definitionDesc := "The schema definition. For `avro` and `json` schemas this is an Avro schema, as JSON schemas in Pulsar are described with Avro definitions. For `protobuf` schemas this is the contents of a `.proto` file, which is registered with the broker as a native Protobuf schema."
+	if !definitionRequired {
+		definitionDesc += " When empty the schema of each message is fetched from the broker according to its schema version, which is only supported for `avro` and `json` schemas."
+	}
+	return service.NewObjectField("schema",
+		service.NewBoolField("enabled").
+			Description("Whether messages are (de)serialised with a Pulsar schema.").
+			Default(false),
+		service.NewStringAnnotatedEnumField("type", map[string]string{
+			schemaTypeAvro:     "Messages are Avro encoded, structured documents are converted to and from the JSON representation of the schema.",
+			schemaTypeJSON:     "Messages are JSON documents.",
+			schemaTypeProtobuf: "Messages are Protobuf encoded, structured documents are converted to and from their JSON representation.",
+		}).
+			Description("The type of schema.").
+			Default(schemaTypeAvro),
+		service.NewStringField("definition").
+			Description(definitionDesc).
+			Default("").
+			Example(`{"type":"record","name":"Example","fields":[{"name":"id","type":"string"}]}`),
+		service.NewStringField("message").
+			Description("The fully qualified name of the Protobuf message within the `definition` to use, required for `protobuf` schemas.").
+			Default("").
+			Example("example.v1.Event"),
+	).
+		Description("Enables the use of Pulsar schemas. When connecting the schema is registered with the broker, which checks that it is compatible with the schema of the topic.").
+		Version("4.33.0").
+		Advanced()
+}
+
+// messageSchema converts between the raw payloads of Pulsar messages and the
+// JSON documents processed by a pipeline.
+type messageSchema struct {
+	typ    string
+	schema pulsar.Schema
+	proto  protoreflect.MessageType
+}
+
+// schemaFromParsed returns the configured schema, or nil if schemas are not
+// enabled.
+func schemaFromParsed(conf *service.ParsedConfig, definitionRequired bool) (*messageSchema, error) {
+	conf = conf.Namespace("schema")
+
+	enabled, err := conf.FieldBool("enabled")
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	s := &messageSchema{}
+	if s.typ, err = conf.FieldString("type"); err != nil {
+		return nil, err
+	}
+
+	var definition, message string
+	if definition, err = conf.FieldString("definition"); err != nil {
+		return nil, err
+	}
+	if message, err = conf.FieldString("message"); err != nil {
+		return nil, err
+	}
+
+	if definition == "" {
+		if definitionRequired || s.typ == schemaTypeProtobuf {
+			return nil, fmt.Errorf("field schema.definition must be set for %v schemas", s.typ)
+		}
+		return s, nil
+	}
+
+	switch s.typ {
+	case schemaTypeAvro:
+		if s.schema, err = pulsar.NewAvroSchemaWithValidation(definition, nil); err != nil {
+			return nil, fmt.Errorf("failed to parse avro schema: %w", err)
+		}
+	case schemaTypeJSON:
+		if s.schema, err = pulsar.NewJSONSchemaWithValidation(definition, nil); err != nil {
+			return nil, fmt.Errorf("failed to parse json schema: %w", err)
+		}
+	case schemaTypeProtobuf:
+		if message == "" {
+			return nil, errors.New("field schema.message must be set for protobuf schemas")
+		}
+		if s.schema, s.proto, err = newProtoNativeSchema(definition, message); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("schema type %v is not supported", s.typ)
+	}
+	return s, nil
+}
+
+// newProtoNativeSchema parses a .proto definition into a native Protobuf
+// schema, which carries the file descriptors of the message in the same format
+// as the Java client.
+func newProtoNativeSchema(definition, message string) (pulsar.Schema, protoreflect.MessageType, error) {
+	const path = "schema.proto"
+
+	files, types, err := protobuf.RegistriesFromMap(map[string]string{path: definition})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse proto schema: %w", err)
+	}
+
+	msgType, err := types.FindMessageByName(protoreflect.FullName(message))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find message %v in proto schema: %w", message, err)
+	}
+
+	fileDesc, err := files.FindFileByPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fileSet descriptorpb.FileDescriptorSet
+	seen := map[string]struct{}{}
+	var addFile func(fd protoreflect.FileDescriptor)
+	addFile = func(fd protoreflect.FileDescriptor) {
+		if _, exists := seen[fd.Path()]; exists {
+			return
+		}
+		seen[fd.Path()] = struct{}{}
+		for i := 0; i < fd.Imports().Len(); i++ {
+			addFile(fd.Imports().Get(i).FileDescriptor)
+		}
+		fileSet.File = append(fileSet.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	addFile(msgType.Descriptor().ParentFile())
+
+	fileSetBytes, err := proto.Marshal(&fileSet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schemaData, err := json.Marshal(pulsar.ProtoNativeSchemaData{
+		FileDescriptorSet:      fileSetBytes,
+		RootMessageTypeName:    message,
+		RootFileDescriptorName: fileDesc.Path(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schema, err := pulsar.NewSchema(pulsar.ProtoNative, schemaData, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schema, msgType, nil
+}
+
+// decode returns the JSON representation of a consumed message.
+func (s *messageSchema) decode(msg pulsar.Message) (b []byte, err error) {
+	if s.proto != nil {
+		dynMsg := dynamicpb.NewMessage(s.proto.Descriptor())
+		if err := proto.Unmarshal(msg.Payload(), dynMsg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+		}
+		return protojson.Marshal(dynMsg)
+	}
+
+	if msg.SchemaVersion() == nil && s.schema == nil {
+		return nil, errors.New("message was not produced with a schema")
+	}
+
+	// The client decodes messages using the schema registered for their schema
+	// version, and panics when the schema is not of the type we expect.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("message schema is not of type %v: %v", s.typ, r)
+		}
+	}()
+
+	var raw json.RawMessage
+	if err := msg.GetSchemaValue(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode %v message: %w", s.typ, err)
+	}
+	return raw, nil
+}
+
+// encode returns the payload of a message to produce from its JSON
+// representation.
+func (s *messageSchema) encode(b []byte) ([]byte, error) {
+	if s.proto != nil {
+		dynMsg := dynamicpb.NewMessage(s.proto.Descriptor())
+		if err := protojson.Unmarshal(b, dynMsg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON into protobuf message: %w", err)
+		}
+		return proto.Marshal(dynMsg)
+	}
+
+	if !json.Valid(b) {
+		return nil, errors.New("message is not a valid JSON document")
+	}
+	encoded, err := s.schema.Encode(json.RawMessage(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %v message: %w", s.typ, err)
+	}
+	return encoded, nil
+}
+
+// pulsarSchema returns the schema to register with the broker, if any.
+func (s *messageSchema) pulsarSchema() pulsar.Schema {
+	if s == nil {
+		return nil
+	}
+	return s.schema
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulsar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type payloadMessage struct {
+	pulsar.Message
+	payload []byte
+}
+
+func (m payloadMessage) Payload() []byte {
+	return m.payload
+}
+
+func (m payloadMessage) SchemaVersion() []byte {
+	return nil
+}
+
+func parseTestSchema(t *testing.T, conf string) *messageSchema {
+	t.Helper()
+
+	parsed, err := outputConfigSpec().ParseYAML(`
+url: pulsar://localhost:6650/
+topic: foo
+`+conf, nil)
+	require.NoError(t, err)
+
+	s, err := schemaFromParsed(parsed, true)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	return s
+}
+
+func TestSchemaAvroEncode(t *testing.T) {
+	s := parseTestSchema(t, `
+schema:
+  enabled: true
+  type: avro
+  definition: '{"type":"record","name":"Example","fields":[{"name":"id","type":"string"},{"name":"count","type":"long"}]}'
+`)
+	assert.Equal(t, pulsar.AVRO, s.pulsarSchema().GetSchemaInfo().Type)
+
+	encoded, err := s.encode([]byte(`{"id":"foo","count":5}`))
+	require.NoError(t, err)
+
+	var decoded json.RawMessage
+	require.NoError(t, s.pulsarSchema().Decode(encoded, &decoded))
+	assert.JSONEq(t, `{"id":"foo","count":5}`, string(decoded))
+
+	_, err = s.encode([]byte(`{"id":"foo"`))
+	require.EqualError(t, err, "message is not a valid JSON document")
+}
+
+func TestSchemaJSONEncode(t *testing.T) {
+	s := parseTestSchema(t, `
+schema:
+  enabled: true
+  type: json
+  definition: '{"type":"record","name":"Example","fields":[{"name":"id","type":"string"}]}'
+`)
+	assert.Equal(t, pulsar.JSON, s.pulsarSchema().GetSchemaInfo().Type)
+
+	encoded, err := s.encode([]byte(`{ "id": "foo" }`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"foo"}`, string(encoded))
+}
+
+func TestSchemaProtobufRoundTrip(t *testing.T) {
+	s := parseTestSchema(t, `
+schema:
+  enabled: true
+  type: protobuf
+  message: example.v1.Event
+  definition: |
+    syntax = "proto3";
+    package example.v1;
+    message Event {
+      string id = 1;
+      int32 count = 2;
+    }
+`)
+
+	info := s.pulsarSchema().GetSchemaInfo()
+	assert.Equal(t, pulsar.SchemaType(pulsar.ProtoNative), info.Type)
+
+	var schemaData pulsar.ProtoNativeSchemaData
+	require.NoError(t, json.Unmarshal([]byte(info.Schema), &schemaData))
+	assert.Equal(t, "example.v1.Event", schemaData.RootMessageTypeName)
+	assert.Equal(t, "schema.proto", schemaData.RootFileDescriptorName)
+
+	encoded, err := s.encode([]byte(`{"id":"foo","count":5}`))
+	require.NoError(t, err)
+
+	decoded, err := s.decode(payloadMessage{payload: encoded})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"foo","count":5}`, string(decoded))
+}
+
+func TestSchemaProtobufMissingMessage(t *testing.T) {
+	parsed, err := outputConfigSpec().ParseYAML(`
+url: pulsar://localhost:6650/
+topic: foo
+schema:
+  enabled: true
+  type: protobuf
+  message: example.v1.Nope
+  definition: |
+    syntax = "proto3";
+    package example.v1;
+    message Event {
+      string id = 1;
+    }
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newPulsarWriterFromParsed(parsed, service.MockResources().Logger())
+	require.ErrorContains(t, err, "failed to find message example.v1.Nope in proto schema")
+}
+
+func TestSchemaDecodeWithoutVersion(t *testing.T) {
+	s := &messageSchema{typ: schemaTypeAvro}
+	_, err := s.decode(payloadMessage{payload: []byte(`foo`)})
+	require.EqualError(t, err, "message was not produced with a schema")
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rabbitmqstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/rabbitmq-stream-go-client/pkg/amqp"
+	"github.com/rabbitmq/rabbitmq-stream-go-client/pkg/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestInputConfigParsing(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		offsetSpec stream.OffsetSpecification
+		errContain string
+	}{
+		{
+			name: "defaults",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+stream: foo
+`,
+			offsetSpec: stream.OffsetSpecification{}.First(),
+		},
+		{
+			name: "offset",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+stream: foo
+offset: offset
+offset_value: 42
+`,
+			offsetSpec: stream.OffsetSpecification{}.Offset(42),
+		},
+		{
+			name: "timestamp",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+super_stream: foo
+offset: timestamp
+offset_value: 1700000000000
+`,
+			offsetSpec: stream.OffsetSpecification{}.Timestamp(1700000000000),
+		},
+		{
+			name: "no stream",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+`,
+			errContain: "either stream or super_stream must be specified",
+		},
+		{
+			name: "both streams",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+stream: foo
+super_stream: bar
+`,
+			errContain: "only one of stream and super_stream can be specified",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := inputSpec().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			r, err := newStreamReaderFromParsed(conf, service.MockResources())
+			if test.errContain != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContain)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.offsetSpec.String(), r.offsetSpec.String())
+		})
+	}
+}
+
+func TestInputURLExpansion(t *testing.T) {
+	conf, err := inputSpec().ParseYAML(`
+urls:
+  - rabbitmq-stream://localhost:5552/,rabbitmq-stream://localhost:5553/
+  - rabbitmq-stream://localhost:5554/
+super_stream: foo
+consumer_name: bar
+`, nil)
+	require.NoError(t, err)
+
+	r, err := newStreamReaderFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"rabbitmq-stream://localhost:5552/",
+		"rabbitmq-stream://localhost:5553/",
+		"rabbitmq-stream://localhost:5554/",
+	}, r.env.urls)
+	assert.Equal(t, "redpanda-connect", r.env.clientName)
+	assert.Equal(t, "foo", r.streamName)
+	assert.True(t, r.isSuper)
+	assert.Equal(t, "bar", r.consumerName)
+}
+
+func TestMessageFromDelivery(t *testing.T) {
+	created := time.UnixMilli(1700000000000)
+	msg := messageFromDelivery(streamDelivery{
+		offset: 10,
+		msg: &amqp.Message{
+			Data: [][]byte{[]byte("hello "), []byte("world")},
+			Properties: &amqp.MessageProperties{
+				MessageID:    "abc",
+				ContentType:  "text/plain",
+				CreationTime: created,
+			},
+			ApplicationProperties: map[string]any{
+				"tenant": "acme",
+			},
+		},
+	}, "foo-0")
+
+	mBytes, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(mBytes))
+
+	meta := map[string]any{}
+	require.NoError(t, msg.MetaWalkMut(func(k string, v any) error {
+		meta[k] = v
+		return nil
+	}))
+	assert.Equal(t, map[string]any{
+		"tenant":                        "acme",
+		"rabbitmq_stream_message_id":    "abc",
+		"rabbitmq_stream_content_type":  "text/plain",
+		"rabbitmq_stream_creation_time": int64(1700000000000),
+		"rabbitmq_stream_name":          "foo-0",
+		"rabbitmq_stream_offset":        int64(10),
+	}, meta)
+}
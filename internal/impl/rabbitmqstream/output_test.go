@@ -0,0 +1,154 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rabbitmqstream
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestOutputConfigValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		errContain string
+	}{
+		{
+			name: "super stream without routing key",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+super_stream: foo
+`,
+			errContain: "field routing_key is required when publishing to a super stream",
+		},
+		{
+			name: "publishing id without producer name",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+stream: foo
+publishing_id: ${! @id }
+`,
+			errContain: "field publishing_id requires a producer_name",
+		},
+		{
+			name: "publishing id with sub entries",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+stream: foo
+producer_name: bar
+publishing_id: ${! @id }
+sub_entry_size: 10
+`,
+			errContain: "field publishing_id cannot be used with a sub_entry_size greater than 1",
+		},
+		{
+			name: "compression without sub entries",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+stream: foo
+compression: zstd
+`,
+			errContain: "field compression requires a sub_entry_size greater than 1",
+		},
+		{
+			name: "compressed sub entries",
+			config: `
+urls: [ rabbitmq-stream://localhost:5552/ ]
+super_stream: foo
+routing_key: ${! @key }
+sub_entry_size: 10
+compression: zstd
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := outputSpec().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newStreamWriterFromParsed(conf, service.MockResources())
+			if test.errContain == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContain)
+		})
+	}
+}
+
+func TestOutboundFromMessage(t *testing.T) {
+	conf, err := outputSpec().ParseYAML(`
+urls: [ rabbitmq-stream://localhost:5552/ ]
+super_stream: foo
+routing_key: ${! @customer }
+producer_name: bar
+publishing_id: ${! @id }
+metadata:
+  include_prefixes: [ cust ]
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newStreamWriterFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	msg := service.NewMessage([]byte("hello world"))
+	msg.MetaSetMut("customer", "acme")
+	msg.MetaSetMut("id", "15")
+	msg.MetaSetMut("region", "eu")
+
+	tracker := newPublishTracker()
+	out, err := w.outboundFromMessage(3, msg, tracker)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]byte{[]byte("hello world")}, out.GetData())
+	assert.Equal(t, "acme", out.routingKey)
+	assert.Equal(t, 3, out.index)
+	assert.True(t, out.HasPublishingId())
+	assert.Equal(t, int64(15), out.GetPublishingId())
+	assert.Equal(t, map[string]any{"customer": "acme"}, out.ApplicationProperties)
+
+	msg.MetaSetMut("id", "nope")
+	_, err = w.outboundFromMessage(0, msg, tracker)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse publishing id")
+}
+
+func TestPublishTracker(t *testing.T) {
+	tracker := newPublishTracker()
+	tracker.add(3)
+
+	tracker.resolve(0, nil)
+	tracker.resolve(2, errors.New("nope"))
+	select {
+	case <-tracker.done:
+		t.Fatal("tracker resolved early")
+	default:
+	}
+
+	tracker.resolve(1, nil)
+	select {
+	case <-tracker.done:
+	default:
+		t.Fatal("tracker not resolved")
+	}
+	assert.Equal(t, map[int]error{2: errors.New("nope")}, tracker.errs)
+}
@@ -16,8 +16,12 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/Jeffail/checkpoint"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/redpanda-data/benthos/v4/public/service"
@@ -38,11 +42,16 @@ func init() {
 	}
 }
 
-const matchFieldName = "match"
+const (
+	matchFieldName          = "match"
+	scanCountFieldName      = "count"
+	cursorCacheFieldName    = "cursor_cache"
+	cursorCacheKeyFieldName = "cursor_cache_key"
+)
 
 func redisScanInputConfig() *service.ConfigSpec {
 	spec := service.NewConfigSpec().
-		Summary(`Scans the set of keys in the current selected database and gets their values, using the Scan and Get commands.`).
+		Summary(`Scans the set of keys in the current selected database and gets their values, using the Scan command followed by a read command appropriate for the type of each key.`).
 		Description(`Optionally, iterates only elements matching a blob-style pattern. For example:
 
 - ` + "`*foo*`" + ` iterates only keys which contain ` + "`foo`" + ` in it.
@@ -53,6 +62,34 @@ This input generates a message for each key value pair in the following format:
 ` + "```json" + `
 {"key":"foo","value":"bar"}
 ` + "```" + `
+
+The value of each key is read according to its type:
+
+- ` + "`string`" + `: a string read with GET.
+- ` + "`hash`" + `: an object of field/value pairs read with HGETALL.
+- ` + "`list`" + `: an array of strings read with LRANGE.
+- ` + "`set`" + `: an array of members read with SMEMBERS.
+- ` + "`zset`" + `: an array of objects of the form ` + "`{\"member\":\"foo\",\"score\":1.5}`" + ` read with ZRANGE.
+- ` + "`stream`" + `: an array of objects of the form ` + "`{\"id\":\"1-0\",\"values\":{\"foo\":\"bar\"}}`" + ` read with XRANGE.
+
+Keys that expire or are deleted between being scanned and being read are skipped.
+
+== Resuming scans
+
+When a ` + "`" + cursorCacheFieldName + "`" + ` is configured the SCAN cursor is stored in that cache resource once all keys returned for it have been acknowledged, and a subsequent run of the input resumes scanning from that cursor rather than from the beginning of the keyspace. Since SCAN cursors are only meaningful to the server that issued them this should only be used when resuming against the same deployment. Once the scan completes the stored cursor is reset, and therefore a subsequent run scans the entire keyspace again.
+
+Redis guarantees that keys present for the full duration of a scan are returned at least once, and therefore duplicates are possible, especially when the keyspace is modified during the scan.
+
+== Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```text" + `
+- redis_key_type
+- redis_key_ttl
+` + "```" + `
+
+The field ` + "`redis_key_ttl`" + ` is only set for keys with an expiry, and contains the remaining time to live in milliseconds at the time the key was read.
 `).
 		Categories("Services").
 		Version("4.27.0")
@@ -70,7 +107,21 @@ This input generates a message for each key value pair in the following format:
 			Example("foo*").
 			Example("foo").
 			Example("*4*").
-			Default(""))
+			Default("")).
+		Field(service.NewIntField(scanCountFieldName).
+			Description("A hint for the number of keys returned by each SCAN call, when set to zero the server default is used.").
+			Default(0).
+			Advanced().
+			Version("4.33.0")).
+		Field(service.NewStringField(cursorCacheFieldName).
+			Description("A https://www.docs.redpanda.com/redpanda-connect/components/caches/about[cache resource^] to use for storing the SCAN cursor once all keys up to that cursor have been acknowledged, this allows the scan to continue from that cursor upon restart rather than from the beginning.").
+			Optional().
+			Version("4.33.0")).
+		Field(service.NewStringField(cursorCacheKeyFieldName).
+			Description("The key identifier used when storing the SCAN cursor.").
+			Default("redis_scan_cursor").
+			Advanced().
+			Version("4.33.0"))
 }
 
 func newRedisScanInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
@@ -82,49 +133,284 @@ func newRedisScanInputFromConfig(conf *service.ParsedConfig, mgr *service.Resour
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving %s: %v", matchFieldName, err)
 	}
+	count, err := conf.FieldInt(scanCountFieldName)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("%s must not be negative", scanCountFieldName)
+	}
 	r := &redisScanReader{
-		client: client,
-		match:  match,
-		log:    mgr.Logger(),
+		client:       client,
+		match:        match,
+		count:        int64(count),
+		checkpointer: checkpoint.NewCapped[uint64](1024),
+		mgr:          mgr,
+		log:          mgr.Logger(),
+	}
+	if conf.Contains(cursorCacheFieldName) {
+		if r.cursorCache, err = conf.FieldString(cursorCacheFieldName); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(r.cursorCache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", r.cursorCache)
+		}
+	}
+	if r.cursorCacheKey, err = conf.FieldString(cursorCacheKeyFieldName); err != nil {
+		return nil, err
 	}
 	return r, nil
 }
 
 type redisScanReader struct {
 	match  string
+	count  int64
 	client redis.UniversalClient
-	iter   *redis.ScanIterator
+	mgr    *service.Resources
 	log    *service.Logger
+
+	cursorCache    string
+	cursorCacheKey string
+	checkpointer   *checkpoint.Capped[uint64]
+
+	initialised bool
+	finished    bool
+	pageCursor  uint64
+	cursor      uint64
+	keys        []string
 }
 
 func (r *redisScanReader) Connect(ctx context.Context) error {
-	_, err := r.client.Ping(ctx).Result()
+	if _, err := r.client.Ping(ctx).Result(); err != nil {
+		return err
+	}
+	if r.initialised {
+		return nil
+	}
+
+	if r.cursorCache != "" {
+		var cursorBytes []byte
+		var cacheErr error
+		if err := r.mgr.AccessCache(ctx, r.cursorCache, func(c service.Cache) {
+			if cursorBytes, cacheErr = c.Get(ctx, r.cursorCacheKey); errors.Is(cacheErr, service.ErrKeyNotFound) {
+				cacheErr = nil
+			}
+		}); err != nil {
+			return fmt.Errorf("failed to obtain scan cursor: %w", err)
+		}
+		if cacheErr != nil {
+			return fmt.Errorf("failed to obtain scan cursor: %w", cacheErr)
+		}
+		if len(cursorBytes) > 0 {
+			cursor, err := strconv.ParseUint(string(cursorBytes), 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse scan cursor: %w", err)
+			}
+			if cursor != 0 {
+				r.log.Infof("Resuming scan from cursor %v", cursor)
+			}
+			r.cursor = cursor
+		}
+	}
+
+	r.initialised = true
+	return nil
+}
+
+// nextKey returns the next key of the scan along with the cursor that can be
+// committed once that key has been processed, scanning the next page of keys
+// when required.
+func (r *redisScanReader) nextKey(ctx context.Context) (string, uint64, error) {
+	for len(r.keys) == 0 {
+		if r.finished {
+			return "", 0, service.ErrEndOfInput
+		}
+
+		keys, next, err := r.client.Scan(ctx, r.cursor, r.match, r.count).Result()
+		if err != nil {
+			return "", 0, err
+		}
+		r.keys, r.pageCursor, r.cursor = keys, r.cursor, next
+		r.finished = next == 0
+
+		if len(keys) == 0 {
+			// There's nothing to process for an empty page and therefore we
+			// can commit the next cursor straight away.
+			if err := r.commitNow(ctx, next); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+
+	var key string
+	key, r.keys = r.keys[0], r.keys[1:]
+
+	// A page of keys can only be skipped once all of its keys have been
+	// processed, and therefore only the last key of a page commits the cursor
+	// of the next page.
+	if len(r.keys) == 0 {
+		return key, r.cursor, nil
+	}
+	return key, r.pageCursor, nil
+}
+
+func (r *redisScanReader) track(ctx context.Context, cursor uint64) (func(context.Context) error, error) {
+	if r.cursorCache == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	release, err := r.checkpointer.Track(ctx, cursor, 1)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		highest := release()
+		if highest == nil {
+			return nil
+		}
+		var setErr error
+		if err := r.mgr.AccessCache(ctx, r.cursorCache, func(c service.Cache) {
+			setErr = c.Set(ctx, r.cursorCacheKey, []byte(strconv.FormatUint(*highest, 10)), nil)
+		}); err != nil {
+			return err
+		}
+		return setErr
+	}, nil
+}
+
+func (r *redisScanReader) commitNow(ctx context.Context, cursor uint64) error {
+	commitFn, err := r.track(ctx, cursor)
 	if err != nil {
 		return err
 	}
-	r.iter = r.client.Scan(context.Background(), 0, r.match, 0).Iterator()
-	return r.iter.Err()
+	return commitFn(ctx)
+}
+
+// readValue reads the type, TTL and value of a key, the type returned is
+// "none" when the key no longer exists.
+func (r *redisScanReader) readValue(ctx context.Context, key string) (keyType string, ttl time.Duration, value any, err error) {
+	pipe := r.client.Pipeline()
+	typeCmd := pipe.Type(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return
+	}
+	keyType, ttl = typeCmd.Val(), ttlCmd.Val()
+
+	switch keyType {
+	case "none":
+		return
+	case "string":
+		var s string
+		if s, err = r.client.Get(ctx, key).Result(); errors.Is(err, redis.Nil) {
+			keyType, err = "none", nil
+		}
+		value = s
+	case "hash":
+		var fields map[string]string
+		if fields, err = r.client.HGetAll(ctx, key).Result(); err != nil {
+			return
+		}
+		obj := make(map[string]any, len(fields))
+		for k, v := range fields {
+			obj[k] = v
+		}
+		value = obj
+	case "list":
+		var elements []string
+		if elements, err = r.client.LRange(ctx, key, 0, -1).Result(); err != nil {
+			return
+		}
+		value = stringsToAny(elements)
+	case "set":
+		var members []string
+		if members, err = r.client.SMembers(ctx, key).Result(); err != nil {
+			return
+		}
+		value = stringsToAny(members)
+	case "zset":
+		var members []redis.Z
+		if members, err = r.client.ZRangeWithScores(ctx, key, 0, -1).Result(); err != nil {
+			return
+		}
+		arr := make([]any, 0, len(members))
+		for _, m := range members {
+			arr = append(arr, map[string]any{
+				"member": m.Member,
+				"score":  m.Score,
+			})
+		}
+		value = arr
+	case "stream":
+		var entries []redis.XMessage
+		if entries, err = r.client.XRange(ctx, key, "-", "+").Result(); err != nil {
+			return
+		}
+		arr := make([]any, 0, len(entries))
+		for _, e := range entries {
+			arr = append(arr, map[string]any{
+				"id":     e.ID,
+				"values": e.Values,
+			})
+		}
+		value = arr
+	default:
+		err = fmt.Errorf("key %v has unsupported type: %v", key, keyType)
+	}
+	return
+}
+
+func stringsToAny(strs []string) []any {
+	arr := make([]any, 0, len(strs))
+	for _, s := range strs {
+		arr = append(arr, s)
+	}
+	return arr
 }
 
 func (r *redisScanReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
-	if r.iter.Next(ctx) {
-		key := r.iter.Val()
+	for {
+		key, commitCursor, err := r.nextKey(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keyType, ttl, value, err := r.readValue(ctx, key)
+		if err != nil {
+			// Put the key back so that it's read again on the next attempt.
+			r.keys = append([]string{key}, r.keys...)
+			return nil, nil, err
+		}
+		if keyType == "none" {
+			r.log.Debugf("Skipping key %v as it no longer exists", key)
+			if err := r.commitNow(ctx, commitCursor); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
 
-		res := r.client.Get(ctx, key)
-		if err := res.Err(); err != nil {
+		commitFn, err := r.track(ctx, commitCursor)
+		if err != nil {
 			return nil, nil, err
 		}
 
 		msg := service.NewMessage(nil)
 		msg.SetStructuredMut(map[string]any{
 			"key":   key,
-			"value": res.Val(),
+			"value": value,
 		})
+		msg.MetaSetMut("redis_key_type", keyType)
+		if ttl > 0 {
+			msg.MetaSetMut("redis_key_ttl", ttl.Milliseconds())
+		}
 		return msg, func(ctx context.Context, err error) error {
-			return err
+			if err != nil {
+				// The cursor must not move past a key that wasn't delivered,
+				// so that it's read again once the input restarts.
+				return nil
+			}
+			return commitFn(ctx)
 		}, nil
 	}
-	return nil, nil, service.ErrEndOfInput
 }
 
 func (r *redisScanReader) Close(ctx context.Context) (err error) {
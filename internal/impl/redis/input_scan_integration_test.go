@@ -0,0 +1,120 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/redpanda-data/benthos/v4/public/service/integration"
+)
+
+func TestIntegrationRedisScanTypes(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+	resource, err := pool.Run("redis", "latest", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	client := redis.NewClient(&redis.Options{
+		Addr: fmt.Sprintf("localhost:%v", resource.GetPort("6379/tcp")),
+	})
+
+	_ = resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		return client.Ping(context.Background()).Err()
+	}))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	require.NoError(t, client.Set(ctx, "str", "foo", time.Hour).Err())
+	require.NoError(t, client.HSet(ctx, "hash", "a", "1").Err())
+	require.NoError(t, client.RPush(ctx, "list", "a", "b").Err())
+	require.NoError(t, client.SAdd(ctx, "set", "a").Err())
+	require.NoError(t, client.ZAdd(ctx, "zset", redis.Z{Member: "a", Score: 1.5}).Err())
+	require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{Stream: "stream", ID: "1-0", Values: map[string]any{"a": "1"}}).Err())
+
+	pConf, err := redisScanInputConfig().ParseYAML(fmt.Sprintf(`
+url: tcp://localhost:%v
+cursor_cache: foocache
+count: 1
+`, resource.GetPort("6379/tcp")), nil)
+	require.NoError(t, err)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+	in, err := newRedisScanInputFromConfig(pConf, mgr)
+	require.NoError(t, err)
+	require.NoError(t, in.Connect(ctx))
+
+	values := map[string]any{}
+	for {
+		msg, ackFn, err := in.Read(ctx)
+		if errors.Is(err, service.ErrEndOfInput) {
+			break
+		}
+		require.NoError(t, err)
+
+		structured, err := msg.AsStructured()
+		require.NoError(t, err)
+
+		obj := structured.(map[string]any)
+		key := obj["key"].(string)
+		values[key] = obj["value"]
+
+		keyType, _ := msg.MetaGetMut("redis_key_type")
+		assert.Equal(t, key, keyType)
+
+		_, hasTTL := msg.MetaGetMut("redis_key_ttl")
+		assert.Equal(t, key == "str", hasTTL, key)
+
+		require.NoError(t, ackFn(ctx, nil))
+	}
+	require.NoError(t, in.Close(ctx))
+
+	assert.Equal(t, map[string]any{
+		"str":  "foo",
+		"hash": map[string]any{"a": "1"},
+		"list": []any{"a", "b"},
+		"set":  []any{"a"},
+		"zset": []any{map[string]any{"member": "a", "score": 1.5}},
+		"stream": []any{map[string]any{
+			"id":     "1-0",
+			"values": map[string]any{"a": "1"},
+		}},
+	}, values)
+
+	require.NoError(t, mgr.AccessCache(ctx, "foocache", func(c service.Cache) {
+		cursor, err := c.Get(ctx, "redis_scan_cursor")
+		require.NoError(t, err)
+		assert.Equal(t, "0", string(cursor))
+	}))
+}